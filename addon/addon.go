@@ -7,8 +7,6 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"os"
 	"reflect"
-	"regexp"
-	"strconv"
 
 	"github.com/cloudflare/cfssl/log"
 	prometheusv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
@@ -49,12 +47,31 @@ var ChartFS embed.FS
 var Scheme = runtime.NewScheme()
 
 const ChartDir = "manifests/chart"
-const resourceRegex = "^(\\+|-)?(([0-9]+(\\.[0-9]*)?)|(\\.[0-9]+))(([KMGTPE]i)|[numkMGTPE]|([eE](\\+|-)?(([0-9]+(\\.[0-9]*)?)|(\\.[0-9]+))))?$"
 
 var addonLog = ctrl.Log.WithName("addon")
 
+// SearchCollectorImage is the collector image shipped with the operator. It is used unless the
+// Search CR sets a trusted spec.deployments.collector.imageOverride, see CollectorImage.
 var SearchCollectorImage string = os.Getenv("COLLECTOR_IMAGE")
 
+// SearchGetter returns the live Search CR, or nil when there is none. The addon reads the collector
+// image from it on every render, so an image override change needs no operator restart.
+type SearchGetter func(ctx context.Context) (*searchv1alpha1.Search, error)
+
+// CollectorImage returns the collector image for instance: spec.deployments.collector.imageOverride
+// when it is from a trusted registry, otherwise SearchCollectorImage. The operator logs an untrusted
+// override when it reconciles the hub collector.
+func CollectorImage(instance *searchv1alpha1.Search) string {
+	if instance == nil {
+		return SearchCollectorImage
+	}
+	override := instance.Spec.Deployments.Collector.ImageOverride
+	if override == "" || imagevalidation.ValidateImageRepo(override) != nil {
+		return SearchCollectorImage
+	}
+	return override
+}
+
 type GlobalValues struct {
 	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,"`
 	ImagePullSecret string            `json:"imagePullSecret"`
@@ -62,20 +79,10 @@ type GlobalValues struct {
 	ProxyConfig     map[string]string `json:"proxyConfig,"`
 }
 
-type UserArgs struct {
-	ContainerArgs  string `json:"containerArgs,"`
-	LimitMemory    string `json:"limitMemory,"`
-	RequestMemory  string `json:"requestMemory,"`
-	RediscoverRate int    `json:"rediscoverRate,"`
-	HeartBeat      int    `json:"heartBeat,"`
-	ReportRate     int    `json:"reportRate,"`
-}
-
 type Values struct {
 	GlobalValues           GlobalValues           `json:"global,"`
 	KubernetesDistribution string                 `json:"kubernetesDistribution"`
 	Prometheus             map[string]interface{} `json:"prometheus"`
-	UserArgs               UserArgs               `json:"userargs,"`
 }
 
 func getValue(cluster *clusterv1.ManagedCluster,
@@ -105,66 +112,75 @@ func getValue(cluster *clusterv1.ManagedCluster,
 	// enable prometheus if on OpenShift
 	addonValues.Prometheus["enabled"] = addonValues.KubernetesDistribution == "OpenShift"
 
-	if val, ok := addon.GetAnnotations()["addon.open-cluster-management.io/search_memory_limit"]; ok {
-		match, err := regexp.MatchString(resourceRegex, val)
-		if err != nil {
-			addonLog.Info("Error parsing memory limit for cluster %s", cluster.Name)
-		} else if match {
-			addonValues.UserArgs.LimitMemory = val
-		}
-	}
-	if val, ok := addon.GetAnnotations()["addon.open-cluster-management.io/search_memory_request"]; ok {
-		match, err := regexp.MatchString(resourceRegex, val)
-		if err != nil {
-			addonLog.Info("Error parsing memory request for cluster %s", cluster.Name)
-		} else if match {
-			addonValues.UserArgs.RequestMemory = val
-		}
+	values, err := addonfactory.JsonStructToValues(addonValues)
+	if err != nil {
+		return nil, err
 	}
-	if val, ok := addon.GetAnnotations()["addon.open-cluster-management.io/search_args"]; ok {
-		addonValues.UserArgs.ContainerArgs = val
+	settings, err := annotationSettingsValues(addon)
+	if err != nil {
+		return nil, err
 	}
-	if val, ok := addon.GetAnnotations()["addon.open-cluster-management.io/search_rediscover_rate"]; ok {
-		intVal, err := strconv.Atoi(val)
-		if err == nil {
-			addonValues.UserArgs.RediscoverRate = intVal
-		}
+	return addonfactory.MergeValues(values, settings), nil
+}
 
-	}
-	if val, ok := addon.GetAnnotations()["addon.open-cluster-management.io/search_heartbeat"]; ok {
-		intVal, err := strconv.Atoi(val)
-		if err == nil {
-			addonValues.UserArgs.HeartBeat = intVal
+// ImageVariable is the AddOnDeploymentConfig customized variable with the collector image. The
+// operator sets it on the AddOnDeploymentConfigs it creates for spec.collectorRollout, so a new
+// image reaches the clusters through the ClusterManagementAddOn rollout strategy.
+const ImageVariable = "search_collector_image"
+
+// collectorImageValues is registered as the last GetValuesFunc so that image overrides injected via
+// the per-cluster ManagedClusterAddOn values annotation are never used: the image is the
+// ImageVariable of the desired AddOnDeploymentConfig, or the CollectorImage of the live Search CR.
+// An ImageVariable that is not from a trusted registry is an error, which the addon framework
+// reports on the ManagedClusterAddOn.
+func collectorImageValues(getter utils.AddOnDeploymentConfigGetter, search SearchGetter) addonfactory.GetValuesFunc {
+	return func(_ *clusterv1.ManagedCluster,
+		addon *addonapiv1alpha1.ManagedClusterAddOn) (addonfactory.Values, error) {
+		instance, err := search(context.TODO())
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get the Search CR for the collector image: %w", err)
 		}
-	}
-	if val, ok := addon.GetAnnotations()["addon.open-cluster-management.io/search_report_rate"]; ok {
-		intVal, err := strconv.Atoi(val)
-		if err == nil {
-			addonValues.UserArgs.ReportRate = intVal
+		image := CollectorImage(instance)
+		config, err := utils.GetDesiredAddOnDeploymentConfig(addon, getter)
+		if err != nil {
+			return nil, err
 		}
+		if config != nil {
+			for _, variable := range config.Spec.CustomizedVariables {
+				if variable.Name != ImageVariable {
+					continue
+				}
+				if err := imagevalidation.ValidateImageRepo(variable.Value); err != nil {
+					return nil, fmt.Errorf("invalid %s in AddOnDeploymentConfig %s/%s: %w",
+						ImageVariable, config.Namespace, config.Name, err)
+				}
+				image = variable.Value
+			}
+		}
+		return addonfactory.Values{
+			"global": map[string]interface{}{
+				"imageOverrides": map[string]interface{}{
+					"search_collector": image,
+				},
+			},
+		}, nil
 	}
-
-	values, err := addonfactory.JsonStructToValues(addonValues)
-	if err != nil {
-		return nil, err
-	}
-	return values, nil
 }
 
-// validateImageOverride is registered as the last GetValuesFunc so that
-// image overrides injected via the per-cluster ManagedClusterAddOn values
-// annotation are validated against the trusted-registry allowlist. Any image
-// that does not originate from a trusted registry is replaced with the
-// operator-controlled SearchCollectorImage.
-func validateImageOverride(_ *clusterv1.ManagedCluster,
-	_ *addonapiv1alpha1.ManagedClusterAddOn) (addonfactory.Values, error) {
-	return addonfactory.Values{
-		"global": map[string]interface{}{
-			"imageOverrides": map[string]interface{}{
-				"search_collector": SearchCollectorImage,
-			},
-		},
-	}, nil
+// getValuesFuncs returns the chart values functions, lowest precedence first: the defaults and the
+// deprecated setting annotations, the values annotation, the AddOnDeploymentConfig, and the image.
+func getValuesFuncs(addonClient addonv1alpha1client.Interface, search SearchGetter) []addonfactory.GetValuesFunc {
+	getter := utils.NewAddOnDeploymentConfigGetter(addonClient)
+	return []addonfactory.GetValuesFunc{
+		getValue,
+		addonfactory.GetValuesFromAddonAnnotation,
+		addonfactory.GetAddOnDeploymentConfigValues(
+			getter,
+			addonfactory.ToAddOnNodePlacementValues,
+			addonfactory.ToAddOnResourceRequirementsValues,
+			toCollectorSettingsValues),
+		collectorImageValues(getter, search),
+	}
 }
 
 func newRegistrationOption(kubeClient kubernetes.Interface, addonName string) *agent.RegistrationOption {
@@ -228,7 +244,7 @@ func newRoleBindingForClusterRole(name, clusterRoleName, clusterName, addonName
 	}
 }
 
-func NewAddonManager(kubeConfig *rest.Config) (addonmanager.AddonManager, error) {
+func NewAddonManager(kubeConfig *rest.Config, search SearchGetter) (addonmanager.AddonManager, error) {
 	if SearchCollectorImage == "" {
 		return nil, fmt.Errorf("the search-collector pod image is empty")
 	}
@@ -255,15 +271,10 @@ func NewAddonManager(kubeConfig *rest.Config) (addonmanager.AddonManager, error)
 		WithScheme(Scheme).
 		WithConfigGVRs(
 			utils.AddOnDeploymentConfigGVR,
-		).WithGetValuesFuncs(
-		getValue,
-		addonfactory.GetValuesFromAddonAnnotation,
-		addonfactory.GetAddOnDeploymentConfigValues(
-			utils.NewAddOnDeploymentConfigGetter(addonClient),
-			addonfactory.ToAddOnNodePlacementValues,
-			addonfactory.ToAddOnResourceRequirementsValues),
-		validateImageOverride,
-	).WithAgentRegistrationOption(newRegistrationOption(kubeClient, SearchAddonName)).
+		).WithGetValuesFuncs(getValuesFuncs(addonClient, search)...).WithAgentRegistrationOption(newRegistrationOption(kubeClient, SearchAddonName)).
+		WithAgentHealthProber(newCollectorHealthProber()).
+		WithAgentHostedModeEnabledOption().
+		WithAgentHostedInfoFn(hostedModeInfo).
 		BuildHelmAgentAddon()
 	if err != nil {
 		klog.Errorf("failed to build agent %v", err)
@@ -273,14 +284,14 @@ func NewAddonManager(kubeConfig *rest.Config) (addonmanager.AddonManager, error)
 	return addonMgr, err
 }
 
-func startAddon(ctx context.Context) {
+func startAddon(ctx context.Context, search SearchGetter) {
 	controller := "controller: "
 	kubeConfig, err := ctrl.GetConfig()
 	if err != nil {
 		klog.Error(err, "unable to get kubeConfig , addon cannot be installed ", controller, "SearchOperator")
 		return
 	}
-	addonMgr, err := NewAddonManager(kubeConfig)
+	addonMgr, err := NewAddonManager(kubeConfig, search)
 	if err != nil {
 		klog.Error(err, " unable to create a new  addon manager ", controller, "SearchOperator")
 	} else {
@@ -294,16 +305,9 @@ func startAddon(ctx context.Context) {
 
 /*
 Addon needs to be started only at the first time when CR is created.
-No need to start every reconcile
+No need to start every reconcile: the addon reads the Search CR through search on every render.
 */
-func CreateAddonOnce(ctx context.Context, instance *searchv1alpha1.Search) {
+func CreateAddonOnce(ctx context.Context, search SearchGetter) {
 	log.Info("Starting Search Addon")
-	if override := instance.Spec.Deployments.Collector.ImageOverride; override != "" {
-		if err := imagevalidation.ValidateImageRepo(override); err != nil {
-			klog.Errorf("Ignoring invalid collector image override: %v", err)
-		} else {
-			SearchCollectorImage = override
-		}
-	}
-	go startAddon(ctx)
+	go startAddon(ctx, search)
 }
@@ -1,10 +1,12 @@
 package addon
 
 import (
-	"open-cluster-management.io/addon-framework/pkg/utils"
+	"context"
+	"strings"
 	"testing"
 
 	prometheusv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
@@ -26,6 +28,8 @@ var (
 	scheme       = runtime.NewScheme()
 	nodeSelector = map[string]string{"kubernetes.io/os": "linux"}
 	tolerations  = []corev1.Toleration{{Key: "foo", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute}}
+	// testSearch is the Search CR that the addon reads the collector image from.
+	testSearch *searchv1alpha1.Search
 )
 
 func init() {
@@ -58,18 +62,16 @@ func newAddon(name, cluster, installNamespace string, annotationValues map[strin
 
 func newAgentAddon(t *testing.T, objects []runtime.Object) agent.AgentAddon {
 	registrationOption := newRegistrationOption(nil, SearchAddonName)
-	getValuesFunc := getValue
 	fakeAddonClient := fakeaddon.NewSimpleClientset(objects...)
 	agentAddon, err := addonfactory.NewAgentAddonFactory(SearchAddonName, ChartFS, ChartDir).
 		WithScheme(scheme).
-		WithGetValuesFuncs(getValuesFunc, addonfactory.GetValuesFromAddonAnnotation,
-			addonfactory.GetAddOnDeploymentConfigValues(
-				utils.NewAddOnDeploymentConfigGetter(fakeAddonClient),
-				addonfactory.ToAddOnNodePlacementValues,
-				addonfactory.ToAddOnResourceRequirementsValues,
-			),
-			validateImageOverride).
+		WithGetValuesFuncs(getValuesFuncs(fakeAddonClient, func(context.Context) (*searchv1alpha1.Search, error) {
+			return testSearch, nil
+		})...).
 		WithAgentRegistrationOption(registrationOption).
+		WithAgentHealthProber(newCollectorHealthProber()).
+		WithAgentHostedModeEnabledOption().
+		WithAgentHostedInfoFn(hostedModeInfo).
 		BuildHelmAgentAddon()
 	if err != nil {
 		t.Fatalf("failed to build agent %v", err)
@@ -113,7 +115,7 @@ func TestManifest(t *testing.T) {
 			cluster: newCluster("cluster1"),
 			addon:   newAddon(SearchAddonName, "cluster1", "", annotationsTest),
 			// The annotation supplies quay.io/test/search_collector:test which is
-			// not from a trusted registry; validateImageOverride must reject it and
+			// not from a trusted registry; collectorImageValues must ignore it and
 			// keep the operator-controlled image.
 			expectedNamespace:      "open-cluster-management-agent-addon",
 			expectedImage:          "quay.io/stolostron/search_collector:2.7.0",
@@ -468,7 +470,7 @@ func TestManifestAddonAgent(t *testing.T) {
 				}
 
 				// The annotation supplies quay.io/test/search_collector:test which is
-				// not from a trusted registry; validateImageOverride must reject it.
+				// not from a trusted registry; collectorImageValues must ignore it.
 				if deployment.Spec.Template.Spec.Containers[0].Image != "quay.io/stolostron/search_collector:2.7.0" {
 					t.Errorf("unexpected image  %s", deployment.Spec.Template.Spec.Containers[0].Image)
 				}
@@ -626,7 +628,7 @@ func findSearchDeployment(objs []runtime.Object) *appsv1.Deployment {
 }
 
 // TestManifest_TrustedImageAllowed verifies that a trusted image supplied via
-// the values annotation is preserved (not replaced) by validateImageOverride.
+// the values annotation is still replaced by collectorImageValues.
 func TestManifest_TrustedImageAllowed(t *testing.T) {
 	trustedAnnotations := map[string]string{
 		"addon.open-cluster-management.io/values": `{"global":{"imageOverrides":{"search_collector":"quay.io/stolostron/search_collector:custom-tag"}}}`,
@@ -640,7 +642,7 @@ func TestManifest_TrustedImageAllowed(t *testing.T) {
 	}
 	for _, o := range objects {
 		if dep, ok := o.(*appsv1.Deployment); ok {
-			// validateImageOverride always re-pins to SearchCollectorImage regardless
+			// collectorImageValues always re-pins to SearchCollectorImage regardless
 			// of what the annotation says — the operator-controlled image is authoritative.
 			if dep.Spec.Template.Spec.Containers[0].Image != "quay.io/stolostron/search_collector:2.7.0" {
 				t.Errorf("expected operator image, got %s", dep.Spec.Template.Spec.Containers[0].Image)
@@ -648,3 +650,69 @@ func TestManifest_TrustedImageAllowed(t *testing.T) {
 		}
 	}
 }
+
+// TestManifest_RolloutImage verifies that the image in the AddOnDeploymentConfig created for a
+// collector rollout is used, and that an untrusted one is reported instead of deployed.
+func TestManifest_RolloutImage(t *testing.T) {
+	SearchCollectorImage = "quay.io/stolostron/search_collector:2.7.0"
+	tests := []struct {
+		name          string
+		image         string
+		expectedImage string
+		expectedErr   string
+	}{
+		{"rollout image", "quay.io/stolostron/search_collector:2.8.0", "quay.io/stolostron/search_collector:2.8.0", ""},
+		{"untrusted image", "quay.io/test/search_collector:test", "",
+			`invalid search_collector_image in AddOnDeploymentConfig cluster1/deploy-config: image "quay.io/test/search_collector:test" is not from a trusted registry`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			agentAddon := newAgentAddon(t, []runtime.Object{newDeploymentConfig(map[string]string{ImageVariable: test.image})})
+			objects, err := agentAddon.Manifests(newCluster("cluster1"), newConfiguredAddon(nil))
+			if test.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+					t.Fatalf("expected error containing %q, but got %v", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to get manifests: %v", err)
+			}
+			deployment := findSearchDeployment(objects)
+			if deployment == nil {
+				t.Fatalf("expected deployment, but failed")
+			}
+			if image := deployment.Spec.Template.Spec.Containers[0].Image; image != test.expectedImage {
+				t.Errorf("expected image %s, but got %s", test.expectedImage, image)
+			}
+		})
+	}
+}
+
+// TestManifest_SearchImageOverride verifies that the image override of the live Search CR is used
+// on every render, and that an untrusted one falls back to the operator image.
+func TestManifest_SearchImageOverride(t *testing.T) {
+	SearchCollectorImage = "quay.io/stolostron/search_collector:2.7.0"
+	testSearch = &searchv1alpha1.Search{}
+	defer func() { testSearch = nil }()
+	agentAddon := newAgentAddon(t, nil)
+
+	for _, test := range []struct{ override, expectedImage string }{
+		{"", "quay.io/stolostron/search_collector:2.7.0"},
+		{"quay.io/stolostron/search_collector:2.8.0", "quay.io/stolostron/search_collector:2.8.0"},
+		{"quay.io/test/search_collector:test", "quay.io/stolostron/search_collector:2.7.0"},
+	} {
+		testSearch.Spec.Deployments.Collector.ImageOverride = test.override
+		objects, err := agentAddon.Manifests(newCluster("cluster1"), newAddon(SearchAddonName, "cluster1", "", nil))
+		if err != nil {
+			t.Fatalf("failed to get manifests: %v", err)
+		}
+		deployment := findSearchDeployment(objects)
+		if deployment == nil {
+			t.Fatalf("expected deployment, but failed")
+		}
+		if image := deployment.Spec.Template.Spec.Containers[0].Image; image != test.expectedImage {
+			t.Errorf("override %q: expected image %s, but got %s", test.override, test.expectedImage, image)
+		}
+	}
+}
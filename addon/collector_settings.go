@@ -0,0 +1,130 @@
+package addon
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"open-cluster-management.io/addon-framework/pkg/addonfactory"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+)
+
+// Collector settings. Each one is set as an AddOnDeploymentConfig customizedVariable with this
+// name, so it can be placed on cluster sets through the ClusterManagementAddOn placements, or on
+// one cluster through the ManagedClusterAddOn configs.
+const (
+	SettingMemoryLimit    = "search_memory_limit"
+	SettingMemoryRequest  = "search_memory_request"
+	SettingArgs           = "search_args"
+	SettingRediscoverRate = "search_rediscover_rate"
+	SettingHeartbeat      = "search_heartbeat"
+	SettingReportRate     = "search_report_rate"
+)
+
+// settingAnnotationPrefix is the prefix of the deprecated ManagedClusterAddOn annotations that
+// carried the settings before AddOnDeploymentConfig, e.g.
+// addon.open-cluster-management.io/search_memory_limit. They are still read, with the same
+// validation, and the AddOnDeploymentConfig takes precedence.
+const settingAnnotationPrefix = "addon.open-cluster-management.io/"
+
+// collectorSettings maps each setting to its chart value under userargs and its parser, in the
+// order errors are reported.
+var collectorSettings = []struct {
+	name  string
+	value string
+	parse func(string) (interface{}, error)
+}{
+	{SettingMemoryLimit, "limitMemory", parseMemory},
+	{SettingMemoryRequest, "requestMemory", parseMemory},
+	{SettingArgs, "containerArgs", parseArgs},
+	{SettingRediscoverRate, "rediscoverRate", parseMilliseconds},
+	{SettingHeartbeat, "heartBeat", parseMilliseconds},
+	{SettingReportRate, "reportRate", parseMilliseconds},
+}
+
+// collectorSettingsValues returns the userargs chart values for the settings that are set. An
+// invalid setting fails the manifests for the cluster, so the error is reported in the
+// ManagedClusterAddOn ManifestApplied condition instead of the previous value being dropped.
+func collectorSettingsValues(settings map[string]string, source string) (addonfactory.Values, error) {
+	userArgs := map[string]interface{}{}
+	var invalid []string
+	for _, s := range collectorSettings {
+		raw, ok := settings[s.name]
+		if !ok {
+			continue
+		}
+		v, err := s.parse(raw)
+		if err != nil {
+			invalid = append(invalid, fmt.Sprintf("%s: %v", s.name, err))
+			continue
+		}
+		userArgs[s.value] = v
+	}
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid collector settings in %s: %s", source, strings.Join(invalid, "; "))
+	}
+	if len(userArgs) == 0 {
+		return addonfactory.Values{}, nil
+	}
+	limit, hasLimit := userArgs["limitMemory"].(string)
+	request, hasRequest := userArgs["requestMemory"].(string)
+	if hasLimit && hasRequest {
+		limitQ, requestQ := resource.MustParse(limit), resource.MustParse(request)
+		if requestQ.Cmp(limitQ) > 0 {
+			return nil, fmt.Errorf("invalid collector settings in %s: %s %s is greater than %s %s",
+				source, SettingMemoryRequest, request, SettingMemoryLimit, limit)
+		}
+	}
+	return addonfactory.Values{"userargs": userArgs}, nil
+}
+
+// annotationSettingsValues reads the deprecated setting annotations of the ManagedClusterAddOn.
+func annotationSettingsValues(addon *addonapiv1alpha1.ManagedClusterAddOn) (addonfactory.Values, error) {
+	settings := map[string]string{}
+	for _, s := range collectorSettings {
+		if val, ok := addon.GetAnnotations()[settingAnnotationPrefix+s.name]; ok {
+			settings[s.name] = val
+		}
+	}
+	return collectorSettingsValues(settings, "ManagedClusterAddOn annotations")
+}
+
+// toCollectorSettingsValues is an addonfactory.AddOnDeploymentConfigToValuesFunc for the settings
+// in the customizedVariables. Variables with other names are left to the other functions.
+func toCollectorSettingsValues(config addonapiv1alpha1.AddOnDeploymentConfig) (addonfactory.Values, error) {
+	settings := map[string]string{}
+	for _, v := range config.Spec.CustomizedVariables {
+		settings[v.Name] = v.Value
+	}
+	return collectorSettingsValues(settings,
+		fmt.Sprintf("AddOnDeploymentConfig %s/%s", config.Namespace, config.Name))
+}
+
+func parseMemory(val string) (interface{}, error) {
+	q, err := resource.ParseQuantity(val)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a quantity, e.g. 512Mi", val)
+	}
+	if q.Sign() <= 0 {
+		return nil, fmt.Errorf("%q must be greater than zero", val)
+	}
+	return val, nil
+}
+
+// parseArgs accepts the collector arguments as one string. The chart renders it as a single-quoted
+// YAML scalar, so quotes and newlines are rejected.
+func parseArgs(val string) (interface{}, error) {
+	if strings.ContainsAny(val, "'\"\n\r") {
+		return nil, fmt.Errorf("%q must not contain quotes or newlines", val)
+	}
+	return val, nil
+}
+
+func parseMilliseconds(val string) (interface{}, error) {
+	n, err := strconv.Atoi(val)
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("%q is not a positive number of milliseconds", val)
+	}
+	return n, nil
+}
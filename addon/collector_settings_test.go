@@ -0,0 +1,144 @@
+package addon
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+)
+
+// newConfiguredAddon returns a ManagedClusterAddOn in cluster1 that uses the AddOnDeploymentConfig
+// cluster1/deploy-config.
+func newConfiguredAddon(annotations map[string]string) *addonapiv1alpha1.ManagedClusterAddOn {
+	addon := newAddon(SearchAddonName, "cluster1", "", annotations)
+	addon.Status.ConfigReferences = []addonapiv1alpha1.ConfigReference{{
+		ConfigGroupResource: addonapiv1alpha1.ConfigGroupResource{
+			Group:    "addon.open-cluster-management.io",
+			Resource: "addondeploymentconfigs",
+		},
+		DesiredConfig: &addonapiv1alpha1.ConfigSpecHash{
+			SpecHash:       "asdf",
+			ConfigReferent: addonapiv1alpha1.ConfigReferent{Namespace: "cluster1", Name: "deploy-config"},
+		},
+	}}
+	return addon
+}
+
+func newDeploymentConfig(variables map[string]string) *addonapiv1alpha1.AddOnDeploymentConfig {
+	config := &addonapiv1alpha1.AddOnDeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "deploy-config", Namespace: "cluster1"},
+	}
+	for name, value := range variables {
+		config.Spec.CustomizedVariables = append(config.Spec.CustomizedVariables,
+			addonapiv1alpha1.CustomizedVariable{Name: name, Value: value})
+	}
+	return config
+}
+
+func envValue(container corev1.Container, name string) string {
+	for _, env := range container.Env {
+		if env.Name == name {
+			return env.Value
+		}
+	}
+	return ""
+}
+
+func TestCollectorSettings_AddOnDeploymentConfig(t *testing.T) {
+	SearchCollectorImage = "quay.io/stolostron/search_collector:2.7.0"
+	agentAddon := newAgentAddon(t, []runtime.Object{newDeploymentConfig(map[string]string{
+		SettingMemoryLimit:    "1500Mi",
+		SettingArgs:           "--v=3",
+		SettingHeartbeat:      "5000",
+		SettingReportRate:     "6000",
+		"someOtherAddonValue": "ignored",
+	})})
+	// The deprecated annotations still apply where the AddOnDeploymentConfig sets nothing.
+	addon := newConfiguredAddon(map[string]string{
+		settingAnnotationPrefix + SettingMemoryLimit:    "2000Mi",
+		settingAnnotationPrefix + SettingMemoryRequest:  "1000Mi",
+		settingAnnotationPrefix + SettingRediscoverRate: "4000",
+		settingAnnotationPrefix + SettingHeartbeat:      "3000",
+	})
+
+	objects, err := agentAddon.Manifests(newCluster("cluster1"), addon)
+	if err != nil {
+		t.Fatalf("failed to get manifests: %v", err)
+	}
+	deployment := findSearchDeployment(objects)
+	if deployment == nil {
+		t.Fatalf("expected deployment, but failed")
+	}
+	container := deployment.Spec.Template.Spec.Containers[0]
+	if got := container.Resources.Limits.Memory().String(); got != "1500Mi" {
+		t.Errorf("expected memory limit 1500Mi, but got %s", got)
+	}
+	if got := container.Resources.Requests.Memory().String(); got != "1000Mi" {
+		t.Errorf("expected memory request 1000Mi, but got %s", got)
+	}
+	if len(container.Args) != 1 || container.Args[0] != "--v=3" {
+		t.Errorf("expected args [--v=3], but got %v", container.Args)
+	}
+	for name, want := range map[string]string{"REDISCOVER_RATE_MS": "4000", "HEARTBEAT_MS": "5000", "REPORT_RATE_MS": "6000"} {
+		if got := envValue(container, name); got != want {
+			t.Errorf("expected %s=%s, but got %q", name, want, got)
+		}
+	}
+}
+
+func TestCollectorSettings_Invalid(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		variables   map[string]string
+		expectedErr string
+	}{
+		{
+			name:        "annotation memory",
+			annotations: map[string]string{settingAnnotationPrefix + SettingMemoryLimit: "lots"},
+			expectedErr: `invalid collector settings in ManagedClusterAddOn annotations: search_memory_limit: "lots" is not a quantity`,
+		},
+		{
+			name:        "annotation rate",
+			annotations: map[string]string{settingAnnotationPrefix + SettingReportRate: "-5"},
+			expectedErr: `search_report_rate: "-5" is not a positive number of milliseconds`,
+		},
+		{
+			name: "variables",
+			variables: map[string]string{
+				SettingHeartbeat: "soon",
+				SettingArgs:      "--v=2'\n",
+			},
+			expectedErr: `invalid collector settings in AddOnDeploymentConfig cluster1/deploy-config: ` +
+				`search_args: "--v=2'\n" must not contain quotes or newlines; ` +
+				`search_heartbeat: "soon" is not a positive number of milliseconds`,
+		},
+		{
+			name:        "request above limit",
+			variables:   map[string]string{SettingMemoryLimit: "1Gi", SettingMemoryRequest: "2Gi"},
+			expectedErr: "search_memory_request 2Gi is greater than search_memory_limit 1Gi",
+		},
+		{
+			name:        "zero memory",
+			variables:   map[string]string{SettingMemoryRequest: "0"},
+			expectedErr: `search_memory_request: "0" must be greater than zero`,
+		},
+	}
+
+	SearchCollectorImage = "quay.io/stolostron/search_collector:2.7.0"
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			agentAddon := newAgentAddon(t, []runtime.Object{newDeploymentConfig(test.variables)})
+			_, err := agentAddon.Manifests(newCluster("cluster1"), newConfiguredAddon(test.annotations))
+			if err == nil {
+				t.Fatalf("expected error %q, but got none", test.expectedErr)
+			}
+			if !strings.Contains(err.Error(), test.expectedErr) {
+				t.Errorf("expected error containing %q, but got %q", test.expectedErr, err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package addon
+
+import (
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"open-cluster-management.io/addon-framework/pkg/addonmanager/constants"
+	"open-cluster-management.io/addon-framework/pkg/agent"
+	"open-cluster-management.io/addon-framework/pkg/utils"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+const (
+	// CollectorDeploymentName is the name of the collector Deployment on the managed cluster, set by
+	// fullnameOverride in the chart values.
+	CollectorDeploymentName = "klusterlet-addon-search"
+
+	// CollectorSyncedCondition is the condition the collector sets on its own Deployment. Its
+	// lastUpdateTime is the last time the collector synced with the indexer on the hub.
+	CollectorSyncedCondition = "SearchIndexerSynced"
+
+	// collectorSyncTimeout is how long the collector may go without syncing before the addon is
+	// reported unavailable.
+	collectorSyncTimeout = 10 * time.Minute
+
+	lastSyncTimeField = "lastSyncTime"
+)
+
+// now is replaced in tests.
+var now = time.Now
+
+// newCollectorHealthProber probes the collector Deployment through the ManifestWork status
+// feedback, so the ManagedClusterAddOn Available condition reflects the collector instead of only
+// the lease: the Deployment must have a ready replica (the collector's readiness probe), and it
+// must have synced with the indexer within collectorSyncTimeout.
+func newCollectorHealthProber() *agent.HealthProber {
+	return &agent.HealthProber{
+		Type: agent.HealthProberTypeWork,
+		WorkProber: &agent.WorkHealthProber{
+			ProbeFields: []agent.ProbeField{{
+				ResourceIdentifier: workapiv1.ResourceIdentifier{
+					Group:     appsv1.GroupName,
+					Resource:  "deployments",
+					Name:      CollectorDeploymentName,
+					Namespace: "*", // the install namespace can be set per cluster
+				},
+				ProbeRules: []workapiv1.FeedbackRule{
+					{Type: workapiv1.WellKnownStatusType},
+					{Type: workapiv1.JSONPathsType, JsonPaths: []workapiv1.JsonPath{{
+						Name: lastSyncTimeField,
+						Path: fmt.Sprintf(`.conditions[?(@.type=="%s")].lastUpdateTime`, CollectorSyncedCondition),
+					}}},
+				},
+			}},
+			HealthChecker: collectorHealthCheck,
+		},
+	}
+}
+
+// collectorHealthCheck is the agent.AddonHealthCheckerFunc for newCollectorHealthProber. A
+// collector that does not set CollectorSyncedCondition is only checked for readiness.
+//
+// In hosted mode the collector Deployment is in a ManifestWork on the hosting cluster, which the
+// addon framework does not probe, so there is never a result for it. Hosted addons are available
+// once their ManifestWork on the managed cluster is applied.
+func collectorHealthCheck(results []agent.FieldResult, cluster *clusterv1.ManagedCluster,
+	addon *addonapiv1alpha1.ManagedClusterAddOn) error {
+	if mode, _ := hostedModeInfo(addon, cluster); mode == constants.InstallModeHosted {
+		return nil
+	}
+	if len(results) == 0 {
+		return fmt.Errorf("no status is reported for the %s deployment", CollectorDeploymentName)
+	}
+	for _, result := range results {
+		if err := utils.WorkloadAvailabilityHealthCheck(result.ResourceIdentifier, result.FeedbackResult); err != nil {
+			return err
+		}
+		for _, value := range result.FeedbackResult.Values {
+			if value.Name != lastSyncTimeField || value.Value.String == nil {
+				continue
+			}
+			lastSync, err := time.Parse(time.RFC3339, *value.Value.String)
+			if err != nil {
+				return fmt.Errorf("the collector reported an invalid %s %q", lastSyncTimeField, *value.Value.String)
+			}
+			if age := now().Sub(lastSync); age > collectorSyncTimeout {
+				return fmt.Errorf("the collector has not synced with the indexer since %s (%s ago)",
+					*value.Value.String, age.Round(time.Second))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package addon
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"open-cluster-management.io/addon-framework/pkg/agent"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+func collectorFeedback(ready int64, lastSyncTime string) agent.FieldResult {
+	replicas := int64(1)
+	values := []workapiv1.FeedbackValue{
+		{Name: "Replicas", Value: workapiv1.FieldValue{Type: workapiv1.Integer, Integer: &replicas}},
+		{Name: "ReadyReplicas", Value: workapiv1.FieldValue{Type: workapiv1.Integer, Integer: &ready}},
+	}
+	if lastSyncTime != "" {
+		values = append(values, workapiv1.FeedbackValue{Name: lastSyncTimeField,
+			Value: workapiv1.FieldValue{Type: workapiv1.String, String: &lastSyncTime}})
+	}
+	return agent.FieldResult{
+		ResourceIdentifier: workapiv1.ResourceIdentifier{Group: "apps", Resource: "deployments",
+			Name: CollectorDeploymentName, Namespace: "open-cluster-management-agent-addon"},
+		FeedbackResult: workapiv1.StatusFeedbackResult{Values: values},
+	}
+}
+
+func TestCollectorHealthCheck(t *testing.T) {
+	current := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+
+	tests := []struct {
+		name        string
+		results     []agent.FieldResult
+		expectedErr string
+	}{
+		{"synced", []agent.FieldResult{collectorFeedback(1, "2026-03-01T11:58:00Z")}, ""},
+		{"collector without sync condition", []agent.FieldResult{collectorFeedback(1, "")}, ""},
+		{"no feedback", nil, "no status is reported for the klusterlet-addon-search deployment"},
+		{"not ready", []agent.FieldResult{collectorFeedback(0, "2026-03-01T11:58:00Z")},
+			"desiredNumberReplicas is 1 but readyReplica is 0"},
+		{"stale sync", []agent.FieldResult{collectorFeedback(1, "2026-03-01T11:30:00Z")},
+			"the collector has not synced with the indexer since 2026-03-01T11:30:00Z (30m0s ago)"},
+		{"invalid sync time", []agent.FieldResult{collectorFeedback(1, "yesterday")},
+			`the collector reported an invalid lastSyncTime "yesterday"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := collectorHealthCheck(test.results, newCluster("cluster1"), newAddon(SearchAddonName, "cluster1", "", nil))
+			if test.expectedErr == "" {
+				if err != nil {
+					t.Errorf("expected healthy, but got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+				t.Errorf("expected error containing %q, but got %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestCollectorHealthProber(t *testing.T) {
+	prober := newCollectorHealthProber()
+	if prober.Type != agent.HealthProberTypeWork {
+		t.Fatalf("expected prober type %s, but got %s", agent.HealthProberTypeWork, prober.Type)
+	}
+	fields := prober.WorkProber.ProbeFields
+	if len(fields) != 1 || fields[0].ResourceIdentifier.Name != CollectorDeploymentName {
+		t.Fatalf("expected one probe field for %s, but got %v", CollectorDeploymentName, fields)
+	}
+	rules := fields[0].ProbeRules
+	if len(rules) != 2 || rules[1].JsonPaths[0].Path != `.conditions[?(@.type=="SearchIndexerSynced")].lastUpdateTime` {
+		t.Errorf("unexpected probe rules %v", rules)
+	}
+}
@@ -0,0 +1,31 @@
+package addon
+
+import (
+	"open-cluster-management.io/addon-framework/pkg/addonmanager/constants"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+)
+
+const (
+	// klusterletDeployModeAnnotation and hostingClusterNameAnnotation are set on the ManagedCluster
+	// when its klusterlet is imported in hosted mode, as for HyperShift hosted clusters.
+	klusterletDeployModeAnnotation = "import.open-cluster-management.io/klusterlet-deploy-mode"
+	hostingClusterNameAnnotation   = "import.open-cluster-management.io/hosting-cluster-name"
+)
+
+// hostedModeInfo returns the install mode of the addon and its hosting cluster. The addon runs in
+// hosted mode when its ManagedClusterAddOn names a hosting cluster, like the addon framework default,
+// or else when the klusterlet of the cluster is hosted. The chart then deploys the collector on the
+// hosting cluster, see search.hosted in the chart helpers.
+func hostedModeInfo(addon *addonapiv1alpha1.ManagedClusterAddOn, cluster *clusterv1.ManagedCluster) (string, string) {
+	if mode, hostingCluster := constants.GetHostedModeInfo(addon, cluster); mode == constants.InstallModeHosted {
+		return mode, hostingCluster
+	}
+	if cluster == nil || cluster.Annotations[klusterletDeployModeAnnotation] != "Hosted" {
+		return constants.InstallModeDefault, ""
+	}
+	if hostingCluster := cluster.Annotations[hostingClusterNameAnnotation]; hostingCluster != "" {
+		return constants.InstallModeHosted, hostingCluster
+	}
+	return constants.InstallModeDefault, ""
+}
@@ -0,0 +1,193 @@
+package addon
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"open-cluster-management.io/addon-framework/pkg/addonmanager/constants"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+)
+
+func TestHostedModeInfo(t *testing.T) {
+	hostedCluster := func(annotations map[string]string) *clusterv1.ManagedCluster {
+		cluster := newCluster("cluster1")
+		cluster.Annotations = annotations
+		return cluster
+	}
+	tests := []struct {
+		name            string
+		addon           *addonapiv1alpha1.ManagedClusterAddOn
+		cluster         *clusterv1.ManagedCluster
+		expectedMode    string
+		expectedHosting string
+	}{
+		{"default", newAddon(SearchAddonName, "cluster1", "", nil), newCluster("cluster1"),
+			constants.InstallModeDefault, ""},
+		{"addon annotation",
+			newAddon(SearchAddonName, "cluster1", "", map[string]string{
+				addonapiv1alpha1.HostingClusterNameAnnotationKey: "hosting"}),
+			newCluster("cluster1"), constants.InstallModeHosted, "hosting"},
+		{"hosted klusterlet", newAddon(SearchAddonName, "cluster1", "", nil),
+			hostedCluster(map[string]string{klusterletDeployModeAnnotation: "Hosted", hostingClusterNameAnnotation: "hosting"}),
+			constants.InstallModeHosted, "hosting"},
+		{"hosted klusterlet without hosting cluster", newAddon(SearchAddonName, "cluster1", "", nil),
+			hostedCluster(map[string]string{klusterletDeployModeAnnotation: "Hosted"}),
+			constants.InstallModeDefault, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mode, hosting := hostedModeInfo(test.addon, test.cluster)
+			if mode != test.expectedMode || hosting != test.expectedHosting {
+				t.Errorf("expected %s on %q, but got %s on %q", test.expectedMode, test.expectedHosting, mode, hosting)
+			}
+		})
+	}
+}
+
+func hostedManifestLocation(obj runtime.Object) string {
+	accessor, _ := meta.Accessor(obj)
+	return accessor.GetAnnotations()[addonapiv1alpha1.HostedManifestLocationAnnotationKey]
+}
+
+// grantsDeploymentStatus reports whether the collector ClusterRole lets the collector set
+// SearchIndexerSynced on its Deployment.
+func grantsDeploymentStatus(t *testing.T, objects []runtime.Object) bool {
+	for _, obj := range objects {
+		role, ok := obj.(*rbacv1.ClusterRole)
+		if !ok {
+			continue
+		}
+		for _, rule := range role.Rules {
+			for _, resource := range rule.Resources {
+				if resource == "deployments/status" {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	t.Fatalf("expected the collector ClusterRole in the manifests")
+	return false
+}
+
+func TestManifest_Hosted(t *testing.T) {
+	SearchCollectorImage = "quay.io/stolostron/search_collector:2.7.0"
+	agentAddon := newAgentAddon(t, nil)
+	addon := newAddon(SearchAddonName, "cluster1", "klusterlet-cluster1", map[string]string{
+		addonapiv1alpha1.HostingClusterNameAnnotationKey: "hosting",
+	})
+
+	objects, err := agentAddon.Manifests(newCluster("cluster1"), addon)
+	if err != nil {
+		t.Fatalf("failed to get manifests: %v", err)
+	}
+	locations := map[string][]string{}
+	for _, obj := range objects {
+		kind := obj.GetObjectKind().GroupVersionKind().Kind
+		switch obj.(type) {
+		case *appsv1.Deployment:
+			kind = "Deployment"
+		case *corev1.ServiceAccount:
+			kind = "ServiceAccount"
+		case *corev1.Namespace:
+			kind = "Namespace"
+		case *networkingv1.NetworkPolicy:
+			kind = "NetworkPolicy"
+		}
+		locations[kind] = append(locations[kind], hostedManifestLocation(obj))
+	}
+	for kind, expected := range map[string][]string{
+		"Deployment":     {"hosting"},
+		"ServiceAccount": {"", "hosting"},
+		"Namespace":      {""},
+		"NetworkPolicy":  {"hosting"},
+	} {
+		if len(locations[kind]) != len(expected) {
+			t.Errorf("expected %s at %v, but got %v", kind, expected, locations[kind])
+			continue
+		}
+		for i := range expected {
+			if locations[kind][i] != expected[i] {
+				t.Errorf("expected %s at %v, but got %v", kind, expected, locations[kind])
+			}
+		}
+	}
+
+	deployment := findSearchDeployment(objects)
+	container := deployment.Spec.Template.Spec.Containers[0]
+	if got := envValue(container, "KUBECONFIG"); got != "/managedconfig/kubeconfig" {
+		t.Errorf("expected KUBECONFIG /managedconfig/kubeconfig, but got %q", got)
+	}
+	var secret string
+	for _, volume := range deployment.Spec.Template.Spec.Volumes {
+		if volume.Name == "managed-config" && volume.Secret != nil {
+			secret = volume.Secret.SecretName
+		}
+	}
+	if secret != "search-collector-managed-kubeconfig" {
+		t.Errorf("expected the managed kubeconfig secret search-collector-managed-kubeconfig, but got %q", secret)
+	}
+	// The ClusterRole is on the managed cluster, where there is no collector Deployment.
+	if grantsDeploymentStatus(t, objects) {
+		t.Errorf("unexpected deployments/status grant in hosted mode")
+	}
+}
+
+func TestManifest_DefaultModeNotHosted(t *testing.T) {
+	SearchCollectorImage = "quay.io/stolostron/search_collector:2.7.0"
+	agentAddon := newAgentAddon(t, nil)
+
+	objects, err := agentAddon.Manifests(newCluster("cluster1"), newAddon(SearchAddonName, "cluster1", "", nil))
+	if err != nil {
+		t.Fatalf("failed to get manifests: %v", err)
+	}
+	for _, obj := range objects {
+		if _, ok := obj.(*corev1.Namespace); ok {
+			t.Errorf("unexpected Namespace in default mode")
+		}
+		if location := hostedManifestLocation(obj); location != "" {
+			t.Errorf("unexpected hosted manifest location %q on %T", location, obj)
+		}
+	}
+	if got := envValue(findSearchDeployment(objects).Spec.Template.Spec.Containers[0], "KUBECONFIG"); got != "" {
+		t.Errorf("unexpected KUBECONFIG %q in default mode", got)
+	}
+	if !grantsDeploymentStatus(t, objects) {
+		t.Errorf("expected the deployments/status grant in default mode")
+	}
+}
+
+func TestCollectorHealthCheck_Hosted(t *testing.T) {
+	agentAddon := newAgentAddon(t, nil)
+	healthChecker := agentAddon.GetAgentAddonOptions().HealthProber.WorkProber.HealthChecker
+	hostedCluster := newCluster("cluster1")
+	hostedCluster.Annotations = map[string]string{klusterletDeployModeAnnotation: "Hosted", hostingClusterNameAnnotation: "hosting"}
+
+	tests := []struct {
+		name    string
+		cluster *clusterv1.ManagedCluster
+		addon   *addonapiv1alpha1.ManagedClusterAddOn
+	}{
+		{"addon annotation", newCluster("cluster1"), newAddon(SearchAddonName, "cluster1", "klusterlet-cluster1",
+			map[string]string{addonapiv1alpha1.HostingClusterNameAnnotationKey: "hosting"})},
+		{"hosted klusterlet", hostedCluster, newAddon(SearchAddonName, "cluster1", "klusterlet-cluster1", nil)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// The framework finds no feedback for the Deployment, which is on the hosting cluster.
+			if err := healthChecker(nil, test.cluster, test.addon); err != nil {
+				t.Errorf("expected the hosted addon to be healthy, but got %v", err)
+			}
+		})
+	}
+
+	if err := healthChecker(nil, newCluster("cluster1"), newAddon(SearchAddonName, "cluster1", "", nil)); err == nil {
+		t.Errorf("expected an error without feedback in default mode")
+	}
+}
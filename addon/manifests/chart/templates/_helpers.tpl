@@ -24,6 +24,16 @@ If release name contains chart name it will be used as a full name.
 {{- end -}}
 {{- end -}}
 
+{{/*
+Render "true" when the addon runs in hosted mode: the collector runs on the hosting cluster and
+watches the managed cluster through the managed kubeconfig Secret.
+*/}}
+{{- define "search.hosted" -}}
+{{- if eq (default "Default" .Values.installMode) "Hosted" -}}
+true
+{{- end -}}
+{{- end -}}
+
 {{/*
 Create chart name and version as used by the chart label.
 */}}
@@ -0,0 +1,158 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1alpha1
+
+import (
+	"slices"
+)
+
+// DefaultProtectedResources are the resources that exclude rules must not target unless the
+// Search CR's collectorConfigPolicy overrides them.
+//
+// The kinds are the ones the search RBAC engine depends on. Excluding them would break
+// per-cluster and namespace-scoped access control:
+//   - ManagedCluster: used to scope search results to clusters a user can access
+//   - Namespace: used to scope search results to namespaces a user can access
+//
+// ManagedClusterSet and ManagedClusterSetBinding are NOT listed here because the RBAC engine does
+// not query them directly — they are used by placement/policy, not search.
+//
+// The groups started as a broad safety net covering everything integration teams (CNV, OLM, GRC,
+// Argo, Kyverno, etc.) and Search itself depend on. As of ACM-37052, most of those teams now have
+// a real labeled CollectorConfig (search.open-cluster-management.io/config-type: integration,
+// shipped from config/integration_collector_configs/ — see IntegrationCollectorConfigSeeder), so
+// the dynamic check (validateExcludeAgainstIntegrationConfigs) already protects their apiGroups
+// via those configs' include rules. What remains here are groups with no single integration-team
+// owner, or that Search itself depends on directly.
+var DefaultProtectedResources = []ProtectedResource{
+	{APIGroup: "cluster.open-cluster-management.io", Kind: "ManagedCluster", Severity: ProtectionSeverityReject},
+	{APIGroup: "", Kind: "Namespace", Severity: ProtectionSeverityReject},
+	// Core group — ConfigMap, Job, Node, Pod, PVC needed by CNV / console / multiple consumers.
+	{APIGroup: "", Severity: ProtectionSeverityReject},
+	// OpenShift cluster config — no single integration-team owner.
+	{APIGroup: "config.openshift.io", Severity: ProtectionSeverityReject},
+	// OpenShift templates — no single integration-team owner.
+	{APIGroup: "template.openshift.io", Severity: ProtectionSeverityReject},
+	// Admission / webhook configs — no single integration-team owner.
+	{APIGroup: "admissionregistration.k8s.io", Severity: ProtectionSeverityReject},
+	// ACM hub operator — Search itself depends on this.
+	{APIGroup: "operator.open-cluster-management.io", Severity: ProtectionSeverityReject},
+	// ACM Search itself.
+	{APIGroup: "search.open-cluster-management.io", Severity: ProtectionSeverityReject},
+}
+
+// rbacProtectedKinds maps the kinds the search RBAC engine depends on to their apiGroup. They are
+// always rejected, whatever severity the policy gives them.
+var rbacProtectedKinds = map[string]string{
+	"ManagedCluster": "cluster.open-cluster-management.io",
+	"Namespace":      "", // core group
+}
+
+func isRBACProtected(r ProtectedResource) bool {
+	group, ok := rbacProtectedKinds[r.Kind]
+	return ok && group == r.APIGroup
+}
+
+// EffectiveProtectedResources returns DefaultProtectedResources with the policy's entries applied:
+// an entry replaces the default with the same apiGroup and kind, or is added after the defaults.
+func (p CollectorConfigPolicy) EffectiveProtectedResources() []ProtectedResource {
+	resources := slices.Clone(DefaultProtectedResources)
+	for _, entry := range p.ProtectedResources {
+		if entry.Severity == "" || isRBACProtected(entry) {
+			entry.Severity = ProtectionSeverityReject
+		}
+		i := slices.IndexFunc(resources, func(r ProtectedResource) bool {
+			return r.APIGroup == entry.APIGroup && r.Kind == entry.Kind
+		})
+		if i >= 0 {
+			resources[i] = entry
+		} else {
+			resources = append(resources, entry)
+		}
+	}
+	return resources
+}
+
+// ProtectionViolation is an exclude rule that targets a protected resource.
+type ProtectionViolation struct {
+	// Field is the resourceSelector field the violation is reported on: "kinds" or "apiGroups".
+	Field    string
+	Value    string
+	Message  string
+	Severity ProtectionSeverity
+}
+
+// ProtectionViolations returns the protected resources that an exclude rule's resourceSelector
+// targets:
+//   - a protected kind, by name or with a wildcard kind on its apiGroup, even when the rule is
+//     narrowed by a label or annotation selector
+//   - a protected apiGroup, unless the rule is narrowed by a label or annotation selector
+//
+// The all-groups wildcard ("*") is only matched against protected kinds here; validateExcludeRule
+// rejects it on its own.
+func ProtectionViolations(sel ResourceSelector, protected []ProtectedResource) []ProtectionViolation {
+	var violations []ProtectionViolation
+	for _, kind := range sel.Kinds {
+		for _, r := range protected {
+			if r.Kind == "" || (kind != "*" && kind != r.Kind) {
+				continue
+			}
+			apiGroup, ok := matchingAPIGroup(sel.APIGroups, r.APIGroup)
+			if !ok {
+				continue
+			}
+			if kind == "*" {
+				violations = append(violations, ProtectionViolation{
+					Field: "apiGroups",
+					Value: apiGroup,
+					Message: "cannot exclude all kinds in this apiGroup — it contains " + r.Kind +
+						": " + protectionReason(r),
+					Severity: r.Severity,
+				})
+			} else {
+				violations = append(violations, ProtectionViolation{
+					Field:    "kinds",
+					Value:    kind,
+					Message:  "cannot exclude " + kind + " — " + protectionReason(r),
+					Severity: r.Severity,
+				})
+			}
+		}
+	}
+
+	// A label or annotation selector limits the exclude to some resources of the group.
+	if sel.LabelSelector != nil || sel.AnnotationSelector != nil {
+		return violations
+	}
+	for _, apiGroup := range sel.APIGroups {
+		for _, r := range protected {
+			if r.Kind == "" && r.APIGroup == apiGroup {
+				violations = append(violations, ProtectionViolation{
+					Field: "apiGroups",
+					Value: apiGroup,
+					Message: "cannot exclude resources in apiGroup " + apiGroup +
+						" — these resources are necessary for system functionality",
+					Severity: r.Severity,
+				})
+			}
+		}
+	}
+	return violations
+}
+
+// matchingAPIGroup returns the first of apiGroups that matches group, including the wildcard "*".
+func matchingAPIGroup(apiGroups []string, group string) (string, bool) {
+	for _, apiGroup := range apiGroups {
+		if apiGroup == "*" || apiGroup == group {
+			return apiGroup, true
+		}
+	}
+	return "", false
+}
+
+func protectionReason(r ProtectedResource) string {
+	if isRBACProtected(r) {
+		return "search depends on it for RBAC and cluster-scoped queries"
+	}
+	return "it is necessary for system functionality"
+}
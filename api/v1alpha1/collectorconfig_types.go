@@ -33,6 +33,33 @@ const (
 // was applied successfully. When False, the Message field describes which rules were skipped and why.
 const CollectorConfigConditionApplied = "Applied"
 
+// CollectorConfigConditionRuleCollisions is set on integration CollectorConfigs and on
+// merged-collector-config. When True, the Message field lists the rules that collided with
+// another integration CollectorConfig and how each collision was resolved.
+const CollectorConfigConditionRuleCollisions = "RuleCollisions"
+
+// CollectorConfigConditionObservedByCollectors is set on merged-collector-config. It is True when
+// every collector that acknowledged the configuration has loaded the current generation.
+const CollectorConfigConditionObservedByCollectors = "ObservedByCollectors"
+
+// CollectorConfigConditionDefaultConflicts is set on the built-in integration CollectorConfigs.
+// When True, the Message field lists the fields where both the shipped default and the live config
+// changed since the default was last applied; the live values are kept.
+const CollectorConfigConditionDefaultConflicts = "DefaultConflicts"
+
+// CollectorConfigConditionVersionsServed is set on CollectorConfigs with rules that pin API
+// versions. It is False when a pinned version of a kind is no longer served on the hub.
+const CollectorConfigConditionVersionsServed = "VersionsServed"
+
+// CollectorConfigConditionMatchesHubSchema is set on CollectorConfigs with rules. It is False when
+// a rule names an apiGroup or kind the hub does not serve, or a custom field whose jsonPath or type
+// does not match the CRD schema on the hub.
+const CollectorConfigConditionMatchesHubSchema = "MatchesHubSchema"
+
+// AnnotationMergeProvenance is set on merged-collector-config. Its value is a JSON list with the
+// source CollectorConfig name and rule index of each merged rule, for the collector to log.
+const AnnotationMergeProvenance = "search.open-cluster-management.io/merge-provenance"
+
 // IntegrationTeamLabel is the label key that integration teams apply to their CollectorConfig CRs
 // so the operator discovers and merges them into the merged-collector-config.
 const IntegrationTeamLabel = "search.open-cluster-management.io/config-type"
@@ -40,10 +67,37 @@ const IntegrationTeamLabel = "search.open-cluster-management.io/config-type"
 // IntegrationTeamLabelValue is the expected value for IntegrationTeamLabel.
 const IntegrationTeamLabelValue = "integration"
 
+// ClusterConfigLabelValue is the IntegrationTeamLabel value on the per-cluster merged-collector-config
+// the operator writes to each managed cluster namespace when rules use a clusterSelector.
+const ClusterConfigLabelValue = "cluster"
+
 // AnnotationManualOverride, when present on an integration CollectorConfig, signals that a user
 // has intentionally customized this config and the operator must not overwrite it on restart.
 const AnnotationManualOverride = "search.open-cluster-management.io/manual-override"
 
+// AnnotationLastAppliedDefault is set by the operator on the built-in integration CollectorConfigs.
+// Its value is the JSON spec of the shipped default last applied, the base of the three-way merge
+// between the shipped default and the live config.
+const AnnotationLastAppliedDefault = "search.open-cluster-management.io/last-applied-default"
+
+// AnnotationIntegrationSource is set by the operator on the integration CollectorConfigs it loads.
+// Its value names the manifest the config came from: embedded:<file> for the configs shipped with
+// the operator, directory:<file> for the INTEGRATION_COLLECTOR_CONFIGS_DIR directory, and
+// configmap:<name>/<key> for ConfigMaps. The config is deleted when its source disappears.
+const AnnotationIntegrationSource = "search.open-cluster-management.io/integration-source"
+
+// IntegrationSourceEmbedded prefixes the AnnotationIntegrationSource value of the integration
+// CollectorConfigs shipped with the operator.
+const IntegrationSourceEmbedded = "embedded:"
+
+// IntegrationConfigMapLabel, set to "true" on a ConfigMap in the Search namespace, makes the
+// operator load each data key of the ConfigMap as an integration CollectorConfig manifest.
+const IntegrationConfigMapLabel = "search.open-cluster-management.io/integration-collector-config"
+
+// AnnotationReset, when present on a built-in integration CollectorConfig, makes the operator
+// restore the shipped default spec and remove the annotation.
+const AnnotationReset = "search.open-cluster-management.io/reset"
+
 // Reason constants for the CollectorConfig Applied condition.
 const (
 	// CollectorConfigReasonApplied means all rules were processed and applied successfully.
@@ -54,6 +108,51 @@ const (
 	CollectorConfigReasonLoadError = "LoadError"
 )
 
+// Reason constants for the CollectorConfig RuleCollisions condition.
+const (
+	// CollectorConfigReasonNoCollisions means the rules did not collide with another integration config.
+	CollectorConfigReasonNoCollisions = "NoCollisions"
+	// CollectorConfigReasonCollisionsResolved means colliding rules were changed or dropped in the merge.
+	CollectorConfigReasonCollisionsResolved = "CollisionsResolved"
+)
+
+// Reason constants for the CollectorConfig DefaultConflicts condition.
+const (
+	// CollectorConfigReasonNoConflicts means the shipped default merged without conflicts.
+	CollectorConfigReasonNoConflicts = "NoConflicts"
+	// CollectorConfigReasonLiveValuesKept means conflicting fields kept their live values.
+	CollectorConfigReasonLiveValuesKept = "LiveValuesKept"
+)
+
+// Reason constants for the CollectorConfig ObservedByCollectors condition.
+const (
+	// CollectorConfigReasonObserved means every acknowledging collector loaded the current generation.
+	CollectorConfigReasonObserved = "Observed"
+	// CollectorConfigReasonPending means one or more collectors still run an older generation.
+	CollectorConfigReasonPending = "Pending"
+	// CollectorConfigReasonNoAcknowledgements means no collector has acknowledged the configuration.
+	CollectorConfigReasonNoAcknowledgements = "NoAcknowledgements"
+)
+
+// Reason constants for the CollectorConfig VersionsServed condition.
+const (
+	// CollectorConfigReasonVersionsServed means every pinned version is served, or its kind is not on the hub.
+	CollectorConfigReasonVersionsServed = "VersionsServed"
+	// CollectorConfigReasonVersionsNotServed means one or more rules pin a version that is no longer served.
+	CollectorConfigReasonVersionsNotServed = "VersionsNotServed"
+	// CollectorConfigReasonDiscoveryFailed means the served versions could not be discovered.
+	CollectorConfigReasonDiscoveryFailed = "DiscoveryFailed"
+)
+
+// Reason constants for the CollectorConfig MatchesHubSchema condition. DiscoveryFailed is shared
+// with VersionsServed.
+const (
+	// CollectorConfigReasonSchemaMatched means every rule matches the hub's discovery data and CRD schemas.
+	CollectorConfigReasonSchemaMatched = "SchemaMatched"
+	// CollectorConfigReasonSchemaMismatch means one or more rules do not match the hub.
+	CollectorConfigReasonSchemaMismatch = "SchemaMismatch"
+)
+
 // CollectorConfigStatus defines the observed state of CollectorConfig.
 type CollectorConfigStatus struct {
 	// +optional
@@ -62,7 +161,149 @@ type CollectorConfigStatus struct {
 	// Conditions contains the latest status conditions for this CollectorConfig.
 	// The "Applied" condition indicates whether the configuration was applied without errors.
 	// When Applied is False, the Message field lists which rules were skipped and why.
+	// The "RuleCollisions" condition reports rules that collided with another integration config.
+	// On the built-in integration configs, the "DefaultConflicts" condition reports fields where
+	// local edits conflict with changes to the shipped default.
+	// On merged-collector-config, the "ObservedByCollectors" condition reports whether every
+	// collector that acknowledged the configuration has loaded the current generation.
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	// Sources lists the CollectorConfigs that were merged. Set on merged-collector-config only.
+	Sources []CollectorConfigSource `json:"sources,omitempty"`
+
+	// +optional
+	// RuleSources has one entry per rule in spec.collectionRules, in the same order, naming the
+	// CollectorConfig and the index of the rule it came from. Set on merged-collector-config only.
+	RuleSources []RuleSource `json:"ruleSources,omitempty"`
+
+	// +optional
+	// DroppedRules is the number of source rules left out of the merge because of collisions or
+	// because they excluded resources an integration config requires. Set on merged-collector-config only.
+	DroppedRules int `json:"droppedRules,omitempty"`
+
+	// +optional
+	// MergedGeneration is the metadata.generation of merged-collector-config written by the last merge.
+	MergedGeneration int64 `json:"mergedGeneration,omitempty"`
+
+	// +optional
+	// LastMergeTime is when the merged configuration or its sources last changed.
+	LastMergeTime *metav1.Time `json:"lastMergeTime,omitempty"`
+
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	// Collectors is written by the search collectors. Each collector records the generation of
+	// merged-collector-config it has loaded. Set on merged-collector-config only.
+	Collectors []CollectorAcknowledgement `json:"collectors,omitempty"`
+
+	// +optional
+	// Impact estimates how many objects, and roughly how many bytes, the include rules add to
+	// search. The operator counts the matching objects on the hub and refreshes the estimate
+	// periodically. Not set on the per-cluster configs.
+	Impact *ImpactEstimate `json:"impact,omitempty"`
+
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	// EdgeTypes lists the edge types declared by spec.relationships, for the search API to expose.
+	// Set on merged-collector-config only.
+	EdgeTypes []EdgeType `json:"edgeTypes,omitempty"`
+}
+
+// EdgeType is a custom relationship edge type in merged-collector-config.
+type EdgeType struct {
+	// Name of the edge type.
+	Name string `json:"name"`
+
+	// SourceKinds are the kinds the edges start from, as Kind or Kind.apiGroup.
+	SourceKinds []string `json:"sourceKinds"`
+
+	// +optional
+	// TargetKinds are the target kinds set on the relationships, as Kind or Kind.apiGroup. Targets
+	// whose kind comes from the reference are not listed.
+	TargetKinds []string `json:"targetKinds,omitempty"`
+
+	// Sources are the CollectorConfigs that declare the edge type.
+	Sources []string `json:"sources"`
+}
+
+// ImpactEstimate is the estimated search footprint of a CollectorConfig's include rules.
+type ImpactEstimate struct {
+	// ObservedGeneration is the metadata.generation the estimate was computed for.
+	ObservedGeneration int64 `json:"observedGeneration"`
+
+	// +optional
+	// LastEstimateTime is when the objects were last counted.
+	LastEstimateTime *metav1.Time `json:"lastEstimateTime,omitempty"`
+
+	// +optional
+	// Objects is the number of objects matched by the include rules, counted once per rule.
+	Objects int64 `json:"objects,omitempty"`
+
+	// +optional
+	// Bytes is the approximate JSON size of the matched objects. Search stores only part of each
+	// object, so this is an upper bound.
+	Bytes int64 `json:"bytes,omitempty"`
+
+	// +optional
+	// Rules has one entry per include rule in spec.collectionRules.
+	Rules []RuleImpact `json:"rules,omitempty"`
+}
+
+// RuleImpact is the estimated search footprint of one include rule.
+type RuleImpact struct {
+	// Index of the rule in spec.collectionRules.
+	Index int `json:"index"`
+
+	// +optional
+	// Objects is the number of objects on the hub the rule matches.
+	Objects int64 `json:"objects,omitempty"`
+
+	// +optional
+	// Bytes is the approximate JSON size of the matched objects.
+	Bytes int64 `json:"bytes,omitempty"`
+
+	// +optional
+	// Partial is true when the count stopped early, so Objects and Bytes are lower bounds.
+	Partial bool `json:"partial,omitempty"`
+
+	// +optional
+	// Error is set when the matching objects could not be counted.
+	Error string `json:"error,omitempty"`
+}
+
+// CollectorConfigSource identifies a CollectorConfig that was merged into merged-collector-config.
+type CollectorConfigSource struct {
+	// Name of the CollectorConfig.
+	Name string `json:"name"`
+
+	// ResourceVersion of the CollectorConfig when it was merged.
+	ResourceVersion string `json:"resourceVersion"`
+}
+
+// RuleSource identifies where a rule in merged-collector-config came from.
+type RuleSource struct {
+	// Source is the name of the CollectorConfig that defines the rule.
+	Source string `json:"source"`
+
+	// Index of the rule in the source's spec.collectionRules.
+	Index int `json:"index"`
+}
+
+// CollectorAcknowledgement is written by a search collector when it loads merged-collector-config.
+type CollectorAcknowledgement struct {
+	// Name of the managed cluster the collector runs on.
+	Name string `json:"name"`
+
+	// ObservedGeneration is the generation of merged-collector-config the collector has loaded.
+	ObservedGeneration int64 `json:"observedGeneration"`
+
+	// +optional
+	// LastObservedTime is when the collector loaded the configuration.
+	LastObservedTime *metav1.Time `json:"lastObservedTime,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -87,6 +328,176 @@ type CollectorConfigSpec struct {
 	// +optional
 	// Defines a list of rules for collecting resources and specific fields.
 	CollectionRules []CollectionRule `json:"collectionRules,omitempty"`
+
+	// +optional
+	// Limits every rule in this config to the selected managed clusters. A rule's own
+	// clusterSelector takes precedence. When omitted, the rules apply to all clusters.
+	ClusterSelector *ClusterSelector `json:"clusterSelector,omitempty"`
+
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=50
+	// Rules that mask or drop sensitive annotations, labels and custom field values before they are
+	// indexed. The redaction rules of all CollectorConfigs apply to every collected resource.
+	Redact []RedactionRule `json:"redact,omitempty"`
+
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=50
+	// Custom relationships added to the search graph as edges from the source resources to the
+	// resources they reference. The relationships of all CollectorConfigs apply on every cluster.
+	Relationships []Relationship `json:"relationships,omitempty"`
+}
+
+// Relationship declares an edge type from a source kind to the resources referenced at a JSONPath
+// of the source, for example MyApp -> Deployment through spec.workloadRef.
+type Relationship struct {
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=63
+	// Name identifies the relationship. The merge keeps the first relationship with a name, so
+	// integration configs can't be overridden by user-collector-config.
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z][a-zA-Z0-9]*$`
+	// +kubebuilder:validation:MaxLength=63
+	// EdgeType is the type of the edges in search, for example usesWorkload. Several
+	// relationships can share an edge type.
+	EdgeType string `json:"edgeType"`
+
+	// Source is the kind the edges start from.
+	Source RelationshipSource `json:"source"`
+
+	// Target is how the referenced resource is found on the source.
+	Target RelationshipTarget `json:"target"`
+}
+
+// RelationshipSource selects the kind of resource an edge starts from.
+type RelationshipSource struct {
+	// +optional
+	// APIGroup of the source kind. Empty is the core group.
+	APIGroup string `json:"apiGroup,omitempty"`
+
+	// +kubebuilder:validation:MinLength=1
+	// Kind of the source resources.
+	Kind string `json:"kind"`
+}
+
+// RelationshipReferenceType is how the value at a relationship's JSONPath identifies the target.
+// +kubebuilder:validation:Enum=reference;name;uid
+type RelationshipReferenceType string
+
+const (
+	// RelationshipReferenceObject is an object reference with name and optionally namespace, kind,
+	// and apiVersion or apiGroup, like spec.workloadRef: {kind: Deployment, name: web}.
+	RelationshipReferenceObject RelationshipReferenceType = "reference"
+	// RelationshipReferenceName is the target's name. The target kind must be set.
+	RelationshipReferenceName RelationshipReferenceType = "name"
+	// RelationshipReferenceUID is the target's metadata.uid.
+	RelationshipReferenceUID RelationshipReferenceType = "uid"
+)
+
+// RelationshipTarget finds the resource an edge points to. The target is looked up in the source's
+// namespace unless the reference names a namespace. A JSONPath that resolves to a list creates an
+// edge to each item.
+type RelationshipTarget struct {
+	// JSONPath to the reference on the source resource, for example {.spec.workloadRef}.
+	JSONPath string `json:"jsonPath"`
+
+	// +optional
+	// +kubebuilder:default=reference
+	// ReferenceType is reference (default), name or uid.
+	ReferenceType RelationshipReferenceType `json:"referenceType,omitempty"`
+
+	// +optional
+	// APIGroup of the target when the reference doesn't include it.
+	APIGroup string `json:"apiGroup,omitempty"`
+
+	// +optional
+	// Kind of the target when the reference doesn't include it. Required for referenceType name.
+	Kind string `json:"kind,omitempty"`
+}
+
+// RedactionTarget is what a redaction rule applies to.
+// +kubebuilder:validation:Enum=annotations;labels;fields
+type RedactionTarget string
+
+const (
+	RedactionTargetAnnotations RedactionTarget = "annotations"
+	RedactionTargetLabels      RedactionTarget = "labels"
+	// RedactionTargetFields applies to the custom fields of the collection rules, by field name
+	// without the fieldSuffix.
+	RedactionTargetFields RedactionTarget = "fields"
+)
+
+// RedactionAction is what a redaction rule does with a matching value.
+// +kubebuilder:validation:Enum=mask;drop
+type RedactionAction string
+
+const (
+	// RedactionActionMask replaces the value with RedactedValue. Custom fields that are not strings
+	// are dropped instead.
+	RedactionActionMask RedactionAction = "mask"
+	// RedactionActionDrop leaves the key or field out.
+	RedactionActionDrop RedactionAction = "drop"
+)
+
+// RedactedValue replaces the values masked by a redaction rule.
+const RedactedValue = "[REDACTED]"
+
+// RedactionRule masks or drops the annotations, labels or custom fields whose key and value match.
+// At least one of keys, keyPattern and valuePattern must be set; a value is redacted when it matches
+// all that are set.
+type RedactionRule struct {
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=63
+	// Name identifies the rule. The merge keeps the first rule with a name, so integration configs
+	// can't be overridden by user-collector-config.
+	Name string `json:"name"`
+
+	// Target is annotations, labels or fields.
+	Target RedactionTarget `json:"target"`
+
+	// +optional
+	// Keys are annotation or label keys, or custom field names, matched exactly.
+	Keys []string `json:"keys,omitempty"`
+
+	// +optional
+	// KeyPattern is a regular expression (RE2 syntax) matched against the key or field name.
+	KeyPattern string `json:"keyPattern,omitempty"`
+
+	// +optional
+	// ValuePattern is a regular expression (RE2 syntax) matched against the value.
+	ValuePattern string `json:"valuePattern,omitempty"`
+
+	// +optional
+	// +kubebuilder:default=mask
+	// Action is mask (default) or drop.
+	Action RedactionAction `json:"action,omitempty"`
+}
+
+// ClusterSelector selects managed clusters by label or through an OCM Placement.
+// +kubebuilder:validation:XValidation:rule="has(self.labelSelector) != has(self.placementRef)",message="specify exactly one of labelSelector or placementRef"
+type ClusterSelector struct {
+	// +optional
+	// Selects ManagedClusters by their labels.
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+
+	// +optional
+	// Selects the ManagedClusters in the decisions of a Placement.
+	PlacementRef *PlacementRef `json:"placementRef,omitempty"`
+}
+
+// PlacementRef refers to an OCM Placement.
+type PlacementRef struct {
+	// +kubebuilder:validation:MinLength=1
+	// Name of the Placement.
+	Name string `json:"name"`
+
+	// +optional
+	// Namespace of the Placement. Defaults to the namespace of the CollectorConfig.
+	Namespace string `json:"namespace,omitempty"`
 }
 
 // CollectNamespaces specifies the namespaces where resources are to be indexed by Search Collectors.
@@ -99,6 +510,29 @@ type CollectNamespaces struct {
 	// The resulting list is compiled by using the intersection of results from all parameters.
 	// You must provide either include or at least one of matchExpressions or matchLabels to retrieve namespaces.
 	NamespaceSelector *NamespaceSelector `json:"namespaceSelector,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:MaxItems=100
+	// Required lists namespaces, by name, that are always collected whatever the namespace selector.
+	// Integration configs use it for the namespaces they depend on. The merge combines the required
+	// namespaces of all configs and drops exclude patterns that match one.
+	Required []string `json:"required,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:MaxItems=20
+	// ClusterOverrides replace the namespace selector on the selected managed clusters. The first
+	// override that selects a cluster applies. To scope by ManagedClusterSet, select the
+	// cluster.open-cluster-management.io/clusterset label.
+	ClusterOverrides []NamespaceSelectorOverride `json:"clusterOverrides,omitempty"`
+}
+
+// NamespaceSelectorOverride is the namespace selector for some managed clusters.
+type NamespaceSelectorOverride struct {
+	// ClusterSelector selects the managed clusters the override applies to.
+	ClusterSelector ClusterSelector `json:"clusterSelector"`
+
+	// NamespaceSelector replaces the namespace selector on the selected clusters.
+	NamespaceSelector NamespaceSelector `json:"namespaceSelector"`
 }
 
 // NamespaceSelector defines the selector for namespaces.
@@ -160,6 +594,11 @@ type CollectionRule struct {
 	// priority 0 through 5 will be collected.
 	// Omitting this field or setting to -1 after having been set will disable collection for the matched resource.
 	CollectAdditionalPrinterColumnsPriority *int `json:"collectAdditionalPrinterColumnsPriority,omitempty"`
+
+	// +optional
+	// Limits this rule to the selected managed clusters. When omitted, the config's clusterSelector
+	// applies, or the rule applies to all clusters.
+	ClusterSelector *ClusterSelector `json:"clusterSelector,omitempty"`
 }
 
 // ResourceSelector specifies which resources a rule applies to.
@@ -169,6 +608,22 @@ type ResourceSelector struct {
 
 	// Specifies kinds of resources. Use "*" to match all kinds in the apiGroup (not permitted with fields).
 	Kinds []string `json:"kinds"`
+
+	// +optional
+	// +kubebuilder:validation:MaxItems=10
+	// Limits the rule to these API versions, e.g. v1beta1. Pin a single version when the fields'
+	// jsonPaths are written for one schema of a kind served in several versions. When omitted, the
+	// rule applies to the version the collector watches.
+	Versions []string `json:"versions,omitempty"`
+
+	// +optional
+	// Limits the rule to resources whose labels match the selector.
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+
+	// +optional
+	// Limits the rule to resources whose annotations match the selector. Keys and values use the
+	// label syntax, so annotation values longer than 63 characters cannot be matched.
+	AnnotationSelector *metav1.LabelSelector `json:"annotationSelector,omitempty"`
 }
 
 // Field specifies an additional field on the resource to index by Search Collectors.
@@ -182,7 +637,80 @@ type Field struct {
 	// +optional
 	// +kubebuilder:default=string
 	// Data type of resource field to be indexed by Search Collectors. Default is a string.
+	// The value is converted to the type after the transforms; bytes parses quantities like 1Gi.
 	Type DataType `json:"type,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:MaxItems=10
+	// Transforms applied in order to the value at jsonPath before it is converted to the type.
+	Transforms []FieldTransform `json:"transforms,omitempty"`
+}
+
+// FieldTransform is one step of a Field's transform pipeline. Exactly one operation is set.
+// A step that leaves no value (a regex without a match, a condition that fails) ends with no
+// value for the next step; only default replaces it. The operations are implemented by
+// pkg/fieldtransform.
+// +kubebuilder:validation:XValidation:rule="[has(self.regex), has(self.map), has(self.join), has(self.default), has(self.when)].filter(x, x).size() == 1",message="specify exactly one transform operation"
+type FieldTransform struct {
+	// +optional
+	// Replaces the value with a capture group of a regular expression, e.g. the tag of an image.
+	Regex *RegexTransform `json:"regex,omitempty"`
+
+	// +optional
+	// Replaces values found in a table, e.g. to map enum values to labels.
+	Map *MapTransform `json:"map,omitempty"`
+
+	// +optional
+	// Joins an array value into a string.
+	Join *JoinTransform `json:"join,omitempty"`
+
+	// +optional
+	// Sets the value when there is none or it is an empty string.
+	Default *string `json:"default,omitempty"`
+
+	// +optional
+	// Keeps the value only when a condition on the resource holds.
+	When *WhenTransform `json:"when,omitempty"`
+}
+
+// RegexTransform extracts a capture group from the value.
+type RegexTransform struct {
+	// Regular expression in Go RE2 syntax, matched against the value as a string.
+	// +kubebuilder:validation:MinLength=1
+	Pattern string `json:"pattern"`
+
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// Capture group to keep; 0 keeps the whole match. Default is 1.
+	Group *int `json:"group,omitempty"`
+}
+
+// MapTransform replaces values found in a table.
+type MapTransform struct {
+	// Replacement for each value.
+	Values map[string]string `json:"values"`
+
+	// +optional
+	// Replacement for values not in the table. Unmatched values are kept when unset.
+	Default *string `json:"default,omitempty"`
+}
+
+// JoinTransform joins the elements of an array value.
+type JoinTransform struct {
+	// +optional
+	// Separator between the elements. Default is ",".
+	Separator *string `json:"separator,omitempty"`
+}
+
+// WhenTransform keeps the value only when another path of the resource matches.
+type WhenTransform struct {
+	// JSONPath to the value on the resource that is tested.
+	JSONPath string `json:"jsonPath"`
+
+	// +optional
+	// Value the tested path must have, compared as a string. When unset the tested path must
+	// exist and not be empty.
+	Equals *string `json:"equals,omitempty"`
 }
 
 // +kubebuilder:object:root=true
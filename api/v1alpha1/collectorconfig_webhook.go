@@ -7,9 +7,17 @@ import (
 	"fmt"
 	"k8s.io/client-go/util/jsonpath"
 	"regexp"
+	"slices"
 	"strings"
 
+	"github.com/stolostron/search-v2-operator/pkg/impact"
+	"github.com/stolostron/search-v2-operator/pkg/schemacheck"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -21,10 +29,20 @@ import (
 // log is for logging in this package.
 var collectorconfiglog = logf.Log.WithName("collectorconfig-resource")
 
-// webhookClient is the Kubernetes client used to list integration team CollectorConfigs
-// during admission. Set once in SetupWebhookWithManager; nil in unit tests that don't
-// register a manager (the integration-config check is skipped when nil).
-var webhookClient client.Client
+// The clients the webhook reads the cluster with during admission. Set once in
+// SetupWebhookWithManager; nil in unit tests that don't register a manager, and the checks that
+// need them are skipped when nil.
+var (
+	// webhookClient lists integration team CollectorConfigs.
+	webhookClient client.Client
+	// webhookEstimator counts the objects matched by new include rules.
+	webhookEstimator *impact.Estimator
+	// webhookSchemaChecker checks new rules against the hub's discovery data and CRD schemas.
+	webhookSchemaChecker *schemacheck.Checker
+)
+
+// searchName is the name of the Search CR the operator reconciles.
+const searchName = "search-v2-operator"
 
 // Precompiled validation patterns.
 var (
@@ -34,6 +52,16 @@ var (
 
 func (r *CollectorConfig) SetupWebhookWithManager(mgr ctrl.Manager) error {
 	webhookClient = mgr.GetClient()
+	estimator, err := newWebhookEstimator(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+	webhookEstimator = estimator
+	checker, err := newWebhookSchemaChecker(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+	webhookSchemaChecker = checker
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
 		WithValidator(r).
@@ -65,11 +93,25 @@ func (r *CollectorConfig) ValidateCreate(ctx context.Context, obj runtime.Object
 		return nil, err
 	}
 
-	if err := cc.validateCollectorConfig(); err != nil {
-		return nil, err
+	warnings, err := cc.validateCollectorConfig(protectedResources(ctx, cc.Namespace))
+	if err != nil {
+		return warnings, err
 	}
 
-	return nil, validateExcludeAgainstIntegrationConfigs(ctx, cc)
+	if err := validateExcludeAgainstIntegrationConfigs(ctx, cc); err != nil {
+		return warnings, err
+	}
+
+	if err := validateRedactionAgainstIntegrationConfigs(ctx, cc); err != nil {
+		return warnings, err
+	}
+
+	if err := validateNamespacesAgainstIntegrationConfigs(ctx, cc); err != nil {
+		return warnings, err
+	}
+
+	warnings = append(warnings, warnOnSchemaMismatch(ctx, cc, nil)...)
+	return append(warnings, warnOnLargeImpact(ctx, cc, nil)...), nil
 }
 
 // ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type
@@ -91,12 +133,29 @@ func (r *CollectorConfig) ValidateUpdate(ctx context.Context, oldObj, newObj run
 	if err := rejectIfProtected(ctx, oldCC, WebhookOperationUpdate); err != nil {
 		return nil, err
 	}
-
-	if err := cc.validateCollectorConfig(); err != nil {
+	if err := rejectRedactionRemoval(ctx, oldCC, cc); err != nil {
 		return nil, err
 	}
 
-	return nil, validateExcludeAgainstIntegrationConfigs(ctx, cc)
+	warnings, err := cc.validateCollectorConfig(protectedResources(ctx, cc.Namespace))
+	if err != nil {
+		return warnings, err
+	}
+
+	if err := validateExcludeAgainstIntegrationConfigs(ctx, cc); err != nil {
+		return warnings, err
+	}
+
+	if err := validateRedactionAgainstIntegrationConfigs(ctx, cc); err != nil {
+		return warnings, err
+	}
+
+	if err := validateNamespacesAgainstIntegrationConfigs(ctx, cc); err != nil {
+		return warnings, err
+	}
+
+	warnings = append(warnings, warnOnSchemaMismatch(ctx, cc, oldCC)...)
+	return append(warnings, warnOnLargeImpact(ctx, cc, oldCC)...), nil
 }
 
 // ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type
@@ -114,9 +173,20 @@ func (r *CollectorConfig) ValidateDelete(ctx context.Context, obj runtime.Object
 	return nil, nil
 }
 
-// validateCollectorConfig performs validation on the CollectorConfig spec
-func (r *CollectorConfig) validateCollectorConfig() error {
+// Validate checks the CollectorConfig spec like the validating webhook does, without the checks
+// that need the cluster. The operator uses it for integration configs it loads from outside the
+// binary.
+func (r *CollectorConfig) Validate() error {
+	_, err := r.validateCollectorConfig(DefaultProtectedResources)
+	return err
+}
+
+// validateCollectorConfig performs validation on the CollectorConfig spec. Exclude rules are
+// checked against the given protected resources; the ones with severity Warn are returned as
+// warnings.
+func (r *CollectorConfig) validateCollectorConfig(protected []ProtectedResource) (admission.Warnings, error) {
 	var allErrs field.ErrorList
+	var warnings admission.Warnings
 
 	// Validate CollectionRules
 	rulesPath := field.NewPath("spec", "collectionRules")
@@ -171,6 +241,14 @@ func (r *CollectorConfig) validateCollectorConfig() error {
 					"must specify exactly 1 apiGroup when fields are defined",
 				))
 			}
+			// The fields' jsonPaths are written for one schema.
+			if len(rule.ResourceSelector.Versions) > 1 {
+				allErrs = append(allErrs, field.Invalid(
+					rulePath.Child("resourceSelector", "versions"),
+					rule.ResourceSelector.Versions,
+					"must specify at most 1 version when fields are defined",
+				))
+			}
 
 			// Validate each field
 			fieldsPath := rulePath.Child("fields")
@@ -205,59 +283,34 @@ func (r *CollectorConfig) validateCollectorConfig() error {
 
 		// Validate exclude-specific constraints
 		if rule.Action == ActionExclude {
-			allErrs = append(allErrs, validateExcludeRule(&rule, rulePath)...)
+			errs, ruleWarnings := validateExcludeRule(&rule, rulePath, protected)
+			allErrs = append(allErrs, errs...)
+			warnings = append(warnings, ruleWarnings...)
 		}
+
+		allErrs = append(allErrs, validateClusterSelector(rule.ClusterSelector, rulePath.Child("clusterSelector"))...)
 	}
+	allErrs = append(allErrs, validateClusterSelector(r.Spec.ClusterSelector, field.NewPath("spec", "clusterSelector"))...)
+	allErrs = append(allErrs, validateRedactionRules(r.Spec.Redact)...)
+	allErrs = append(allErrs, validateRelationships(r.Spec.Relationships)...)
+	allErrs = append(allErrs, validateCollectNamespaces(r.Spec.CollectNamespaces)...)
 
 	if len(allErrs) == 0 {
-		return nil
+		return warnings, nil
 	}
 
-	return allErrs.ToAggregate()
-}
-
-// protectedKinds lists resource types the search RBAC engine depends on.
-// Excluding them would break per-cluster and namespace-scoped access control:
-//   - ManagedCluster: used to scope search results to clusters a user can access
-//   - Namespace: used to scope search results to namespaces a user can access
-//
-// ManagedClusterSet and ManagedClusterSetBinding are NOT listed here because the
-// RBAC engine does not query them directly — they are used by placement/policy, not search.
-var protectedKinds = map[string]string{
-	"ManagedCluster": "cluster.open-cluster-management.io",
-	"Namespace":      "", // core group
-}
-
-// protectedAPIGroups is a temporary list of API groups that must not be excluded.
-// This started as a broad safety net covering everything integration teams (CNV, OLM, GRC,
-// Argo, Kyverno, etc.) and Search itself depend on. As of ACM-37052, most of those teams now
-// have a real labeled CollectorConfig (search.open-cluster-management.io/config-type: integration,
-// shipped from config/integration_collector_configs/ — see IntegrationCollectorConfigSeeder),
-// so the dynamic check (validateExcludeAgainstIntegrationConfigs) already protects their apiGroups
-// via those configs' include rules. What remains here are groups with no single integration-team
-// owner, or that Search itself depends on directly.
-//
-// "" = core group (ConfigMap, Job, Node, Pod, PVC — needed by CNV, console, and others; no single owner).
-var protectedAPIGroups = map[string]struct{}{
-	// Core group — ConfigMap, Job, Node, Pod, PVC needed by CNV / console / multiple consumers.
-	"": {},
-	// OpenShift cluster config — no single integration-team owner.
-	"config.openshift.io": {},
-	// OpenShift templates — no single integration-team owner.
-	"template.openshift.io": {},
-	// Admission / webhook configs — no single integration-team owner.
-	"admissionregistration.k8s.io": {},
-	// ACM hub operator — Search itself depends on this.
-	"operator.open-cluster-management.io": {},
-	// ACM Search itself.
-	"search.open-cluster-management.io": {},
+	return warnings, allErrs.ToAggregate()
 }
 
 // validateExcludeRule enforces constraints specific to exclude rules:
-//   - Cannot target ManagedCluster or Namespace (search RBAC engine depends on them)
+//   - Cannot target a protected resource (see ProtectionViolations). Protected resources with
+//     severity Warn are returned as warnings instead of errors.
+//   - Cannot exclude all apiGroups ("*")
 //   - Cannot specify fields, collectConditions, or fieldSuffix (meaningless on an exclude)
-func validateExcludeRule(rule *CollectionRule, path *field.Path) field.ErrorList {
+func validateExcludeRule(rule *CollectionRule, path *field.Path, protected []ProtectedResource) (
+	field.ErrorList, admission.Warnings) {
 	var allErrs field.ErrorList
+	var warnings admission.Warnings
 
 	// Reject fields, collectConditions, collectAnnotations, and fieldSuffix on exclude rules.
 	if len(rule.Fields) > 0 {
@@ -296,86 +349,48 @@ func validateExcludeRule(rule *CollectionRule, path *field.Path) field.ErrorList
 		))
 	}
 
-	// Reject exclusion of RBAC-critical kinds (ManagedCluster, Namespace).
-	allErrs = append(allErrs, validateProtectedKinds(rule, path)...)
-
-	// Reject exclusion of integration-critical API groups.
-	// This is a temporary safety net until integration teams ship labeled CollectorConfigs.
-	for _, apiGroup := range rule.ResourceSelector.APIGroups {
-		if apiGroup == "*" {
-			allErrs = append(allErrs, field.Invalid(
-				path.Child("resourceSelector", "apiGroups"),
-				apiGroup,
-				"cannot exclude all apiGroups — "+
-					"resources in many groups are necessary for system functionality",
-			))
-			break
-		}
-		if _, protected := protectedAPIGroups[apiGroup]; protected {
-			allErrs = append(allErrs, field.Invalid(
-				path.Child("resourceSelector", "apiGroups"),
-				apiGroup,
-				"cannot exclude resources in apiGroup "+apiGroup+
-					" — these resources are necessary for system functionality",
-			))
-		}
-	}
-	return allErrs
-}
-
-// validateProtectedKinds checks that an exclude rule does not target ManagedCluster or Namespace,
-// either by name or via a wildcard kind on their apiGroup.
-func validateProtectedKinds(rule *CollectionRule, path *field.Path) field.ErrorList {
-	var allErrs field.ErrorList
-	for _, kind := range rule.ResourceSelector.Kinds {
-		if kind == "*" {
-			allErrs = append(allErrs, validateWildcardKindAgainstProtected(rule.ResourceSelector.APIGroups, path)...)
+	// Reject or warn about exclusion of protected kinds and API groups, as the Search CR's
+	// collectorConfigPolicy says.
+	for _, v := range ProtectionViolations(rule.ResourceSelector, protected) {
+		err := field.Invalid(path.Child("resourceSelector", v.Field), v.Value, v.Message)
+		if v.Severity == ProtectionSeverityWarn {
+			warnings = append(warnings, err.Error())
 			continue
 		}
-		if protectedGroup, protected := protectedKinds[kind]; protected {
-			errs := validateSpecificKindAgainstProtected(kind, protectedGroup, rule.ResourceSelector.APIGroups, path)
-			allErrs = append(allErrs, errs...)
-		}
+		allErrs = append(allErrs, err)
 	}
-	return allErrs
-}
 
-// validateWildcardKindAgainstProtected rejects kinds:["*"] when the rule's apiGroups contain
-// a group that holds a protected kind (e.g. cluster.open-cluster-management.io → ManagedCluster).
-func validateWildcardKindAgainstProtected(apiGroups []string, path *field.Path) field.ErrorList {
-	var allErrs field.ErrorList
-	for protectedKind, protectedGroup := range protectedKinds {
-		for _, apiGroup := range apiGroups {
-			if apiGroup == "*" || apiGroup == protectedGroup {
-				allErrs = append(allErrs, field.Invalid(
-					path.Child("resourceSelector", "apiGroups"),
-					apiGroup,
-					"cannot exclude all kinds in this apiGroup — it contains "+
-						protectedKind+", which search depends on for RBAC and cluster-scoped queries",
-				))
-				break
-			}
-		}
+	// Whatever the policy, resources in many groups are needed.
+	if slices.Contains(rule.ResourceSelector.APIGroups, "*") {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("resourceSelector", "apiGroups"),
+			"*",
+			"cannot exclude all apiGroups — "+
+				"resources in many groups are necessary for system functionality",
+		))
 	}
-	return allErrs
+	return allErrs, warnings
 }
 
-// validateSpecificKindAgainstProtected rejects a specific protected kind when the rule's
-// apiGroups match the kind's group (including the global wildcard "*").
-func validateSpecificKindAgainstProtected(
-	kind, protectedGroup string, apiGroups []string, path *field.Path,
-) field.ErrorList {
+// validateClusterSelector checks that a ClusterSelector sets exactly one of labelSelector or
+// placementRef and that the label selector can be parsed. The CRD enforces the same through CEL;
+// this covers API servers that do not evaluate CEL rules.
+func validateClusterSelector(sel *ClusterSelector, path *field.Path) field.ErrorList {
 	var allErrs field.ErrorList
-	for _, apiGroup := range apiGroups {
-		if apiGroup == protectedGroup || apiGroup == "*" {
-			allErrs = append(allErrs, field.Invalid(
-				path.Child("resourceSelector", "kinds"),
-				kind,
-				"cannot exclude "+kind+" — search depends on it for RBAC and cluster-scoped queries",
-			))
-			break
+	if sel == nil {
+		return allErrs
+	}
+	if (sel.LabelSelector == nil) == (sel.PlacementRef == nil) {
+		allErrs = append(allErrs, field.Invalid(path, "", "specify exactly one of labelSelector or placementRef"))
+	}
+	if sel.LabelSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(sel.LabelSelector); err != nil {
+			allErrs = append(allErrs, field.Invalid(path.Child("labelSelector"), sel.LabelSelector, err.Error()))
 		}
 	}
+	if sel.PlacementRef != nil && sel.PlacementRef.Name == "" {
+		allErrs = append(allErrs, field.Required(path.Child("placementRef", "name"), "placement name is required"))
+	}
 	return allErrs
 }
 
@@ -393,6 +408,31 @@ func (r *CollectorConfig) validateResourceSelector(selector *ResourceSelector, p
 		allErrs = append(allErrs, field.Required(path.Child("kinds"), "must specify at least one kind"))
 	}
 
+	// Versions validation
+	seen := map[string]bool{}
+	for i, version := range selector.Versions {
+		versionPath := path.Child("versions").Index(i)
+		if seen[version] {
+			allErrs = append(allErrs, field.Duplicate(versionPath, version))
+		}
+		seen[version] = true
+		for _, msg := range validation.IsDNS1123Label(version) {
+			allErrs = append(allErrs, field.Invalid(versionPath, version, msg))
+		}
+	}
+
+	// Label and annotation selector validation
+	if selector.LabelSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(selector.LabelSelector); err != nil {
+			allErrs = append(allErrs, field.Invalid(path.Child("labelSelector"), selector.LabelSelector, err.Error()))
+		}
+	}
+	if selector.AnnotationSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(selector.AnnotationSelector); err != nil {
+			allErrs = append(allErrs, field.Invalid(path.Child("annotationSelector"), selector.AnnotationSelector, err.Error()))
+		}
+	}
+
 	return allErrs
 }
 
@@ -440,6 +480,53 @@ func (r *CollectorConfig) validateField(customField *Field, path *field.Path) fi
 		}
 	}
 
+	// Transforms validation
+	transformsPath := path.Child("transforms")
+	for i, t := range customField.Transforms {
+		allErrs = append(allErrs, validateFieldTransform(&t, transformsPath.Index(i))...)
+	}
+
+	return allErrs
+}
+
+// validateFieldTransform checks that exactly one operation is set and that it can be compiled
+// the way pkg/fieldtransform compiles it.
+func validateFieldTransform(t *FieldTransform, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	set := 0
+	for _, op := range []bool{t.Regex != nil, t.Map != nil, t.Join != nil, t.Default != nil, t.When != nil} {
+		if op {
+			set++
+		}
+	}
+	if set != 1 {
+		allErrs = append(allErrs, field.Invalid(path, set,
+			"specify exactly one of regex, map, join, default or when"))
+	}
+
+	if t.Regex != nil {
+		re, err := regexp.Compile(t.Regex.Pattern)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(path.Child("regex", "pattern"), t.Regex.Pattern, err.Error()))
+		} else if t.Regex.Group != nil && (*t.Regex.Group < 0 || *t.Regex.Group > re.NumSubexp()) {
+			allErrs = append(allErrs, field.Invalid(path.Child("regex", "group"), *t.Regex.Group,
+				fmt.Sprintf("pattern has %d capture groups", re.NumSubexp())))
+		} else if t.Regex.Group == nil && re.NumSubexp() == 0 {
+			allErrs = append(allErrs, field.Invalid(path.Child("regex", "pattern"), t.Regex.Pattern,
+				"pattern has no capture group; add one or set group to 0 to keep the whole match"))
+		}
+	}
+	if t.Map != nil && len(t.Map.Values) == 0 {
+		allErrs = append(allErrs, field.Required(path.Child("map", "values"), "must map at least one value"))
+	}
+	if t.When != nil && !isValidJSONPath(t.When.JSONPath) {
+		allErrs = append(allErrs, field.Invalid(
+			path.Child("when", "jsonPath"),
+			t.When.JSONPath,
+			"must be a valid JSONPath expression (e.g. \".status.phase\" or \"{.status.phase}\")",
+		))
+	}
 	return allErrs
 }
 
@@ -488,6 +575,24 @@ func contains(slice []string, item string) bool {
 	return false
 }
 
+// protectedResources returns the protected resources of the Search CR's collectorConfigPolicy in
+// the namespace. The defaults are used when webhookClient is nil (unit tests without a registered
+// manager) or the Search CR can't be read.
+func protectedResources(ctx context.Context, namespace string) []ProtectedResource {
+	if webhookClient == nil {
+		return DefaultProtectedResources
+	}
+	search := &Search{}
+	err := webhookClient.Get(ctx, client.ObjectKey{Name: searchName, Namespace: namespace}, search)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			collectorconfiglog.Error(err, "could not get the Search CR; using the default protected resources")
+		}
+		return DefaultProtectedResources
+	}
+	return search.Spec.CollectorConfigPolicy.EffectiveProtectedResources()
+}
+
 // isOperatorOwned returns true if the CollectorConfig has a controller owner reference,
 // indicating it is managed by the operator and should not be modified directly.
 func isOperatorOwned(cc *CollectorConfig) bool {
@@ -499,6 +604,13 @@ func isOperatorOwned(cc *CollectorConfig) bool {
 	return false
 }
 
+// repeatsSourceRules returns true for the configs the operator writes from other configs: the
+// operator-owned merged-collector-config and the per-cluster configs in managed cluster namespaces.
+// Per-cluster configs have no owner reference, so they are recognized by their label.
+func repeatsSourceRules(cc *CollectorConfig) bool {
+	return isOperatorOwned(cc) || cc.Labels[IntegrationTeamLabel] == ClusterConfigLabelValue
+}
+
 // rejectIfProtected returns an error if the config has a controller owner reference
 // and the caller is not a service account in the same namespace (i.e., not the operator).
 func rejectIfProtected(ctx context.Context, cc *CollectorConfig, operation WebhookOperation) error {
@@ -519,30 +631,50 @@ func rejectIfProtected(ctx context.Context, cc *CollectorConfig, operation Webho
 		return nil
 	}
 
-	// Allow any service account in the resource's namespace.
-	// Only the operator namespace should have write RBAC for CollectorConfigs,
-	// so namespace-scoped SA check is sufficient.
-	expectedPrefix := fmt.Sprintf("system:serviceaccount:%s:", req.Namespace)
-	if strings.HasPrefix(req.UserInfo.Username, expectedPrefix) {
+	if isOperatorRequest(req) {
 		return nil
 	}
 
 	return fmt.Errorf("%s is managed by the search operator and cannot be modified directly", cc.Name)
 }
 
+// isOperatorRequest returns true if the request comes from a service account in the resource's
+// namespace. Only the operator namespace should have write RBAC for CollectorConfigs, so the
+// namespace-scoped SA check is sufficient.
+func isOperatorRequest(req admission.Request) bool {
+	expectedPrefix := fmt.Sprintf("system:serviceaccount:%s:", req.Namespace)
+	return strings.HasPrefix(req.UserInfo.Username, expectedPrefix)
+}
+
+// listIntegrationConfigs returns the integration team CollectorConfigs in a namespace, for the
+// checks that compare a config with them. It returns none when webhookClient is nil (unit tests
+// without a registered manager) or the list fails: a list failure is logged and should not block
+// valid CRD operations, so the checks are skipped.
+func listIntegrationConfigs(ctx context.Context, namespace string) []CollectorConfig {
+	if webhookClient == nil {
+		return nil
+	}
+	integrationList := &CollectorConfigList{}
+	if err := webhookClient.List(ctx, integrationList,
+		client.InNamespace(namespace),
+		client.MatchingLabels{IntegrationTeamLabel: IntegrationTeamLabelValue},
+	); err != nil {
+		collectorconfiglog.Error(err, "could not list integration team CollectorConfigs; skipping the checks against them")
+		return nil
+	}
+	return integrationList.Items
+}
+
 // validateExcludeAgainstIntegrationConfigs rejects exclude rules on non-integration
 // CollectorConfigs that would conflict with an integration team's include rules.
 // This mirrors the merge-time protection in the operator (excludeOverlapsIntegrationIncludes)
 // but surfaces the error at admission time for an immediate feedback.
 //
 // The check is skipped when:
-//   - webhookClient is nil (unit tests without a registered manager)
 //   - the submitted CollectorConfig is itself an integration team config
 //   - the CollectorConfig has no exclude rules
+//   - the integration team configs can't be listed (see listIntegrationConfigs)
 func validateExcludeAgainstIntegrationConfigs(ctx context.Context, cc *CollectorConfig) error {
-	if webhookClient == nil {
-		return nil
-	}
 	// Integration team configs are allowed to exclude — they own their own rules.
 	if cc.Labels[IntegrationTeamLabel] == IntegrationTeamLabelValue {
 		return nil
@@ -559,16 +691,7 @@ func validateExcludeAgainstIntegrationConfigs(ctx context.Context, cc *Collector
 		return nil
 	}
 
-	// List integration team CollectorConfigs.
-	integrationList := &CollectorConfigList{}
-	if err := webhookClient.List(ctx, integrationList,
-		client.InNamespace(cc.Namespace),
-		client.MatchingLabels{IntegrationTeamLabel: IntegrationTeamLabelValue},
-	); err != nil {
-		// Log and allow — a list failure should not block valid CRD operations.
-		collectorconfiglog.Error(err, "could not list integration team CollectorConfigs; skipping overlap check")
-		return nil
-	}
+	integrationConfigs := listIntegrationConfigs(ctx, cc.Namespace)
 
 	// Reject any exclude that overlaps an integration include.
 	var allErrs field.ErrorList
@@ -577,13 +700,14 @@ func validateExcludeAgainstIntegrationConfigs(ctx context.Context, cc *Collector
 		if excludeRule.Action != ActionExclude {
 			continue
 		}
-		for _, ic := range integrationList.Items {
+		for _, ic := range integrationConfigs {
 			for _, teamRule := range ic.Spec.CollectionRules {
 				if teamRule.Action != ActionInclude {
 					continue
 				}
 				if webhookSetsIntersect(excludeRule.ResourceSelector.APIGroups, teamRule.ResourceSelector.APIGroups) &&
-					webhookSetsIntersect(excludeRule.ResourceSelector.Kinds, teamRule.ResourceSelector.Kinds) {
+					webhookSetsIntersect(excludeRule.ResourceSelector.Kinds, teamRule.ResourceSelector.Kinds) &&
+					!MetadataSelectorsDisjoint(excludeRule.ResourceSelector, teamRule.ResourceSelector) {
 					allErrs = append(allErrs, field.Invalid(
 						rulesPath.Index(i).Child("resourceSelector"),
 						excludeRule.ResourceSelector,
@@ -614,3 +738,68 @@ func webhookSetsIntersect(a, b []string) bool {
 	}
 	return false
 }
+
+// MetadataSelectorsDisjoint returns true when the label or annotation selectors of two
+// ResourceSelectors cannot both match the same resource, e.g. owner=tekton and owner=argo. A
+// selector only narrows a rule, so selectors that are missing, invalid or merely different are
+// not disjoint. The operator uses it with the apiGroup and kind checks to decide rule overlap.
+func MetadataSelectorsDisjoint(a, b ResourceSelector) bool {
+	return labelSelectorsDisjoint(a.LabelSelector, b.LabelSelector) ||
+		labelSelectorsDisjoint(a.AnnotationSelector, b.AnnotationSelector)
+}
+
+// keyConstraint collects what the requirements of two selectors demand of one key.
+type keyConstraint struct {
+	mustExist    bool
+	mustNotExist bool
+	allowed      sets.Set[string] // nil allows any value
+	forbidden    sets.Set[string]
+}
+
+// labelSelectorsDisjoint returns true when the requirements of a and b contradict each other
+// for some key.
+func labelSelectorsDisjoint(a, b *metav1.LabelSelector) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	constraints := map[string]*keyConstraint{}
+	for _, ls := range []*metav1.LabelSelector{a, b} {
+		selector, err := metav1.LabelSelectorAsSelector(ls)
+		if err != nil {
+			return false
+		}
+		reqs, _ := selector.Requirements()
+		for _, req := range reqs {
+			c, ok := constraints[req.Key()]
+			if !ok {
+				c = &keyConstraint{forbidden: sets.New[string]()}
+				constraints[req.Key()] = c
+			}
+			values := sets.New(req.Values().UnsortedList()...)
+			switch req.Operator() {
+			case selection.Equals, selection.DoubleEquals, selection.In:
+				c.mustExist = true
+				if c.allowed == nil {
+					c.allowed = values
+				} else {
+					c.allowed = c.allowed.Intersection(values)
+				}
+			case selection.NotEquals, selection.NotIn:
+				c.forbidden = c.forbidden.Union(values)
+			case selection.Exists:
+				c.mustExist = true
+			case selection.DoesNotExist:
+				c.mustNotExist = true
+			}
+		}
+	}
+	for _, c := range constraints {
+		if c.mustExist && c.mustNotExist {
+			return true
+		}
+		if c.allowed != nil && c.allowed.Difference(c.forbidden).Len() == 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/stolostron/search-v2-operator/pkg/impact"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// impactTimeout bounds the time admission spends counting objects.
+	impactTimeout = 5 * time.Second
+
+	// impactResourcesTTL is how long admission reuses the discovered resource types.
+	impactResourcesTTL = 5 * time.Minute
+)
+
+// impactWarningObjects and impactWarningBytes are the estimated additions above which the webhook
+// warns about a new include rule. Variables so tests can lower them.
+var (
+	impactWarningObjects int64 = 10000
+	impactWarningBytes   int64 = 100 << 20
+
+	// maxImpactResourceTypes limits the resource types counted for one rule during admission. A
+	// wildcard rule is counted on the first ones only, and the estimate is marked partial.
+	maxImpactResourceTypes = 20
+)
+
+// webhookResources caches the resource types webhookEstimator discovered, so admission does not
+// read discovery for every request.
+var webhookResources = &discoveryCache[[]impact.Resource]{}
+
+// discoveryCache is a value read from discovery, read again once it is older than
+// impactResourcesTTL.
+type discoveryCache[T any] struct {
+	mu        sync.Mutex
+	value     T
+	refreshed time.Time
+}
+
+// get returns the cached value, or calls read when there is none or it is older than
+// impactResourcesTTL. Errors are not cached.
+func (c *discoveryCache[T]) get(read func() (T, error)) (T, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.refreshed.IsZero() && time.Since(c.refreshed) < impactResourcesTTL {
+		return c.value, nil
+	}
+	value, err := read()
+	if err != nil {
+		return value, err
+	}
+	c.value, c.refreshed = value, time.Now()
+	return value, nil
+}
+
+// discoverResources returns the resource types webhookEstimator discovers, sorted so that a capped
+// rule counts the same resource types on every request.
+func discoverResources() ([]impact.Resource, error) {
+	resources, err := webhookEstimator.Resources()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(resources, func(i, j int) bool { return resources[i].GVR.String() < resources[j].GVR.String() })
+	return resources, nil
+}
+
+// matchingResources returns the first maxImpactResourceTypes resource types that sel matches, and
+// whether there were more.
+func matchingResources(resources []impact.Resource, sel impact.Selector) ([]impact.Resource, bool) {
+	var matched []impact.Resource
+	for _, r := range resources {
+		if !sel.Matches(r) {
+			continue
+		}
+		if len(matched) == maxImpactResourceTypes {
+			return matched, true
+		}
+		matched = append(matched, r)
+	}
+	return matched, false
+}
+
+// newWebhookEstimator builds an estimator that lists a single page per resource type, so
+// admission stays fast. Counts come from the page's remainingItemCount.
+func newWebhookEstimator(cfg *rest.Config) (*impact.Estimator, error) {
+	cfg = rest.CopyConfig(cfg)
+	cfg.Timeout = impactTimeout
+	estimator, err := impact.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	estimator.MaxPages = 1
+	return estimator, nil
+}
+
+// ImpactSelector converts a ResourceSelector for the impact estimator.
+func ImpactSelector(sel ResourceSelector) impact.Selector {
+	return impact.Selector{
+		APIGroups:          sel.APIGroups,
+		Kinds:              sel.Kinds,
+		LabelSelector:      sel.LabelSelector,
+		AnnotationSelector: sel.AnnotationSelector,
+	}
+}
+
+// warnOnLargeImpact returns a warning for each include rule that is not in oldCC and is estimated
+// to add more than impactWarningObjects objects or impactWarningBytes bytes to search. Only the
+// first maxImpactResourceTypes resource types of a rule are counted; status.impact has the full
+// estimate. The estimate is advisory: counting errors are logged and never reject the request.
+// Operator-written configs are skipped because they repeat the rules of their sources.
+func warnOnLargeImpact(ctx context.Context, cc, oldCC *CollectorConfig) admission.Warnings {
+	if webhookEstimator == nil || repeatsSourceRules(cc) {
+		return nil
+	}
+	var added []int
+	for i, rule := range cc.Spec.CollectionRules {
+		if rule.Action == ActionInclude && !hasIncludeRule(oldCC, rule.ResourceSelector) {
+			added = append(added, i)
+		}
+	}
+	if len(added) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, impactTimeout)
+	defer cancel()
+	resources, err := webhookResources.get(discoverResources)
+	if err != nil {
+		collectorconfiglog.Error(err, "could not discover resources; skipping impact estimate", "name", cc.Name)
+		return nil
+	}
+	var warnings admission.Warnings
+	for _, i := range added {
+		sel := ImpactSelector(cc.Spec.CollectionRules[i].ResourceSelector)
+		matched, capped := matchingResources(resources, sel)
+		est, err := webhookEstimator.Estimate(ctx, matched, sel)
+		if err != nil {
+			collectorconfiglog.Error(err, "could not count all matching objects", "name", cc.Name, "rule", i)
+		}
+		est.Partial = est.Partial || capped
+		if est.Objects > impactWarningObjects || est.Bytes > impactWarningBytes {
+			warnings = append(warnings, fmt.Sprintf(
+				"spec.collectionRules[%d] matches %s%d objects (about %s) on the hub; collecting them "+
+					"from every managed cluster can grow search storage significantly",
+				i, atLeast(est.Partial), est.Objects, formatBytes(est.Bytes)))
+		}
+	}
+	return warnings
+}
+
+// hasIncludeRule reports whether cc already has an include rule with the same selector.
+func hasIncludeRule(cc *CollectorConfig, sel ResourceSelector) bool {
+	if cc == nil {
+		return false
+	}
+	for _, rule := range cc.Spec.CollectionRules {
+		if rule.Action == ActionInclude && equality.Semantic.DeepEqual(rule.ResourceSelector, sel) {
+			return true
+		}
+	}
+	return false
+}
+
+func atLeast(partial bool) string {
+	if partial {
+		return "at least "
+	}
+	return ""
+}
+
+// formatBytes formats a size in binary units, such as 12.5MiB.
+func formatBytes(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%dB", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,120 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// validateCollectNamespaces checks that required namespaces are namespace names, that include and
+// exclude patterns are valid file path expressions, and that each cluster override has a valid
+// clusterSelector.
+func validateCollectNamespaces(cn *CollectNamespaces) field.ErrorList {
+	if cn == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	cnPath := field.NewPath("spec", "collectNamespaces")
+	allErrs = append(allErrs, validateNamespaceSelector(cn.NamespaceSelector, cnPath.Child("namespaceSelector"))...)
+	for i, ns := range cn.Required {
+		for _, msg := range validation.IsDNS1123Label(ns) {
+			allErrs = append(allErrs, field.Invalid(cnPath.Child("required").Index(i), ns, msg))
+		}
+	}
+	for i := range cn.ClusterOverrides {
+		override := &cn.ClusterOverrides[i]
+		overridePath := cnPath.Child("clusterOverrides").Index(i)
+		allErrs = append(allErrs, validateClusterSelector(&override.ClusterSelector, overridePath.Child("clusterSelector"))...)
+		allErrs = append(allErrs, validateNamespaceSelector(&override.NamespaceSelector,
+			overridePath.Child("namespaceSelector"))...)
+	}
+	return allErrs
+}
+
+func validateNamespaceSelector(sel *NamespaceSelector, path *field.Path) field.ErrorList {
+	if sel == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	checkPatterns := func(name string, patterns []string) {
+		for i, pattern := range patterns {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				allErrs = append(allErrs, field.Invalid(path.Child(name).Index(i), pattern,
+					fmt.Sprintf("invalid file path expression: %v", err)))
+			}
+		}
+	}
+	checkPatterns("include", sel.Include)
+	checkPatterns("exclude", sel.Exclude)
+	return allErrs
+}
+
+// RequiredNamespacesExcluded returns the required namespaces that an exclude pattern matches. The
+// operator uses it to drop such patterns from the merged namespace selectors.
+func RequiredNamespacesExcluded(pattern string, required []string) []string {
+	var matched []string
+	for _, ns := range required {
+		if ok, _ := filepath.Match(pattern, ns); ok {
+			matched = append(matched, ns)
+		}
+	}
+	return matched
+}
+
+// validateNamespacesAgainstIntegrationConfigs rejects namespace exclude patterns in a
+// non-integration CollectorConfig that match a namespace an integration config requires. The
+// merge drops such patterns, so rejecting them tells the user right away.
+func validateNamespacesAgainstIntegrationConfigs(ctx context.Context, cc *CollectorConfig) error {
+	if cc.Spec.CollectNamespaces == nil || cc.Labels[IntegrationTeamLabel] == IntegrationTeamLabelValue {
+		return nil
+	}
+	owners := map[string]string{}
+	var required []string
+	for _, ic := range listIntegrationConfigs(ctx, cc.Namespace) {
+		if ic.Spec.CollectNamespaces == nil {
+			continue
+		}
+		for _, ns := range ic.Spec.CollectNamespaces.Required {
+			if _, ok := owners[ns]; !ok {
+				owners[ns] = ic.Name
+				required = append(required, ns)
+			}
+		}
+	}
+	if len(required) == 0 {
+		return nil
+	}
+
+	var allErrs field.ErrorList
+	checkExcludes := func(sel *NamespaceSelector, path *field.Path) {
+		if sel == nil {
+			return
+		}
+		for i, pattern := range sel.Exclude {
+			var reasons []string
+			for _, ns := range RequiredNamespacesExcluded(pattern, required) {
+				reasons = append(reasons, ns+" is required by integration CollectorConfig "+owners[ns])
+			}
+			if len(reasons) > 0 {
+				allErrs = append(allErrs, field.Invalid(path.Child("exclude").Index(i), pattern,
+					"cannot exclude required namespaces: "+strings.Join(reasons, ", ")))
+			}
+		}
+	}
+	cnPath := field.NewPath("spec", "collectNamespaces")
+	checkExcludes(cc.Spec.CollectNamespaces.NamespaceSelector, cnPath.Child("namespaceSelector"))
+	for i := range cc.Spec.CollectNamespaces.ClusterOverrides {
+		checkExcludes(&cc.Spec.CollectNamespaces.ClusterOverrides[i].NamespaceSelector,
+			cnPath.Child("clusterOverrides").Index(i).Child("namespaceSelector"))
+	}
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return allErrs.ToAggregate()
+}
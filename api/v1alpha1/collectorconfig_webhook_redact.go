@@ -0,0 +1,145 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// validateRedactionRules checks that each redaction rule has a unique name, a supported target
+// and action, something to match, and patterns that compile.
+func validateRedactionRules(rules []RedactionRule) field.ErrorList {
+	var allErrs field.ErrorList
+	redactPath := field.NewPath("spec", "redact")
+	names := map[string]bool{}
+	for i, rule := range rules {
+		rulePath := redactPath.Index(i)
+		if rule.Name == "" {
+			allErrs = append(allErrs, field.Required(rulePath.Child("name"), "name is required"))
+		} else if names[rule.Name] {
+			allErrs = append(allErrs, field.Duplicate(rulePath.Child("name"), rule.Name))
+		}
+		names[rule.Name] = true
+
+		switch rule.Target {
+		case RedactionTargetAnnotations, RedactionTargetLabels, RedactionTargetFields:
+		default:
+			allErrs = append(allErrs, field.NotSupported(rulePath.Child("target"), rule.Target, []string{
+				string(RedactionTargetAnnotations), string(RedactionTargetLabels), string(RedactionTargetFields)}))
+		}
+		switch rule.Action {
+		case "", RedactionActionMask, RedactionActionDrop:
+		default:
+			allErrs = append(allErrs, field.NotSupported(rulePath.Child("action"), rule.Action, []string{
+				string(RedactionActionMask), string(RedactionActionDrop)}))
+		}
+
+		if len(rule.Keys) == 0 && rule.KeyPattern == "" && rule.ValuePattern == "" {
+			allErrs = append(allErrs, field.Required(rulePath,
+				"at least one of keys, keyPattern or valuePattern must be set"))
+		}
+		if rule.KeyPattern != "" {
+			if _, err := regexp.Compile(rule.KeyPattern); err != nil {
+				allErrs = append(allErrs, field.Invalid(rulePath.Child("keyPattern"), rule.KeyPattern,
+					fmt.Sprintf("invalid regular expression: %v", err)))
+			}
+		}
+		if rule.ValuePattern != "" {
+			if _, err := regexp.Compile(rule.ValuePattern); err != nil {
+				allErrs = append(allErrs, field.Invalid(rulePath.Child("valuePattern"), rule.ValuePattern,
+					fmt.Sprintf("invalid regular expression: %v", err)))
+			}
+		}
+	}
+	return allErrs
+}
+
+// rejectRedactionRemoval keeps redaction rules from being removed from an integration
+// CollectorConfig shipped with the operator, such as the default deny-list, by anyone but the
+// operator: an update may not drop a rule, change it, or remove the integration label while the
+// config has rules. Other integration configs belong to their teams, which may change them.
+func rejectRedactionRemoval(ctx context.Context, oldCC, cc *CollectorConfig) error {
+	if !isShipped(oldCC) || len(oldCC.Spec.Redact) == 0 {
+		return nil
+	}
+	current := map[string]RedactionRule{}
+	if cc.Labels[IntegrationTeamLabel] == IntegrationTeamLabelValue {
+		for _, rule := range cc.Spec.Redact {
+			current[rule.Name] = rule
+		}
+	}
+	var removed []string
+	for _, rule := range oldCC.Spec.Redact {
+		if kept, ok := current[rule.Name]; !ok || !redactionRuleEqual(kept, rule) {
+			removed = append(removed, rule.Name)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return fmt.Errorf("could not extract admission request: %v", err)
+	}
+	if isOperatorRequest(req) {
+		return nil
+	}
+	return fmt.Errorf("redaction rules %v of built-in CollectorConfig %s can only be changed by the search operator",
+		removed, cc.Name)
+}
+
+// isShipped returns true for the integration CollectorConfigs shipped with the operator.
+func isShipped(cc *CollectorConfig) bool {
+	return cc.Labels[IntegrationTeamLabel] == IntegrationTeamLabelValue &&
+		strings.HasPrefix(cc.Annotations[AnnotationIntegrationSource], IntegrationSourceEmbedded)
+}
+
+// redactionRuleEqual compares two redaction rules, treating the empty action as mask.
+func redactionRuleEqual(a, b RedactionRule) bool {
+	if a.Action == "" {
+		a.Action = RedactionActionMask
+	}
+	if b.Action == "" {
+		b.Action = RedactionActionMask
+	}
+	return a.Name == b.Name && a.Target == b.Target && slices.Equal(a.Keys, b.Keys) &&
+		a.KeyPattern == b.KeyPattern && a.ValuePattern == b.ValuePattern && a.Action == b.Action
+}
+
+// validateRedactionAgainstIntegrationConfigs rejects redaction rules in a non-integration
+// CollectorConfig that reuse the name of an integration config's rule. The merge keeps the first
+// rule with a name, so the user rule would be dropped; rejecting it tells the user right away.
+func validateRedactionAgainstIntegrationConfigs(ctx context.Context, cc *CollectorConfig) error {
+	if len(cc.Spec.Redact) == 0 || cc.Labels[IntegrationTeamLabel] == IntegrationTeamLabelValue {
+		return nil
+	}
+	owners := map[string]string{}
+	for _, ic := range listIntegrationConfigs(ctx, cc.Namespace) {
+		for _, rule := range ic.Spec.Redact {
+			if _, ok := owners[rule.Name]; !ok {
+				owners[rule.Name] = ic.Name
+			}
+		}
+	}
+
+	var allErrs field.ErrorList
+	redactPath := field.NewPath("spec", "redact")
+	for i, rule := range cc.Spec.Redact {
+		if owner, ok := owners[rule.Name]; ok {
+			allErrs = append(allErrs, field.Invalid(redactPath.Index(i).Child("name"), rule.Name,
+				"the redaction rule is defined by integration CollectorConfig "+owner+" and cannot be replaced"))
+		}
+	}
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return allErrs.ToAggregate()
+}
@@ -0,0 +1,68 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1alpha1
+
+import (
+	"regexp"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// edgeTypePattern matches the edge types search stores in the search.edges table.
+var edgeTypePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9]*$`)
+
+// validateRelationships checks that each relationship has a unique name, an edge type, a source
+// kind, and a target JSONPath that parses, and that a target found by name has a kind.
+func validateRelationships(relationships []Relationship) field.ErrorList {
+	var allErrs field.ErrorList
+	relPath := field.NewPath("spec", "relationships")
+	names := map[string]bool{}
+	for i, rel := range relationships {
+		path := relPath.Index(i)
+		if rel.Name == "" {
+			allErrs = append(allErrs, field.Required(path.Child("name"), "name is required"))
+		} else if names[rel.Name] {
+			allErrs = append(allErrs, field.Duplicate(path.Child("name"), rel.Name))
+		}
+		names[rel.Name] = true
+
+		if rel.EdgeType == "" {
+			allErrs = append(allErrs, field.Required(path.Child("edgeType"), "edgeType is required"))
+		} else if !edgeTypePattern.MatchString(rel.EdgeType) {
+			allErrs = append(allErrs, field.Invalid(path.Child("edgeType"), rel.EdgeType,
+				"edgeType must start with a letter and contain only letters and digits"))
+		}
+
+		if rel.Source.Kind == "" {
+			allErrs = append(allErrs, field.Required(path.Child("source", "kind"), "source kind is required"))
+		} else if rel.Source.Kind == "*" || rel.Source.APIGroup == "*" {
+			allErrs = append(allErrs, field.Invalid(path.Child("source"), rel.Source.APIGroup+"/"+rel.Source.Kind,
+				"wildcards are not supported in a relationship source"))
+		}
+
+		targetPath := path.Child("target")
+		if rel.Target.JSONPath == "" {
+			allErrs = append(allErrs, field.Required(targetPath.Child("jsonPath"), "jsonPath is required"))
+		} else if !isValidJSONPath(rel.Target.JSONPath) {
+			allErrs = append(allErrs, field.Invalid(targetPath.Child("jsonPath"), rel.Target.JSONPath,
+				"invalid JSONPath syntax"))
+		}
+		switch rel.Target.ReferenceType {
+		case "", RelationshipReferenceObject, RelationshipReferenceUID:
+		case RelationshipReferenceName:
+			if rel.Target.Kind == "" {
+				allErrs = append(allErrs, field.Required(targetPath.Child("kind"),
+					"kind is required when referenceType is name"))
+			}
+		default:
+			allErrs = append(allErrs, field.NotSupported(targetPath.Child("referenceType"), rel.Target.ReferenceType,
+				[]string{string(RelationshipReferenceObject), string(RelationshipReferenceName),
+					string(RelationshipReferenceUID)}))
+		}
+		if rel.Target.Kind == "*" || rel.Target.APIGroup == "*" {
+			allErrs = append(allErrs, field.Invalid(targetPath, rel.Target.APIGroup+"/"+rel.Target.Kind,
+				"wildcards are not supported in a relationship target"))
+		}
+	}
+	return allErrs
+}
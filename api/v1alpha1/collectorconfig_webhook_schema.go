@@ -0,0 +1,94 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stolostron/search-v2-operator/pkg/schemacheck"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// webhookSchema caches the snapshot of webhookSchemaChecker, so admission does not read discovery
+// and CRDs for every request.
+var webhookSchema = &discoveryCache[*schemacheck.Snapshot]{}
+
+// newWebhookSchemaChecker builds a checker whose calls are bounded by impactTimeout.
+func newWebhookSchemaChecker(cfg *rest.Config) (*schemacheck.Checker, error) {
+	cfg = rest.CopyConfig(cfg)
+	cfg.Timeout = impactTimeout
+	return schemacheck.NewForConfig(cfg)
+}
+
+// SchemaCheckRule converts a CollectionRule for the schema checker. Exclude rules have no fields
+// to check.
+func SchemaCheckRule(rule CollectionRule) schemacheck.Rule {
+	checked := schemacheck.Rule{
+		APIGroups: rule.ResourceSelector.APIGroups,
+		Kinds:     rule.ResourceSelector.Kinds,
+		Versions:  rule.ResourceSelector.Versions,
+	}
+	if rule.Action != ActionInclude {
+		return checked
+	}
+	for _, f := range rule.Fields {
+		checked.Fields = append(checked.Fields, schemacheck.Field{
+			JSONPath:    f.JSONPath,
+			Type:        string(f.Type),
+			Transformed: len(f.Transforms) > 0,
+		})
+	}
+	return checked
+}
+
+// warnOnSchemaMismatch returns a warning for each problem the schema checker finds in the rules
+// that are not in oldCC: apiGroups and kinds the hub does not serve, and custom fields whose
+// jsonPath or type does not match the CRD schema. The kinds may still exist on managed clusters,
+// so these are warnings, and discovery errors are only logged. Discovery data and CRDs are reused
+// for impactResourcesTTL. Operator-written configs are skipped because they repeat the rules of
+// their sources.
+func warnOnSchemaMismatch(ctx context.Context, cc, oldCC *CollectorConfig) admission.Warnings {
+	if webhookSchemaChecker == nil || repeatsSourceRules(cc) {
+		return nil
+	}
+	var changed []int
+	for i, rule := range cc.Spec.CollectionRules {
+		if !hasRule(oldCC, rule) {
+			changed = append(changed, i)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, impactTimeout)
+	defer cancel()
+	snapshot, err := webhookSchema.get(webhookSchemaChecker.Snapshot)
+	if err != nil {
+		collectorconfiglog.Error(err, "could not discover resources; skipping schema check", "name", cc.Name)
+		return nil
+	}
+	var warnings admission.Warnings
+	for _, i := range changed {
+		for _, p := range snapshot.Check(ctx, SchemaCheckRule(cc.Spec.CollectionRules[i])) {
+			warnings = append(warnings, fmt.Sprintf("spec.collectionRules[%d].%s: %s on the hub", i, p.Field, p.Message))
+		}
+	}
+	return warnings
+}
+
+// hasRule reports whether cc already has the same rule.
+func hasRule(cc *CollectorConfig, rule CollectionRule) bool {
+	if cc == nil {
+		return false
+	}
+	for _, r := range cc.Spec.CollectionRules {
+		if equality.Semantic.DeepEqual(r, rule) {
+			return true
+		}
+	}
+	return false
+}
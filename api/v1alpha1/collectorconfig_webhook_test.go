@@ -5,13 +5,22 @@ package v1alpha1
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/stolostron/search-v2-operator/pkg/impact"
+	"github.com/stolostron/search-v2-operator/pkg/schemacheck"
 	"github.com/stretchr/testify/assert"
 	admissionv1 "k8s.io/api/admission/v1"
 	authenticationv1 "k8s.io/api/authentication/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
@@ -235,7 +244,7 @@ func TestAcceptEmptyCollectionRules(t *testing.T) {
 // Accept a config with multiple valid rules.
 func TestAcceptMultipleValidRules(t *testing.T) {
 	c := validConfig()
-	// Use coordination.k8s.io — not in protectedAPIGroups, valid for user exclusion
+	// Use coordination.k8s.io — not in DefaultProtectedResources, valid for user exclusion
 	c.Spec.CollectionRules = append(c.Spec.CollectionRules, CollectionRule{
 		Action: ActionExclude,
 		ResourceSelector: ResourceSelector{
@@ -397,6 +406,46 @@ func TestRejectCollectPrinterColumnsBelowNegativeOne(t *testing.T) {
 	assert.Contains(t, err.Error(), "must be >= -1 (-1 disables collection)")
 }
 
+// Accept a rule limited to clusters by label and a config limited by Placement.
+func TestAcceptClusterSelectors(t *testing.T) {
+	c := validConfig()
+	c.Spec.CollectionRules[0].ClusterSelector = &ClusterSelector{
+		LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"gitops": "true"}},
+	}
+	c.Spec.ClusterSelector = &ClusterSelector{PlacementRef: &PlacementRef{Name: "edge"}}
+	_, err := c.ValidateCreate(context.Background(), c)
+	assert.NoError(t, err)
+}
+
+// Reject a clusterSelector that sets both labelSelector and placementRef, or neither.
+func TestRejectClusterSelectorWithBothOrNeither(t *testing.T) {
+	c := validConfig()
+	c.Spec.CollectionRules[0].ClusterSelector = &ClusterSelector{
+		LabelSelector: &metav1.LabelSelector{},
+		PlacementRef:  &PlacementRef{Name: "edge"},
+	}
+	_, err := c.ValidateCreate(context.Background(), c)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "specify exactly one of labelSelector or placementRef")
+
+	c.Spec.CollectionRules[0].ClusterSelector = &ClusterSelector{}
+	_, err = c.ValidateCreate(context.Background(), c)
+	assert.Error(t, err)
+}
+
+// Reject a cluster label selector that cannot be parsed.
+func TestRejectInvalidClusterLabelSelector(t *testing.T) {
+	c := validConfig()
+	c.Spec.ClusterSelector = &ClusterSelector{
+		LabelSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "env", Operator: "Near", Values: []string{"edge"}},
+		}},
+	}
+	_, err := c.ValidateCreate(context.Background(), c)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "spec.clusterSelector.labelSelector")
+}
+
 // --- Webhook protection tests ---
 
 func ctxWithUser(username string) context.Context {
@@ -778,7 +827,7 @@ func TestAcceptExcludeWildcardKindOnSafeAPIGroup(t *testing.T) {
 	assert.NoError(t, err, "wildcard kind on a non-protected apiGroup (apps) should be accepted")
 }
 
-// Groups that used to be in the static protectedAPIGroups safety net before ACM-37052 shipped
+// Groups that used to be in the DefaultProtectedResources safety net before ACM-37052 shipped
 // real integration CollectorConfigs for them (kubevirt.io -> CNV, argoproj.io -> Argo, etc).
 // Without a fake webhookClient, the dynamic integration-overlap check is skipped (see
 // TestExcludeIntegrationCheckSkippedWhenClientNil), so these should now be accepted.
@@ -809,13 +858,13 @@ func TestAcceptExcludeFormerlyStaticProtectedGroupsNowIntegrationOwned(t *testin
 				},
 			}
 			_, err := c.ValidateCreate(context.Background(), c)
-			assert.NoError(t, err, apiGroup+" is no longer in the static protectedAPIGroups list")
+			assert.NoError(t, err, apiGroup+" is no longer in the DefaultProtectedResources list")
 		})
 	}
 }
 
 // Groups with no single integration-team owner (or that Search itself depends on) remain in the
-// static protectedAPIGroups safety net after ACM-37052.
+// DefaultProtectedResources safety net after ACM-37052.
 func TestRejectExcludeStillStaticProtectedGroups(t *testing.T) {
 	stillProtected := []string{
 		"",                                    // core group — ConfigMap/Job/Node/Pod/PVC, multiple owners
@@ -975,7 +1024,7 @@ func TestAllowIntegrationConfigWithExcludeRule(t *testing.T) {
 
 // When webhookClient is nil (unit test without manager), the dynamic integration
 // config overlap check is skipped. Uses coordination.k8s.io which is not in
-// protectedAPIGroups so only the dynamic check is relevant here.
+// DefaultProtectedResources so only the dynamic check is relevant here.
 func TestExcludeIntegrationCheckSkippedWhenClientNil(t *testing.T) {
 	webhookClient = nil
 	c := validConfig()
@@ -1006,3 +1055,664 @@ func TestExcludeAllowedWhenIntegrationListFails(t *testing.T) {
 	_, err := c.ValidateCreate(context.Background(), c)
 	assert.NoError(t, err, "a List failure should be logged and allowed, not rejected")
 }
+
+// --- Label and annotation selectors ---
+
+// An exclude narrowed by a label selector may target a protected apiGroup, e.g. ConfigMaps
+// labelled owner=tekton in the core group.
+func TestAcceptNarrowedExcludeOnProtectedAPIGroup(t *testing.T) {
+	c := validConfig()
+	c.Spec.CollectionRules[0] = CollectionRule{
+		Action: ActionExclude,
+		ResourceSelector: ResourceSelector{
+			APIGroups:     []string{""},
+			Kinds:         []string{"ConfigMap"},
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"owner": "tekton"}},
+		},
+	}
+	_, err := c.ValidateCreate(context.Background(), c)
+	assert.NoError(t, err)
+}
+
+// A selector does not make excluding protected kinds or all apiGroups acceptable.
+func TestRejectNarrowedExcludeOfProtectedKindOrAllGroups(t *testing.T) {
+	narrowed := &metav1.LabelSelector{MatchLabels: map[string]string{"owner": "tekton"}}
+	for name, selector := range map[string]ResourceSelector{
+		"namespace":  {APIGroups: []string{""}, Kinds: []string{"Namespace"}, LabelSelector: narrowed},
+		"all groups": {APIGroups: []string{"*"}, Kinds: []string{"Lease"}, AnnotationSelector: narrowed},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := validConfig()
+			c.Spec.CollectionRules[0] = CollectionRule{Action: ActionExclude, ResourceSelector: selector}
+			_, err := c.ValidateCreate(context.Background(), c)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRejectInvalidMetadataSelectors(t *testing.T) {
+	invalid := &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+		{Key: "owner", Operator: metav1.LabelSelectorOpIn},
+	}}
+	c := validConfig()
+	c.Spec.CollectionRules[0].ResourceSelector.LabelSelector = invalid
+	c.Spec.CollectionRules[0].ResourceSelector.AnnotationSelector = invalid
+	_, err := c.ValidateCreate(context.Background(), c)
+	assert.ErrorContains(t, err, "resourceSelector.labelSelector")
+	assert.Contains(t, err.Error(), "resourceSelector.annotationSelector")
+}
+
+// A user exclude whose selector cannot match the resources an integration config includes does
+// not overlap it; one that may match them does.
+func TestExcludeOverlapWithMetadataSelectors(t *testing.T) {
+	team := integrationCC("tekton-config", "default", "tekton.dev", []string{"PipelineRun"})
+	team.Spec.CollectionRules[0].ResourceSelector.LabelSelector = &metav1.LabelSelector{
+		MatchLabels: map[string]string{"owner": "tekton"},
+	}
+	buildFakeWebhookClient(t, team)
+
+	exclude := func(sel *metav1.LabelSelector) *CollectorConfig {
+		c := validConfig()
+		c.Namespace = "default"
+		c.Spec.CollectionRules[0] = CollectionRule{
+			Action: ActionExclude,
+			ResourceSelector: ResourceSelector{
+				APIGroups:     []string{"tekton.dev"},
+				Kinds:         []string{"PipelineRun"},
+				LabelSelector: sel,
+			},
+		}
+		return c
+	}
+
+	c := exclude(&metav1.LabelSelector{MatchLabels: map[string]string{"owner": "argo"}})
+	_, err := c.ValidateCreate(context.Background(), c)
+	assert.NoError(t, err, "owner=argo cannot match resources labelled owner=tekton")
+
+	c = exclude(&metav1.LabelSelector{MatchLabels: map[string]string{"tier": "batch"}})
+	_, err = c.ValidateCreate(context.Background(), c)
+	assert.Error(t, err, "tier=batch may match resources labelled owner=tekton")
+}
+
+func TestMetadataSelectorsDisjoint(t *testing.T) {
+	labels := func(ls *metav1.LabelSelector) ResourceSelector { return ResourceSelector{LabelSelector: ls} }
+	matchLabels := func(k, v string) *metav1.LabelSelector {
+		return &metav1.LabelSelector{MatchLabels: map[string]string{k: v}}
+	}
+	expr := func(k string, op metav1.LabelSelectorOperator, values ...string) *metav1.LabelSelector {
+		return &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: k, Operator: op, Values: values},
+		}}
+	}
+	tests := []struct {
+		name     string
+		a, b     ResourceSelector
+		disjoint bool
+	}{
+		{"no selectors", ResourceSelector{}, ResourceSelector{}, false},
+		{"one selector", labels(matchLabels("owner", "tekton")), ResourceSelector{}, false},
+		{"same value", labels(matchLabels("owner", "tekton")), labels(matchLabels("owner", "tekton")), false},
+		{"different values", labels(matchLabels("owner", "tekton")), labels(matchLabels("owner", "argo")), true},
+		{"different keys", labels(matchLabels("owner", "tekton")), labels(matchLabels("tier", "batch")), false},
+		{"in sets intersect", labels(expr("owner", metav1.LabelSelectorOpIn, "a", "b")),
+			labels(expr("owner", metav1.LabelSelectorOpIn, "b", "c")), false},
+		{"in sets disjoint", labels(expr("owner", metav1.LabelSelectorOpIn, "a")),
+			labels(expr("owner", metav1.LabelSelectorOpIn, "c")), true},
+		{"not in all values", labels(matchLabels("owner", "tekton")),
+			labels(expr("owner", metav1.LabelSelectorOpNotIn, "tekton")), true},
+		{"exists and does not exist", labels(expr("owner", metav1.LabelSelectorOpExists)),
+			labels(expr("owner", metav1.LabelSelectorOpDoesNotExist)), true},
+		{"annotations disjoint",
+			ResourceSelector{AnnotationSelector: matchLabels("search/visible", "true")},
+			ResourceSelector{AnnotationSelector: matchLabels("search/visible", "false")}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.disjoint, MetadataSelectorsDisjoint(tt.a, tt.b))
+			assert.Equal(t, tt.disjoint, MetadataSelectorsDisjoint(tt.b, tt.a))
+		})
+	}
+}
+
+// --- Field transforms ---
+
+func configWithTransforms(transforms ...FieldTransform) *CollectorConfig {
+	c := validConfig()
+	c.Spec.CollectionRules[0].Fields = []Field{
+		{Name: "imageTag", JSONPath: "{.spec.template.spec.containers[0].image}", Transforms: transforms},
+	}
+	return c
+}
+
+func TestAcceptFieldTransforms(t *testing.T) {
+	wholeMatch, sep, def := 0, ";", "none"
+	c := configWithTransforms(
+		FieldTransform{When: &WhenTransform{JSONPath: ".status.readyReplicas"}},
+		FieldTransform{Regex: &RegexTransform{Pattern: `:([^:]+)$`}},
+		FieldTransform{Regex: &RegexTransform{Pattern: `^\d+`, Group: &wholeMatch}},
+		FieldTransform{Map: &MapTransform{Values: map[string]string{"1": "v1"}}},
+		FieldTransform{Join: &JoinTransform{Separator: &sep}},
+		FieldTransform{Default: &def},
+	)
+	_, err := c.ValidateCreate(context.Background(), c)
+	assert.NoError(t, err)
+}
+
+func TestRejectInvalidFieldTransforms(t *testing.T) {
+	def, group := "none", 2
+	tests := map[string]struct {
+		transform FieldTransform
+		errPath   string
+	}{
+		"no operation":      {FieldTransform{}, "transforms[0]"},
+		"two operations":    {FieldTransform{Default: &def, Join: &JoinTransform{}}, "transforms[0]"},
+		"bad regex":         {FieldTransform{Regex: &RegexTransform{Pattern: "("}}, "transforms[0].regex.pattern"},
+		"no capture group":  {FieldTransform{Regex: &RegexTransform{Pattern: "v[0-9]+"}}, "transforms[0].regex.pattern"},
+		"missing group":     {FieldTransform{Regex: &RegexTransform{Pattern: "(v)", Group: &group}}, "transforms[0].regex.group"},
+		"empty map":         {FieldTransform{Map: &MapTransform{}}, "transforms[0].map.values"},
+		"bad when jsonPath": {FieldTransform{When: &WhenTransform{JSONPath: "status"}}, "transforms[0].when.jsonPath"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := configWithTransforms(tt.transform)
+			_, err := c.ValidateCreate(context.Background(), c)
+			assert.ErrorContains(t, err, tt.errPath)
+		})
+	}
+}
+
+// setWebhookEstimator sets webhookEstimator to one backed by a hub with the given number of
+// Deployments, and lowers the warning threshold to 2 objects.
+func setWebhookEstimator(t *testing.T, deployments int) {
+	t.Helper()
+	var objs []runtime.Object
+	for i := 0; i < deployments; i++ {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("apps/v1")
+		u.SetKind("Deployment")
+		u.SetNamespace("default")
+		u.SetName(fmt.Sprintf("d%d", i))
+		objs = append(objs, u)
+	}
+	dyn := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		{Group: "apps", Version: "v1", Resource: "deployments"}:  "DeploymentList",
+		{Group: "apps", Version: "v1", Resource: "statefulsets"}: "StatefulSetList",
+	}, objs...)
+	disc := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: []*metav1.APIResourceList{
+		{GroupVersion: "apps/v1", APIResources: []metav1.APIResource{
+			{Name: "deployments", Kind: "Deployment", Namespaced: true, Verbs: []string{"list"}},
+			{Name: "statefulsets", Kind: "StatefulSet", Namespaced: true, Verbs: []string{"list"}},
+		}},
+	}}}
+	webhookEstimator = &impact.Estimator{Discovery: disc, Dynamic: dyn, MaxPages: 1}
+	webhookResources = &discoveryCache[[]impact.Resource]{}
+	impactWarningObjects = 2
+	t.Cleanup(func() {
+		webhookEstimator = nil
+		webhookResources = &discoveryCache[[]impact.Resource]{}
+		impactWarningObjects = 10000
+	})
+}
+
+func TestWarnOnLargeImpact_NewIncludeRule(t *testing.T) {
+	setWebhookEstimator(t, 3)
+	cc := validConfig()
+
+	warnings, err := cc.ValidateCreate(context.Background(), cc)
+	assert.NoError(t, err)
+	if assert.Len(t, warnings, 1) {
+		assert.Contains(t, warnings[0], "spec.collectionRules[0] matches 3 objects")
+	}
+
+	// The rule is not new on update, so there is nothing to warn about.
+	warnings, err = cc.ValidateUpdate(context.Background(), validConfig(), cc)
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+}
+
+func TestWarnOnLargeImpact_BelowThreshold(t *testing.T) {
+	setWebhookEstimator(t, 2)
+	cc := validConfig()
+
+	warnings, err := cc.ValidateCreate(context.Background(), cc)
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+}
+
+func TestWarnOnLargeImpact_CachesDiscovery(t *testing.T) {
+	setWebhookEstimator(t, 3)
+	cc := validConfig()
+
+	assert.Len(t, warnOnLargeImpact(context.Background(), cc, nil), 1)
+	// Drop the resource types from discovery; admission keeps using the cached ones.
+	webhookEstimator.Discovery.(*fakediscovery.FakeDiscovery).Resources = nil
+	assert.Len(t, warnOnLargeImpact(context.Background(), cc, nil), 1)
+
+	// Once the snapshot expires, discovery is read again.
+	webhookResources.refreshed = time.Now().Add(-impactResourcesTTL)
+	assert.Empty(t, warnOnLargeImpact(context.Background(), cc, nil))
+}
+
+func TestWarnOnLargeImpact_CapsResourceTypes(t *testing.T) {
+	setWebhookEstimator(t, 3)
+	maxImpactResourceTypes = 1
+	t.Cleanup(func() { maxImpactResourceTypes = 20 })
+	cc := validConfig()
+	cc.Spec.CollectionRules[0].ResourceSelector.Kinds = []string{"*"}
+
+	warnings := warnOnLargeImpact(context.Background(), cc, nil)
+	if assert.Len(t, warnings, 1) {
+		assert.Contains(t, warnings[0], "spec.collectionRules[0] matches at least 3 objects")
+	}
+}
+
+func TestWarnOnLargeImpact_SkipsOperatorOwnedConfigs(t *testing.T) {
+	setWebhookEstimator(t, 3)
+	cc := validConfig()
+	cc.OwnerReferences = []metav1.OwnerReference{{Name: "search-v2-operator", Controller: boolPtr(true)}}
+
+	assert.Empty(t, warnOnLargeImpact(context.Background(), cc, nil))
+}
+
+// Per-cluster configs have no owner reference, but repeat the rules of their sources too.
+func TestWarnOnLargeImpact_SkipsClusterConfigs(t *testing.T) {
+	setWebhookEstimator(t, 3)
+	cc := validConfig()
+	cc.Labels = map[string]string{IntegrationTeamLabel: ClusterConfigLabelValue}
+
+	assert.Empty(t, warnOnLargeImpact(context.Background(), cc, nil))
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512B", formatBytes(512))
+	assert.Equal(t, "1.5KiB", formatBytes(1536))
+	assert.Equal(t, "100.0MiB", formatBytes(100<<20))
+}
+
+// setWebhookSchemaChecker sets webhookSchemaChecker to one backed by a hub that serves
+// Deployments and no CRDs.
+func setWebhookSchemaChecker(t *testing.T) {
+	t.Helper()
+	disc := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: []*metav1.APIResourceList{
+		{GroupVersion: "apps/v1", APIResources: []metav1.APIResource{{Name: "deployments", Kind: "Deployment"}}},
+	}}}
+	dyn := fakedynamic.NewSimpleDynamicClient(runtime.NewScheme())
+	webhookSchemaChecker = &schemacheck.Checker{Discovery: disc, Dynamic: dyn}
+	webhookSchema = &discoveryCache[*schemacheck.Snapshot]{}
+	t.Cleanup(func() {
+		webhookSchemaChecker = nil
+		webhookSchema = &discoveryCache[*schemacheck.Snapshot]{}
+	})
+}
+
+func TestWarnOnSchemaMismatch(t *testing.T) {
+	setWebhookSchemaChecker(t)
+	cc := validConfig()
+	cc.Spec.CollectionRules = append(cc.Spec.CollectionRules, CollectionRule{
+		Action:           ActionInclude,
+		ResourceSelector: ResourceSelector{APIGroups: []string{"argoproj.i0"}, Kinds: []string{"Application"}},
+	})
+
+	warnings, err := cc.ValidateCreate(context.Background(), cc)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`spec.collectionRules[1].resourceSelector.apiGroups: apiGroup "argoproj.i0" is not served on the hub`,
+	}, []string(warnings))
+
+	// The rule is not new on update, so there is nothing to warn about.
+	warnings, err = cc.ValidateUpdate(context.Background(), cc.DeepCopy(), cc)
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+}
+
+func TestWarnOnSchemaMismatch_CachesDiscovery(t *testing.T) {
+	setWebhookSchemaChecker(t)
+	cc := validConfig()
+	cc.Spec.CollectionRules[0].ResourceSelector = ResourceSelector{APIGroups: []string{"apps"}, Kinds: []string{"Deployment"}}
+
+	assert.Empty(t, warnOnSchemaMismatch(context.Background(), cc, nil))
+	// Drop the resource types from discovery; admission keeps using the cached snapshot.
+	webhookSchemaChecker.Discovery.(*fakediscovery.FakeDiscovery).Resources = nil
+	assert.Empty(t, warnOnSchemaMismatch(context.Background(), cc, nil))
+
+	// Once the snapshot expires, discovery is read again.
+	webhookSchema.refreshed = time.Now().Add(-impactResourcesTTL)
+	assert.Len(t, warnOnSchemaMismatch(context.Background(), cc, nil), 1)
+}
+
+func TestWarnOnSchemaMismatch_SkipsClusterConfigs(t *testing.T) {
+	setWebhookSchemaChecker(t)
+	cc := validConfig()
+	cc.Labels = map[string]string{IntegrationTeamLabel: ClusterConfigLabelValue}
+	cc.Spec.CollectionRules[0].ResourceSelector = ResourceSelector{APIGroups: []string{"argoproj.i0"}, Kinds: []string{"Application"}}
+
+	assert.Empty(t, warnOnSchemaMismatch(context.Background(), cc, nil))
+}
+
+// --- collectorConfigPolicy ---
+
+func searchWithPolicy(namespace string, protected ...ProtectedResource) *Search {
+	return &Search{
+		ObjectMeta: metav1.ObjectMeta{Name: searchName, Namespace: namespace},
+		Spec: SearchSpec{
+			CollectorConfigPolicy: CollectorConfigPolicy{ProtectedResources: protected},
+		},
+	}
+}
+
+func excludeConfig(apiGroup, kind string) *CollectorConfig {
+	c := validConfig()
+	c.Spec.CollectionRules[0] = CollectionRule{
+		Action:           ActionExclude,
+		ResourceSelector: ResourceSelector{APIGroups: []string{apiGroup}, Kinds: []string{kind}},
+	}
+	return c
+}
+
+// Policy entries replace the default with the same apiGroup and kind, and others are added.
+func TestEffectiveProtectedResources(t *testing.T) {
+	assert.Equal(t, DefaultProtectedResources, CollectorConfigPolicy{}.EffectiveProtectedResources())
+
+	policy := CollectorConfigPolicy{ProtectedResources: []ProtectedResource{
+		{APIGroup: "", Severity: ProtectionSeverityWarn},
+		{APIGroup: "rbac.example.com", Kind: "AccessGrant"},
+		{APIGroup: "", Kind: "Namespace", Severity: ProtectionSeverityWarn},
+	}}
+	resources := policy.EffectiveProtectedResources()
+	assert.Len(t, resources, len(DefaultProtectedResources)+1)
+	assert.Contains(t, resources, ProtectedResource{APIGroup: "", Severity: ProtectionSeverityWarn})
+	assert.Contains(t, resources, ProtectedResource{
+		APIGroup: "rbac.example.com", Kind: "AccessGrant", Severity: ProtectionSeverityReject})
+	assert.Contains(t, resources, ProtectedResource{
+		APIGroup: "", Kind: "Namespace", Severity: ProtectionSeverityReject}, "RBAC kinds can't be loosened")
+	assert.Equal(t, ProtectionSeverityReject, DefaultProtectedResources[2].Severity, "defaults are not modified")
+}
+
+// A kind added to the policy can't be excluded, by name or with a wildcard kind.
+func TestRejectExcludeKindProtectedByPolicy(t *testing.T) {
+	buildFakeWebhookClient(t, searchWithPolicy("default",
+		ProtectedResource{APIGroup: "rbac.example.com", Kind: "AccessGrant", Severity: ProtectionSeverityReject}))
+
+	for _, kind := range []string{"AccessGrant", "*"} {
+		c := excludeConfig("rbac.example.com", kind)
+		_, err := c.ValidateCreate(context.Background(), c)
+		assert.Error(t, err, kind)
+		assert.Contains(t, err.Error(), "AccessGrant")
+	}
+
+	c := excludeConfig("rbac.example.com", "Other")
+	_, err := c.ValidateCreate(context.Background(), c)
+	assert.NoError(t, err)
+}
+
+// A group with severity Warn admits the exclude with a warning; its protected kinds stay rejected.
+func TestWarnExcludeGroupLoosenedByPolicy(t *testing.T) {
+	buildFakeWebhookClient(t, searchWithPolicy("default",
+		ProtectedResource{APIGroup: "", Severity: ProtectionSeverityWarn}))
+
+	c := excludeConfig("", "Secret")
+	warnings, err := c.ValidateCreate(context.Background(), c)
+	assert.NoError(t, err)
+	assert.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "cannot exclude resources in apiGroup")
+
+	c = excludeConfig("", "Namespace")
+	_, err = c.ValidateUpdate(context.Background(), c, c)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot exclude Namespace")
+}
+
+// The defaults apply when there is no Search CR in the namespace.
+func TestProtectedResourcesDefaultWithoutSearch(t *testing.T) {
+	buildFakeWebhookClient(t, searchWithPolicy("other",
+		ProtectedResource{APIGroup: "", Severity: ProtectionSeverityWarn}))
+
+	c := excludeConfig("", "Secret")
+	_, err := c.ValidateCreate(context.Background(), c)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot exclude resources in apiGroup")
+}
+
+// --- Redaction rules ---
+
+func redactionConfig(rules ...RedactionRule) *CollectorConfig {
+	c := validConfig()
+	c.Spec.Redact = rules
+	return c
+}
+
+func TestAcceptRedactionRules(t *testing.T) {
+	c := redactionConfig(
+		RedactionRule{Name: "tokens", Target: RedactionTargetAnnotations, KeyPattern: "(?i)token"},
+		RedactionRule{Name: "last-applied", Target: RedactionTargetAnnotations,
+			Keys: []string{"kubectl.kubernetes.io/last-applied-configuration"}, Action: RedactionActionDrop},
+	)
+	_, err := c.ValidateCreate(context.Background(), c)
+	assert.NoError(t, err)
+}
+
+func TestRejectInvalidRedactionRules(t *testing.T) {
+	tests := []struct {
+		name string
+		rule RedactionRule
+		want string
+	}{
+		{"no match criteria", RedactionRule{Name: "a", Target: RedactionTargetLabels},
+			"at least one of keys, keyPattern or valuePattern"},
+		{"bad key pattern", RedactionRule{Name: "a", Target: RedactionTargetLabels, KeyPattern: "("},
+			"spec.redact[0].keyPattern"},
+		{"bad value pattern", RedactionRule{Name: "a", Target: RedactionTargetFields, ValuePattern: "[a"},
+			"spec.redact[0].valuePattern"},
+		{"bad target", RedactionRule{Name: "a", Target: "status", Keys: []string{"x"}},
+			"spec.redact[0].target"},
+		{"bad action", RedactionRule{Name: "a", Target: RedactionTargetLabels, Keys: []string{"x"}, Action: "hash"},
+			"spec.redact[0].action"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := redactionConfig(tt.rule)
+			_, err := c.ValidateCreate(context.Background(), c)
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+
+	rule := RedactionRule{Name: "a", Target: RedactionTargetLabels, Keys: []string{"x"}}
+	c := redactionConfig(rule, rule)
+	_, err := c.ValidateCreate(context.Background(), c)
+	assert.ErrorContains(t, err, "spec.redact[1].name: Duplicate value")
+}
+
+func integrationRedactionConfig() *CollectorConfig {
+	c := redactionConfig(
+		RedactionRule{Name: "tokens", Target: RedactionTargetAnnotations, KeyPattern: "(?i)token"},
+		RedactionRule{Name: "passwords", Target: RedactionTargetLabels, KeyPattern: "(?i)password"},
+	)
+	c.Name = "redaction-integration"
+	c.Namespace = "open-cluster-management"
+	c.Labels = map[string]string{IntegrationTeamLabel: IntegrationTeamLabelValue}
+	c.Annotations = map[string]string{AnnotationIntegrationSource: IntegrationSourceEmbedded + "redaction.yaml"}
+	return c
+}
+
+// Only the operator can remove or change the redaction rules of a built-in integration config.
+func TestRejectRedactionRemovalFromIntegrationConfig(t *testing.T) {
+	oldCC := integrationRedactionConfig()
+
+	removed := oldCC.DeepCopy()
+	removed.Spec.Redact = removed.Spec.Redact[:1]
+	_, err := removed.ValidateUpdate(nonOperatorCtx(), oldCC, removed)
+	assert.ErrorContains(t, err, "redaction rules [passwords]")
+
+	weakened := oldCC.DeepCopy()
+	weakened.Spec.Redact[0].KeyPattern = "^never$"
+	_, err = weakened.ValidateUpdate(nonOperatorCtx(), oldCC, weakened)
+	assert.ErrorContains(t, err, "redaction rules [tokens]")
+
+	unlabeled := oldCC.DeepCopy()
+	unlabeled.Labels = nil
+	_, err = unlabeled.ValidateUpdate(nonOperatorCtx(), oldCC, unlabeled)
+	assert.ErrorContains(t, err, "redaction rules [tokens passwords]")
+
+	_, err = removed.ValidateUpdate(operatorCtx(), oldCC, removed)
+	assert.NoError(t, err, "the operator restores and updates the shipped defaults")
+
+	added := oldCC.DeepCopy()
+	added.Spec.Redact = append(added.Spec.Redact,
+		RedactionRule{Name: "keys", Target: RedactionTargetFields, KeyPattern: "(?i)apikey"})
+	_, err = added.ValidateUpdate(nonOperatorCtx(), oldCC, added)
+	assert.NoError(t, err, "adding redaction rules is allowed")
+
+	stripped := removed.DeepCopy()
+	stripped.Annotations = nil
+	_, err = stripped.ValidateUpdate(nonOperatorCtx(), oldCC, stripped)
+	assert.ErrorContains(t, err, "redaction rules [passwords]", "the old config decides")
+}
+
+// Integration configs that don't ship with the operator belong to their teams.
+func TestAllowRedactionRemovalFromTeamConfig(t *testing.T) {
+	for _, source := range []string{"", "configmap:team-configs/redaction.yaml"} {
+		oldCC := integrationRedactionConfig()
+		oldCC.Annotations[AnnotationIntegrationSource] = source
+
+		removed := oldCC.DeepCopy()
+		removed.Spec.Redact = removed.Spec.Redact[:1]
+		_, err := removed.ValidateUpdate(nonOperatorCtx(), oldCC, removed)
+		assert.NoError(t, err, source)
+	}
+}
+
+// A user config can't reuse the name of an integration config's redaction rule.
+func TestRejectRedactionRuleShadowingIntegrationConfig(t *testing.T) {
+	integration := integrationRedactionConfig()
+	integration.Namespace = "default"
+	buildFakeWebhookClient(t, integration)
+
+	c := redactionConfig(RedactionRule{Name: "tokens", Target: RedactionTargetAnnotations, Keys: []string{"x"}})
+	_, err := c.ValidateCreate(context.Background(), c)
+	assert.ErrorContains(t, err, "defined by integration CollectorConfig redaction-integration")
+
+	c = redactionConfig(RedactionRule{Name: "team-secrets", Target: RedactionTargetAnnotations, Keys: []string{"x"}})
+	_, err = c.ValidateCreate(context.Background(), c)
+	assert.NoError(t, err)
+}
+
+func validRelationship() Relationship {
+	return Relationship{
+		Name:     "myapp-workload",
+		EdgeType: "usesWorkload",
+		Source:   RelationshipSource{APIGroup: "example.com", Kind: "MyApp"},
+		Target:   RelationshipTarget{JSONPath: "{.spec.workloadRef}"},
+	}
+}
+
+func TestAcceptRelationships(t *testing.T) {
+	byName := validRelationship()
+	byName.Name = "myapp-config"
+	byName.Target = RelationshipTarget{JSONPath: ".spec.configName", ReferenceType: RelationshipReferenceName,
+		Kind: "ConfigMap"}
+	c := validConfig()
+	c.Spec.Relationships = []Relationship{validRelationship(), byName}
+	_, err := c.ValidateCreate(context.Background(), c)
+	assert.NoError(t, err)
+}
+
+func TestRejectInvalidRelationships(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Relationship)
+		want   string
+	}{
+		{"no edge type", func(r *Relationship) { r.EdgeType = "" }, "spec.relationships[0].edgeType: Required"},
+		{"bad edge type", func(r *Relationship) { r.EdgeType = "uses-workload" }, "spec.relationships[0].edgeType: Invalid"},
+		{"no source kind", func(r *Relationship) { r.Source.Kind = "" }, "spec.relationships[0].source.kind"},
+		{"wildcard source", func(r *Relationship) { r.Source.Kind = "*" }, "wildcards are not supported"},
+		{"no jsonPath", func(r *Relationship) { r.Target.JSONPath = "" }, "spec.relationships[0].target.jsonPath: Required"},
+		{"bad jsonPath", func(r *Relationship) { r.Target.JSONPath = "{.spec[}" }, "invalid JSONPath syntax"},
+		{"name without kind", func(r *Relationship) { r.Target.ReferenceType = RelationshipReferenceName },
+			"kind is required when referenceType is name"},
+		{"bad reference type", func(r *Relationship) { r.Target.ReferenceType = "label" },
+			"spec.relationships[0].target.referenceType"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rel := validRelationship()
+			tt.modify(&rel)
+			c := validConfig()
+			c.Spec.Relationships = []Relationship{rel}
+			_, err := c.ValidateCreate(context.Background(), c)
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+
+	c := validConfig()
+	c.Spec.Relationships = []Relationship{validRelationship(), validRelationship()}
+	_, err := c.ValidateCreate(context.Background(), c)
+	assert.ErrorContains(t, err, "spec.relationships[1].name: Duplicate value")
+}
+
+func TestRejectInvalidCollectNamespaces(t *testing.T) {
+	c := validConfig()
+	c.Spec.CollectNamespaces = &CollectNamespaces{
+		NamespaceSelector: &NamespaceSelector{Exclude: []string{"team-["}},
+		Required:          []string{"Not_A_Namespace"},
+		ClusterOverrides: []NamespaceSelectorOverride{{
+			ClusterSelector: ClusterSelector{LabelSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"cluster.open-cluster-management.io/clusterset": "edge"}}},
+			NamespaceSelector: NamespaceSelector{Include: []string{"edge-*"}},
+		}},
+	}
+	_, err := c.ValidateCreate(context.Background(), c)
+	assert.ErrorContains(t, err, "spec.collectNamespaces.namespaceSelector.exclude[0]")
+	assert.ErrorContains(t, err, "spec.collectNamespaces.required[0]")
+	assert.NotContains(t, err.Error(), "clusterOverrides")
+}
+
+// A user can't exclude a namespace an integration config requires, in its selector or an override.
+func TestRejectExcludeOfRequiredNamespace(t *testing.T) {
+	integration := integrationCC("cnv-integration", "default", "kubevirt.io", []string{"VirtualMachine"})
+	integration.Spec.CollectNamespaces = &CollectNamespaces{Required: []string{"openshift-cnv"}}
+	buildFakeWebhookClient(t, integration)
+
+	c := validConfig()
+	c.Spec.CollectNamespaces = &CollectNamespaces{
+		NamespaceSelector: &NamespaceSelector{Exclude: []string{"openshift-*"}},
+	}
+	_, err := c.ValidateCreate(context.Background(), c)
+	assert.ErrorContains(t, err, "openshift-cnv is required by integration CollectorConfig cnv-integration")
+
+	c.Spec.CollectNamespaces = &CollectNamespaces{
+		ClusterOverrides: []NamespaceSelectorOverride{{
+			ClusterSelector:   ClusterSelector{PlacementRef: &PlacementRef{Name: "edge"}},
+			NamespaceSelector: NamespaceSelector{Exclude: []string{"openshift-cnv"}},
+		}},
+	}
+	_, err = c.ValidateCreate(context.Background(), c)
+	assert.ErrorContains(t, err, "spec.collectNamespaces.clusterOverrides[0].namespaceSelector.exclude[0]")
+
+	c.Spec.CollectNamespaces = &CollectNamespaces{
+		NamespaceSelector: &NamespaceSelector{Exclude: []string{"openshift-monitoring"}},
+	}
+	_, err = c.ValidateCreate(context.Background(), c)
+	assert.NoError(t, err)
+}
+
+func TestValidateResourceSelectorVersions(t *testing.T) {
+	c := validConfig()
+	c.Spec.CollectionRules[0].ResourceSelector.Versions = []string{"v1beta1"}
+	_, err := c.ValidateCreate(context.Background(), c)
+	assert.NoError(t, err)
+
+	c.Spec.CollectionRules[0].ResourceSelector.Versions = []string{"v1beta1", "v1beta1", "V1"}
+	_, err = c.ValidateCreate(context.Background(), c)
+	assert.ErrorContains(t, err, "spec.collectionRules[0].resourceSelector.versions[1]: Duplicate value")
+	assert.ErrorContains(t, err, "spec.collectionRules[0].resourceSelector.versions[2]: Invalid value")
+}
+
+// Custom fields are written for one schema, so they can pin at most one version.
+func TestRejectFieldsForSeveralVersions(t *testing.T) {
+	c := validConfig()
+	c.Spec.CollectionRules[0].ResourceSelector.Versions = []string{"v1alpha1", "v1beta1"}
+	c.Spec.CollectionRules[0].Fields = []Field{{Name: "replicas", JSONPath: ".spec.replicas"}}
+	_, err := c.ValidateCreate(context.Background(), c)
+	assert.ErrorContains(t, err, "must specify at most 1 version when fields are defined")
+}
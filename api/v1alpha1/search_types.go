@@ -6,6 +6,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
 )
 
 // Important: Run the "make manifests" command to regenerate the manifests after you modify this file.
@@ -72,6 +73,180 @@ type SearchSpec struct {
 	// +optional
 	// Define tolerations to schedule pods on nodes with matching taints.
 	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// +optional
+	// Monitoring configuration for the search components.
+	Monitoring MonitoringSpec `json:"monitoring,omitempty"`
+
+	// +optional
+	// Policy for the rules that CollectorConfigs may contain.
+	CollectorConfigPolicy CollectorConfigPolicy `json:"collectorConfigPolicy,omitempty"`
+
+	// +optional
+	// Progressive rollout of the search-collector addon. When set, the operator manages the install
+	// strategy of the search-collector ClusterManagementAddOn, and a new collector image or new
+	// collector settings reach the managed clusters through these placements instead of all at once.
+	CollectorRollout *CollectorRollout `json:"collectorRollout,omitempty"`
+}
+
+// CollectorRollout selects the clusters that run the search-collector addon, and how an upgrade
+// moves through them.
+type CollectorRollout struct {
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=20
+	// +listType=map
+	// +listMapKey=namespace
+	// +listMapKey=name
+	// Placements that select the clusters to run the collector. The addon is installed on every
+	// selected cluster; a cluster selected by several placements follows the last one.
+	Placements []CollectorRolloutPlacement `json:"placements"`
+
+	// +optional
+	// Name of an AddOnDeploymentConfig in the Search namespace with the collector settings
+	// (customizedVariables, nodePlacement, resourceRequirements). Changes to it are rolled out
+	// like a new image.
+	DeploymentConfig string `json:"deploymentConfig,omitempty"`
+}
+
+// CollectorRolloutPlacement is a Placement and its rollout strategy.
+type CollectorRolloutPlacement struct {
+	// Name of the Placement.
+	Name string `json:"name"`
+
+	// Namespace of the Placement. It must be bound to the cluster sets it selects from.
+	Namespace string `json:"namespace"`
+
+	// +optional
+	// +kubebuilder:default={type: All}
+	// How the selected clusters move to a new image or settings: All at once, Progressive with a
+	// maxConcurrency, or ProgressivePerGroup through the Placement's decision groups (for example
+	// one group per cluster set). minSuccessTime and progressDeadline apply to the progressive types.
+	RolloutStrategy clusterv1alpha1.RolloutStrategy `json:"rolloutStrategy,omitempty"`
+}
+
+type CollectorConfigPolicy struct {
+	// +optional
+	// +listType=map
+	// +listMapKey=apiGroup
+	// +listMapKey=kind
+	// +kubebuilder:validation:MaxItems=100
+	// Resources that CollectorConfig exclude rules must not target. Entries override the built-in
+	// protected resources with the same apiGroup and kind; the others keep their defaults. ManagedCluster
+	// and Namespace, which search RBAC depends on, are always rejected.
+	ProtectedResources []ProtectedResource `json:"protectedResources,omitempty"`
+}
+
+// ProtectionSeverity is what happens to an exclude rule that targets a protected resource.
+// +kubebuilder:validation:Enum=Reject;Warn
+type ProtectionSeverity string
+
+const (
+	// ProtectionSeverityReject rejects the CollectorConfig, and drops the exclude rule from the merged config.
+	ProtectionSeverityReject ProtectionSeverity = "Reject"
+	// ProtectionSeverityWarn admits the CollectorConfig with a warning.
+	ProtectionSeverityWarn ProtectionSeverity = "Warn"
+)
+
+// ProtectedResource is a kind, or a whole API group, that exclude rules must not target.
+type ProtectedResource struct {
+	// The API group of the resource. "" is the core group.
+	APIGroup string `json:"apiGroup"`
+
+	// +optional
+	// +kubebuilder:default=""
+	// The kind of the resource. When empty, the whole group is protected, and an exclude narrowed by
+	// a label or annotation selector is allowed.
+	Kind string `json:"kind"`
+
+	// +optional
+	// +kubebuilder:default=Reject
+	// Reject (default) or Warn.
+	Severity ProtectionSeverity `json:"severity,omitempty"`
+}
+
+type MonitoringSpec struct {
+	// +optional
+	// Configuration for the PostgreSQL metrics exporter.
+	DatabaseExporter DatabaseExporterSpec `json:"databaseExporter,omitempty"`
+
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=10
+	// Overrides for the alerts in the search PrometheusRule. Alerts that are not listed use the defaults.
+	Alerts []AlertConfig `json:"alerts,omitempty"`
+
+	// +optional
+	// Grafana dashboards for the search components.
+	Dashboards DashboardsSpec `json:"dashboards,omitempty"`
+}
+
+type DashboardsSpec struct {
+	// +optional
+	// Create a ConfigMap for each search dashboard, labeled for the Grafana dashboard sidecar.
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// SearchAlertName is the name of an alert in the search PrometheusRule.
+// +kubebuilder:validation:Enum=SearchPVCNotPresent;SearchPVCNotPresentCritical;SearchPVCUsageHigh;SearchPVCUsageCritical;SearchPostgresRestarting;SearchPostgresLongRunningQuery;SearchPostgresConnectionsHigh;SearchIndexerLatencyHigh;SearchAPIErrorRateHigh;SearchCollectorNotReporting
+type SearchAlertName string
+
+const (
+	AlertSearchPVCNotPresent            SearchAlertName = "SearchPVCNotPresent"
+	AlertSearchPVCNotPresentCritical    SearchAlertName = "SearchPVCNotPresentCritical"
+	AlertSearchPVCUsageHigh             SearchAlertName = "SearchPVCUsageHigh"
+	AlertSearchPVCUsageCritical         SearchAlertName = "SearchPVCUsageCritical"
+	AlertSearchPostgresRestarting       SearchAlertName = "SearchPostgresRestarting"
+	AlertSearchPostgresLongRunningQuery SearchAlertName = "SearchPostgresLongRunningQuery"
+	AlertSearchPostgresConnectionsHigh  SearchAlertName = "SearchPostgresConnectionsHigh"
+	AlertSearchIndexerLatencyHigh       SearchAlertName = "SearchIndexerLatencyHigh"
+	AlertSearchAPIErrorRateHigh         SearchAlertName = "SearchAPIErrorRateHigh"
+	AlertSearchCollectorNotReporting    SearchAlertName = "SearchCollectorNotReporting"
+)
+
+// AlertThreshold is a number used in an alert expression.
+// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+type AlertThreshold string
+
+// AlertConfig customizes one alert in the search PrometheusRule.
+// +kubebuilder:validation:XValidation:rule="!has(self.thresholds) || self.thresholds.all(k, k in {'SearchPVCNotPresent': [], 'SearchPVCNotPresentCritical': ['maxManagedClusters', 'maxApps', 'maxIndexerRequests30m'], 'SearchPVCUsageHigh': ['percent'], 'SearchPVCUsageCritical': ['percent'], 'SearchPostgresRestarting': ['restarts'], 'SearchPostgresLongRunningQuery': ['seconds'], 'SearchPostgresConnectionsHigh': ['percent'], 'SearchIndexerLatencyHigh': ['seconds'], 'SearchAPIErrorRateHigh': ['percent'], 'SearchCollectorNotReporting': ['minutes']}[self.name])",message="unsupported threshold for this alert, see docs/RUNBOOKS.md"
+type AlertConfig struct {
+	// Name of the alert.
+	Name SearchAlertName `json:"name"`
+
+	// +optional
+	// Set to false to remove the alert from the PrometheusRule.
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:MaxProperties=3
+	// Thresholds used in the alert expression, keyed by threshold name.
+	Thresholds map[string]AlertThreshold `json:"thresholds,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(0|(([0-9]+)y)?(([0-9]+)w)?(([0-9]+)d)?(([0-9]+)h)?(([0-9]+)m)?(([0-9]+)s)?(([0-9]+)ms)?)$`
+	// How long the condition must hold before the alert fires, for example 10m.
+	For string `json:"for,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:MaxProperties=10
+	// +kubebuilder:validation:XValidation:rule="self.all(k, k.matches('^[a-zA-Z_][a-zA-Z0-9_]*$'))",message="label names must match [a-zA-Z_][a-zA-Z0-9_]*"
+	// Extra labels added to the alert, for example to route it to an Alertmanager receiver.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type DatabaseExporterSpec struct {
+	// +optional
+	// Run a postgres_exporter sidecar in the search-postgres pod and create a ServiceMonitor for it.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// +optional
+	// Image_override for the postgres_exporter container.
+	ImageOverride string `json:"imageOverride,omitempty"`
+
+	// +optional
+	// Compute resources required by the postgres_exporter container.
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
 }
 
 type SearchDeployments struct {
@@ -135,6 +310,90 @@ type SearchStatus struct {
 	// +optional
 	// Conditions
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// +optional
+	// Expression indexes on search.resources that the operator manages for the custom fields
+	// declared in merged-collector-config.
+	FieldIndexes []FieldIndex `json:"fieldIndexes,omitempty"`
+
+	// +optional
+	// Health of the search-collector addon on the managed clusters, from the Available condition of
+	// each search-collector ManagedClusterAddOn.
+	Collectors *CollectorsHealth `json:"collectors,omitempty"`
+
+	// +optional
+	// Progress of the collector rollout, when spec.collectorRollout is set.
+	CollectorRollout *CollectorRolloutStatus `json:"collectorRollout,omitempty"`
+}
+
+// CollectorRolloutStatus reports how far the current collector image and settings have been rolled
+// out.
+type CollectorRolloutStatus struct {
+	// Collector image being rolled out.
+	Image string `json:"image"`
+
+	// AddOnDeploymentConfig that carries the image and settings being rolled out.
+	DeploymentConfig string `json:"deploymentConfig"`
+
+	// Number of clusters with the search-collector addon.
+	Total int `json:"total"`
+
+	// Number of clusters that applied the image and settings being rolled out.
+	Updated int `json:"updated"`
+
+	// Number of clusters where the rollout failed.
+	Failed int `json:"failed"`
+
+	// +optional
+	// Names of the first 10 failed clusters, sorted.
+	FailedClusters []string `json:"failedClusters,omitempty"`
+
+	// +optional
+	// Progress per placement, from the ClusterManagementAddOn install progressions.
+	Placements []CollectorRolloutPlacementStatus `json:"placements,omitempty"`
+}
+
+// CollectorRolloutPlacementStatus is the rollout progress of one placement.
+type CollectorRolloutPlacementStatus struct {
+	// Name of the Placement.
+	Name string `json:"name"`
+
+	// Namespace of the Placement.
+	Namespace string `json:"namespace"`
+
+	// +optional
+	// Reason of the Progressing condition, e.g. Upgrading or UpgradeSucceed.
+	Reason string `json:"reason,omitempty"`
+
+	// +optional
+	// Message of the Progressing condition, with the cluster counts.
+	Message string `json:"message,omitempty"`
+}
+
+// CollectorsHealth counts the managed clusters whose search-collector addon is not available.
+type CollectorsHealth struct {
+	// Number of managed clusters with the search-collector addon.
+	Total int `json:"total"`
+
+	// Number of managed clusters whose search-collector addon is not Available, including clusters
+	// where the availability is not known yet.
+	Unhealthy int `json:"unhealthy"`
+
+	// +optional
+	// Names of the first 10 unhealthy clusters, sorted.
+	UnhealthyClusters []string `json:"unhealthyClusters,omitempty"`
+}
+
+// FieldIndex describes an expression index the operator created for a custom collected field.
+type FieldIndex struct {
+	// Name of the index in the search schema.
+	Name string `json:"name"`
+
+	// Key of the field in the resource data, including the rule's fieldSuffix.
+	Field string `json:"field"`
+
+	// Index access method, either gin or btree.
+	Method string `json:"method"`
 }
 
 // +kubebuilder:object:root=true
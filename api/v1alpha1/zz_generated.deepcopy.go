@@ -26,6 +26,65 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertConfig) DeepCopyInto(out *AlertConfig) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Thresholds != nil {
+		in, out := &in.Thresholds, &out.Thresholds
+		*out = make(map[string]AlertThreshold, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertConfig.
+func (in *AlertConfig) DeepCopy() *AlertConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterSelector) DeepCopyInto(out *ClusterSelector) {
+	*out = *in
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PlacementRef != nil {
+		in, out := &in.PlacementRef, &out.PlacementRef
+		*out = new(PlacementRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSelector.
+func (in *ClusterSelector) DeepCopy() *ClusterSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CollectNamespaces) DeepCopyInto(out *CollectNamespaces) {
 	*out = *in
@@ -34,6 +93,18 @@ func (in *CollectNamespaces) DeepCopyInto(out *CollectNamespaces) {
 		*out = new(NamespaceSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Required != nil {
+		in, out := &in.Required, &out.Required
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClusterOverrides != nil {
+		in, out := &in.ClusterOverrides, &out.ClusterOverrides
+		*out = make([]NamespaceSelectorOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CollectNamespaces.
@@ -53,7 +124,9 @@ func (in *CollectionRule) DeepCopyInto(out *CollectionRule) {
 	if in.Fields != nil {
 		in, out := &in.Fields, &out.Fields
 		*out = make([]Field, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.CollectAnnotations != nil {
 		in, out := &in.CollectAnnotations, &out.CollectAnnotations
@@ -70,6 +143,11 @@ func (in *CollectionRule) DeepCopyInto(out *CollectionRule) {
 		*out = new(int)
 		**out = **in
 	}
+	if in.ClusterSelector != nil {
+		in, out := &in.ClusterSelector, &out.ClusterSelector
+		*out = new(ClusterSelector)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CollectionRule.
@@ -82,6 +160,25 @@ func (in *CollectionRule) DeepCopy() *CollectionRule {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CollectorAcknowledgement) DeepCopyInto(out *CollectorAcknowledgement) {
+	*out = *in
+	if in.LastObservedTime != nil {
+		in, out := &in.LastObservedTime, &out.LastObservedTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CollectorAcknowledgement.
+func (in *CollectorAcknowledgement) DeepCopy() *CollectorAcknowledgement {
+	if in == nil {
+		return nil
+	}
+	out := new(CollectorAcknowledgement)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CollectorConfig) DeepCopyInto(out *CollectorConfig) {
 	*out = *in
@@ -141,6 +238,41 @@ func (in *CollectorConfigList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CollectorConfigPolicy) DeepCopyInto(out *CollectorConfigPolicy) {
+	*out = *in
+	if in.ProtectedResources != nil {
+		in, out := &in.ProtectedResources, &out.ProtectedResources
+		*out = make([]ProtectedResource, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CollectorConfigPolicy.
+func (in *CollectorConfigPolicy) DeepCopy() *CollectorConfigPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(CollectorConfigPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CollectorConfigSource) DeepCopyInto(out *CollectorConfigSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CollectorConfigSource.
+func (in *CollectorConfigSource) DeepCopy() *CollectorConfigSource {
+	if in == nil {
+		return nil
+	}
+	out := new(CollectorConfigSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CollectorConfigSpec) DeepCopyInto(out *CollectorConfigSpec) {
 	*out = *in
@@ -156,6 +288,23 @@ func (in *CollectorConfigSpec) DeepCopyInto(out *CollectorConfigSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ClusterSelector != nil {
+		in, out := &in.ClusterSelector, &out.ClusterSelector
+		*out = new(ClusterSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Redact != nil {
+		in, out := &in.Redact, &out.Redact
+		*out = make([]RedactionRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Relationships != nil {
+		in, out := &in.Relationships, &out.Relationships
+		*out = make([]Relationship, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CollectorConfigSpec.
@@ -178,6 +327,39 @@ func (in *CollectorConfigStatus) DeepCopyInto(out *CollectorConfigStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Sources != nil {
+		in, out := &in.Sources, &out.Sources
+		*out = make([]CollectorConfigSource, len(*in))
+		copy(*out, *in)
+	}
+	if in.RuleSources != nil {
+		in, out := &in.RuleSources, &out.RuleSources
+		*out = make([]RuleSource, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastMergeTime != nil {
+		in, out := &in.LastMergeTime, &out.LastMergeTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Collectors != nil {
+		in, out := &in.Collectors, &out.Collectors
+		*out = make([]CollectorAcknowledgement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Impact != nil {
+		in, out := &in.Impact, &out.Impact
+		*out = new(ImpactEstimate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EdgeTypes != nil {
+		in, out := &in.EdgeTypes, &out.EdgeTypes
+		*out = make([]EdgeType, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CollectorConfigStatus.
@@ -190,6 +372,139 @@ func (in *CollectorConfigStatus) DeepCopy() *CollectorConfigStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CollectorRollout) DeepCopyInto(out *CollectorRollout) {
+	*out = *in
+	if in.Placements != nil {
+		in, out := &in.Placements, &out.Placements
+		*out = make([]CollectorRolloutPlacement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CollectorRollout.
+func (in *CollectorRollout) DeepCopy() *CollectorRollout {
+	if in == nil {
+		return nil
+	}
+	out := new(CollectorRollout)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CollectorRolloutPlacement) DeepCopyInto(out *CollectorRolloutPlacement) {
+	*out = *in
+	in.RolloutStrategy.DeepCopyInto(&out.RolloutStrategy)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CollectorRolloutPlacement.
+func (in *CollectorRolloutPlacement) DeepCopy() *CollectorRolloutPlacement {
+	if in == nil {
+		return nil
+	}
+	out := new(CollectorRolloutPlacement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CollectorRolloutPlacementStatus) DeepCopyInto(out *CollectorRolloutPlacementStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CollectorRolloutPlacementStatus.
+func (in *CollectorRolloutPlacementStatus) DeepCopy() *CollectorRolloutPlacementStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CollectorRolloutPlacementStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CollectorRolloutStatus) DeepCopyInto(out *CollectorRolloutStatus) {
+	*out = *in
+	if in.FailedClusters != nil {
+		in, out := &in.FailedClusters, &out.FailedClusters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Placements != nil {
+		in, out := &in.Placements, &out.Placements
+		*out = make([]CollectorRolloutPlacementStatus, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CollectorRolloutStatus.
+func (in *CollectorRolloutStatus) DeepCopy() *CollectorRolloutStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CollectorRolloutStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CollectorsHealth) DeepCopyInto(out *CollectorsHealth) {
+	*out = *in
+	if in.UnhealthyClusters != nil {
+		in, out := &in.UnhealthyClusters, &out.UnhealthyClusters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CollectorsHealth.
+func (in *CollectorsHealth) DeepCopy() *CollectorsHealth {
+	if in == nil {
+		return nil
+	}
+	out := new(CollectorsHealth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DashboardsSpec) DeepCopyInto(out *DashboardsSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DashboardsSpec.
+func (in *DashboardsSpec) DeepCopy() *DashboardsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DashboardsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseExporterSpec) DeepCopyInto(out *DatabaseExporterSpec) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(corev1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseExporterSpec.
+func (in *DatabaseExporterSpec) DeepCopy() *DatabaseExporterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseExporterSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DeploymentConfig) DeepCopyInto(out *DeploymentConfig) {
 	*out = *in
@@ -222,9 +537,46 @@ func (in *DeploymentConfig) DeepCopy() *DeploymentConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EdgeType) DeepCopyInto(out *EdgeType) {
+	*out = *in
+	if in.SourceKinds != nil {
+		in, out := &in.SourceKinds, &out.SourceKinds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TargetKinds != nil {
+		in, out := &in.TargetKinds, &out.TargetKinds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Sources != nil {
+		in, out := &in.Sources, &out.Sources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EdgeType.
+func (in *EdgeType) DeepCopy() *EdgeType {
+	if in == nil {
+		return nil
+	}
+	out := new(EdgeType)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Field) DeepCopyInto(out *Field) {
 	*out = *in
+	if in.Transforms != nil {
+		in, out := &in.Transforms, &out.Transforms
+		*out = make([]FieldTransform, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Field.
@@ -237,6 +589,156 @@ func (in *Field) DeepCopy() *Field {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FieldIndex) DeepCopyInto(out *FieldIndex) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FieldIndex.
+func (in *FieldIndex) DeepCopy() *FieldIndex {
+	if in == nil {
+		return nil
+	}
+	out := new(FieldIndex)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FieldTransform) DeepCopyInto(out *FieldTransform) {
+	*out = *in
+	if in.Regex != nil {
+		in, out := &in.Regex, &out.Regex
+		*out = new(RegexTransform)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Map != nil {
+		in, out := &in.Map, &out.Map
+		*out = new(MapTransform)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Join != nil {
+		in, out := &in.Join, &out.Join
+		*out = new(JoinTransform)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Default != nil {
+		in, out := &in.Default, &out.Default
+		*out = new(string)
+		**out = **in
+	}
+	if in.When != nil {
+		in, out := &in.When, &out.When
+		*out = new(WhenTransform)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FieldTransform.
+func (in *FieldTransform) DeepCopy() *FieldTransform {
+	if in == nil {
+		return nil
+	}
+	out := new(FieldTransform)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImpactEstimate) DeepCopyInto(out *ImpactEstimate) {
+	*out = *in
+	if in.LastEstimateTime != nil {
+		in, out := &in.LastEstimateTime, &out.LastEstimateTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]RuleImpact, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImpactEstimate.
+func (in *ImpactEstimate) DeepCopy() *ImpactEstimate {
+	if in == nil {
+		return nil
+	}
+	out := new(ImpactEstimate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JoinTransform) DeepCopyInto(out *JoinTransform) {
+	*out = *in
+	if in.Separator != nil {
+		in, out := &in.Separator, &out.Separator
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JoinTransform.
+func (in *JoinTransform) DeepCopy() *JoinTransform {
+	if in == nil {
+		return nil
+	}
+	out := new(JoinTransform)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MapTransform) DeepCopyInto(out *MapTransform) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Default != nil {
+		in, out := &in.Default, &out.Default
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MapTransform.
+func (in *MapTransform) DeepCopy() *MapTransform {
+	if in == nil {
+		return nil
+	}
+	out := new(MapTransform)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MonitoringSpec) DeepCopyInto(out *MonitoringSpec) {
+	*out = *in
+	in.DatabaseExporter.DeepCopyInto(&out.DatabaseExporter)
+	if in.Alerts != nil {
+		in, out := &in.Alerts, &out.Alerts
+		*out = make([]AlertConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	out.Dashboards = in.Dashboards
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonitoringSpec.
+func (in *MonitoringSpec) DeepCopy() *MonitoringSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MonitoringSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NamespaceSelector) DeepCopyInto(out *NamespaceSelector) {
 	*out = *in
@@ -276,6 +778,155 @@ func (in *NamespaceSelector) DeepCopy() *NamespaceSelector {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceSelectorOverride) DeepCopyInto(out *NamespaceSelectorOverride) {
+	*out = *in
+	in.ClusterSelector.DeepCopyInto(&out.ClusterSelector)
+	in.NamespaceSelector.DeepCopyInto(&out.NamespaceSelector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceSelectorOverride.
+func (in *NamespaceSelectorOverride) DeepCopy() *NamespaceSelectorOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceSelectorOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementRef) DeepCopyInto(out *PlacementRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementRef.
+func (in *PlacementRef) DeepCopy() *PlacementRef {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProtectedResource) DeepCopyInto(out *ProtectedResource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProtectedResource.
+func (in *ProtectedResource) DeepCopy() *ProtectedResource {
+	if in == nil {
+		return nil
+	}
+	out := new(ProtectedResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProtectionViolation) DeepCopyInto(out *ProtectionViolation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProtectionViolation.
+func (in *ProtectionViolation) DeepCopy() *ProtectionViolation {
+	if in == nil {
+		return nil
+	}
+	out := new(ProtectionViolation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RedactionRule) DeepCopyInto(out *RedactionRule) {
+	*out = *in
+	if in.Keys != nil {
+		in, out := &in.Keys, &out.Keys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedactionRule.
+func (in *RedactionRule) DeepCopy() *RedactionRule {
+	if in == nil {
+		return nil
+	}
+	out := new(RedactionRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegexTransform) DeepCopyInto(out *RegexTransform) {
+	*out = *in
+	if in.Group != nil {
+		in, out := &in.Group, &out.Group
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegexTransform.
+func (in *RegexTransform) DeepCopy() *RegexTransform {
+	if in == nil {
+		return nil
+	}
+	out := new(RegexTransform)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Relationship) DeepCopyInto(out *Relationship) {
+	*out = *in
+	out.Source = in.Source
+	out.Target = in.Target
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Relationship.
+func (in *Relationship) DeepCopy() *Relationship {
+	if in == nil {
+		return nil
+	}
+	out := new(Relationship)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RelationshipSource) DeepCopyInto(out *RelationshipSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RelationshipSource.
+func (in *RelationshipSource) DeepCopy() *RelationshipSource {
+	if in == nil {
+		return nil
+	}
+	out := new(RelationshipSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RelationshipTarget) DeepCopyInto(out *RelationshipTarget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RelationshipTarget.
+func (in *RelationshipTarget) DeepCopy() *RelationshipTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(RelationshipTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceSelector) DeepCopyInto(out *ResourceSelector) {
 	*out = *in
@@ -289,6 +940,21 @@ func (in *ResourceSelector) DeepCopyInto(out *ResourceSelector) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Versions != nil {
+		in, out := &in.Versions, &out.Versions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AnnotationSelector != nil {
+		in, out := &in.AnnotationSelector, &out.AnnotationSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceSelector.
@@ -301,6 +967,36 @@ func (in *ResourceSelector) DeepCopy() *ResourceSelector {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RuleImpact) DeepCopyInto(out *RuleImpact) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuleImpact.
+func (in *RuleImpact) DeepCopy() *RuleImpact {
+	if in == nil {
+		return nil
+	}
+	out := new(RuleImpact)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RuleSource) DeepCopyInto(out *RuleSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuleSource.
+func (in *RuleSource) DeepCopy() *RuleSource {
+	if in == nil {
+		return nil
+	}
+	out := new(RuleSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Search) DeepCopyInto(out *Search) {
 	*out = *in
@@ -398,6 +1094,13 @@ func (in *SearchSpec) DeepCopyInto(out *SearchSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	in.Monitoring.DeepCopyInto(&out.Monitoring)
+	in.CollectorConfigPolicy.DeepCopyInto(&out.CollectorConfigPolicy)
+	if in.CollectorRollout != nil {
+		in, out := &in.CollectorRollout, &out.CollectorRollout
+		*out = new(CollectorRollout)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SearchSpec.
@@ -420,6 +1123,21 @@ func (in *SearchStatus) DeepCopyInto(out *SearchStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.FieldIndexes != nil {
+		in, out := &in.FieldIndexes, &out.FieldIndexes
+		*out = make([]FieldIndex, len(*in))
+		copy(*out, *in)
+	}
+	if in.Collectors != nil {
+		in, out := &in.Collectors, &out.Collectors
+		*out = new(CollectorsHealth)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CollectorRollout != nil {
+		in, out := &in.CollectorRollout, &out.CollectorRollout
+		*out = new(CollectorRolloutStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SearchStatus.
@@ -451,3 +1169,23 @@ func (in *StorageSpec) DeepCopy() *StorageSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WhenTransform) DeepCopyInto(out *WhenTransform) {
+	*out = *in
+	if in.Equals != nil {
+		in, out := &in.Equals, &out.Equals
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WhenTransform.
+func (in *WhenTransform) DeepCopy() *WhenTransform {
+	if in == nil {
+		return nil
+	}
+	out := new(WhenTransform)
+	in.DeepCopyInto(out)
+	return out
+}
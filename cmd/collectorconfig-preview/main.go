@@ -0,0 +1,170 @@
+// Copyright Contributors to the Open Cluster Management project
+
+// collectorconfig-preview shows what a CollectorConfig does with an object before it is applied:
+// which collection rules match, the resulting action, and the custom field values with the reason
+// a field is empty.
+//
+//	collectorconfig-preview --config my-config.yaml --object deployment.yaml
+//	collectorconfig-preview --resource apps/v1/Deployment --object-namespace default --object-name web
+//
+// Without --config it previews merged-collector-config from the hub. Live objects and configs are
+// read with the caller's kubeconfig, so the preview only shows objects the caller can read.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"github.com/stolostron/search-v2-operator/pkg/preview"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	defaultConfigName      = "merged-collector-config"
+	defaultConfigNamespace = "open-cluster-management"
+)
+
+var collectorConfigResource = searchv1alpha1.GroupVersion.WithResource("collectorconfigs")
+
+type options struct {
+	configFile      string
+	configName      string
+	configNamespace string
+	objectFile      string
+	resource        string
+	objectNamespace string
+	objectName      string
+	output          string
+}
+
+func main() {
+	opts := options{}
+	flag.StringVar(&opts.configFile, "config", "", "CollectorConfig YAML file to preview. When unset the config is read from the cluster.")
+	flag.StringVar(&opts.configName, "config-name", defaultConfigName, "Name of the CollectorConfig to read from the cluster.")
+	flag.StringVar(&opts.configNamespace, "config-namespace", defaultConfigNamespace, "Namespace of the CollectorConfig to read from the cluster.")
+	flag.StringVar(&opts.objectFile, "object", "", "YAML or JSON file with the sample object.")
+	flag.StringVar(&opts.resource, "resource", "", "group/version/Kind of a live object to preview, e.g. apps/v1/Deployment or v1/ConfigMap.")
+	flag.StringVar(&opts.objectNamespace, "object-namespace", "", "Namespace of the live object.")
+	flag.StringVar(&opts.objectName, "object-name", "", "Name of the live object.")
+	flag.StringVar(&opts.output, "o", "yaml", "Output format: yaml or json.")
+	flag.Parse()
+
+	if err := run(context.Background(), opts); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, opts options) error {
+	if (opts.objectFile == "") == (opts.resource == "") {
+		return fmt.Errorf("specify exactly one of --object or --resource")
+	}
+	if opts.resource != "" && opts.objectName == "" {
+		return fmt.Errorf("--object-name is required with --resource")
+	}
+
+	// The cluster is only contacted for a config or object that is not read from a file.
+	var cfg *rest.Config
+	var client dynamic.Interface
+	if opts.configFile == "" || opts.resource != "" {
+		var err error
+		if cfg, err = ctrl.GetConfig(); err != nil {
+			return err
+		}
+		if client, err = dynamic.NewForConfig(cfg); err != nil {
+			return err
+		}
+	}
+
+	cc := &searchv1alpha1.CollectorConfig{}
+	if opts.configFile != "" {
+		if err := readYAML(opts.configFile, cc); err != nil {
+			return err
+		}
+	} else {
+		u, err := client.Resource(collectorConfigResource).Namespace(opts.configNamespace).
+			Get(ctx, opts.configName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("could not read CollectorConfig %s/%s: %w", opts.configNamespace, opts.configName, err)
+		}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, cc); err != nil {
+			return err
+		}
+	}
+
+	obj := &unstructured.Unstructured{}
+	if opts.objectFile != "" {
+		if err := readYAML(opts.objectFile, &obj.Object); err != nil {
+			return err
+		}
+	} else {
+		gvk, err := parseResource(opts.resource)
+		if err != nil {
+			return err
+		}
+		dc, err := discovery.NewDiscoveryClientForConfig(cfg)
+		if err != nil {
+			return err
+		}
+		mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(dc))
+		if obj, err = preview.Fetch(ctx, client, mapper, gvk, opts.objectNamespace, opts.objectName); err != nil {
+			return err
+		}
+	}
+
+	result := preview.Preview(cc, obj)
+	var out []byte
+	var err error
+	switch opts.output {
+	case "json":
+		out, err = json.MarshalIndent(result, "", "  ")
+	case "yaml":
+		out, err = yaml.Marshal(result)
+	default:
+		return fmt.Errorf("unsupported output format %q", opts.output)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(strings.TrimSuffix(string(out), "\n"))
+	return nil
+}
+
+// parseResource parses group/version/Kind, or version/Kind for the core group.
+func parseResource(resource string) (schema.GroupVersionKind, error) {
+	parts := strings.Split(resource, "/")
+	switch len(parts) {
+	case 2:
+		return schema.GroupVersionKind{Version: parts[0], Kind: parts[1]}, nil
+	case 3:
+		return schema.GroupVersionKind{Group: parts[0], Version: parts[1], Kind: parts[2]}, nil
+	default:
+		return schema.GroupVersionKind{}, fmt.Errorf("--resource must be group/version/Kind or version/Kind, got %q", resource)
+	}
+}
+
+func readYAML(path string, into interface{}) error {
+	data, err := os.ReadFile(path) // #nosec G304 -- the path is given by the user running the tool
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(data, into); err != nil {
+		return fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package integrationconfigs
+
+import "embed"
+
+// DashboardsFS embeds the Grafana dashboards for the search components shipped in dashboards/.
+// The operator creates one ConfigMap per file when spec.monitoring.dashboards.enabled is set
+// on the Search CR — see controllers/create_dashboards.go.
+//
+//go:embed dashboards/*.json
+var DashboardsFS embed.FS
+
+// DashboardsDir is the embedded directory name, used by callers to build paths into DashboardsFS.
+const DashboardsDir = "dashboards"
@@ -0,0 +1,99 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+	"sort"
+
+	"github.com/stolostron/search-v2-operator/addon"
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// maxListedUnhealthyClusters limits status.collectors.unhealthyClusters.
+const maxListedUnhealthyClusters = 10
+
+// collectorsHealth counts the search-collector ManagedClusterAddOns that are not Available. The
+// addon framework sets Available from the collector health prober, see addon/health.go.
+func collectorsHealth(addons []addonapiv1alpha1.ManagedClusterAddOn) *searchv1alpha1.CollectorsHealth {
+	health := &searchv1alpha1.CollectorsHealth{}
+	var unhealthy []string
+	for _, mca := range addons {
+		if mca.Name != addon.SearchAddonName {
+			continue
+		}
+		health.Total++
+		if !apimeta.IsStatusConditionTrue(mca.Status.Conditions, addonapiv1alpha1.ManagedClusterAddOnConditionAvailable) {
+			unhealthy = append(unhealthy, mca.Namespace)
+		}
+	}
+	sort.Strings(unhealthy)
+	health.Unhealthy = len(unhealthy)
+	if len(unhealthy) > maxListedUnhealthyClusters {
+		unhealthy = unhealthy[:maxListedUnhealthyClusters]
+	}
+	health.UnhealthyClusters = unhealthy
+	return health
+}
+
+// reconcileCollectorHealth rolls the search-collector addon health up into status.collectors.
+func (r *SearchReconciler) reconcileCollectorHealth(ctx context.Context,
+	instance *searchv1alpha1.Search) (*reconcile.Result, error) {
+	list := &addonapiv1alpha1.ManagedClusterAddOnList{}
+	if err := r.List(ctx, list); err != nil {
+		if apimeta.IsNoMatchError(err) {
+			// The addon API is not installed, so there are no collectors to report.
+			return nil, nil
+		}
+		log.Error(err, "Could not list ManagedClusterAddOns to report collector health")
+		return &reconcile.Result{}, err
+	}
+	health := collectorsHealth(list.Items)
+	if equality.Semantic.DeepEqual(instance.Status.Collectors, health) {
+		return nil, nil
+	}
+	instance.Status.Collectors = health
+	if err := r.commitSearchCRInstanceState(ctx, instance); err != nil {
+		return &reconcile.Result{}, err
+	}
+	log.V(2).Info("Updated collector health in Search status", "total", health.Total, "unhealthy", health.Unhealthy)
+	return nil, nil
+}
+
+// collectorAddonHealthPred triggers when a search-collector ManagedClusterAddOn is added or
+// removed, its Available condition changes, or its rollout moves on (see collector_rollout.go).
+var collectorAddonHealthPred = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		return e.Object.GetName() == addon.SearchAddonName
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldMCA, okOld := e.ObjectOld.(*addonapiv1alpha1.ManagedClusterAddOn)
+		newMCA, okNew := e.ObjectNew.(*addonapiv1alpha1.ManagedClusterAddOn)
+		if !okOld || !okNew || newMCA.Name != addon.SearchAddonName {
+			return false
+		}
+		oldCond := apimeta.FindStatusCondition(oldMCA.Status.Conditions, addonapiv1alpha1.ManagedClusterAddOnConditionAvailable)
+		newCond := apimeta.FindStatusCondition(newMCA.Status.Conditions, addonapiv1alpha1.ManagedClusterAddOnConditionAvailable)
+		if (oldCond == nil) != (newCond == nil) || (oldCond != nil && oldCond.Status != newCond.Status) {
+			return true
+		}
+		oldCond = apimeta.FindStatusCondition(oldMCA.Status.Conditions, addonapiv1alpha1.ManagedClusterAddOnConditionProgressing)
+		newCond = apimeta.FindStatusCondition(newMCA.Status.Conditions, addonapiv1alpha1.ManagedClusterAddOnConditionProgressing)
+		if (oldCond == nil) != (newCond == nil) || (oldCond != nil && oldCond.Reason != newCond.Reason) {
+			return true
+		}
+		return !equality.Semantic.DeepEqual(oldMCA.Status.ConfigReferences, newMCA.Status.ConfigReferences)
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return e.Object.GetName() == addon.SearchAddonName
+	},
+	GenericFunc: func(e event.GenericEvent) bool {
+		return false
+	},
+}
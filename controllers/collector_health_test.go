@@ -0,0 +1,89 @@
+// Copyright Contributors to the Open Cluster Management project
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stolostron/search-v2-operator/addon"
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func newCollectorAddon(name, cluster string, available metav1.ConditionStatus) *addonapiv1alpha1.ManagedClusterAddOn {
+	mca := &addonapiv1alpha1.ManagedClusterAddOn{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: cluster}}
+	if available != "" {
+		mca.Status.Conditions = []metav1.Condition{{
+			Type: addonapiv1alpha1.ManagedClusterAddOnConditionAvailable, Status: available,
+			Reason: "ProbeAvailable", LastTransitionTime: metav1.Now(),
+		}}
+	}
+	return mca
+}
+
+func TestReconcileCollectorHealth(t *testing.T) {
+	s := scheme.Scheme
+	_ = searchv1alpha1.SchemeBuilder.AddToScheme(s)
+	_ = addonapiv1alpha1.AddToScheme(s)
+	objs := []runtime.Object{
+		newSearchInstance(),
+		newCollectorAddon(addon.SearchAddonName, "cluster-a", metav1.ConditionTrue),
+		newCollectorAddon(addon.SearchAddonName, "cluster-c", metav1.ConditionFalse),
+		newCollectorAddon(addon.SearchAddonName, "cluster-b", metav1.ConditionUnknown),
+		newCollectorAddon(addon.SearchAddonName, "cluster-d", ""),
+		newCollectorAddon("other-addon", "cluster-e", metav1.ConditionFalse),
+	}
+	r := &SearchReconciler{Client: fake.NewClientBuilder().WithRuntimeObjects(objs...).
+		WithStatusSubresource(&searchv1alpha1.Search{}).Build(), Scheme: s}
+	// The addons are in the cluster namespaces, outside the operator namespace.
+	scopeToCache(t, r)
+	instance := &searchv1alpha1.Search{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: OperatorName, Namespace: testNamespace}, instance))
+
+	result, err := r.reconcileCollectorHealth(context.TODO(), instance)
+	require.NoError(t, err)
+	assert.Nil(t, result)
+
+	stored := &searchv1alpha1.Search{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: OperatorName, Namespace: testNamespace}, stored))
+	assert.Equal(t, &searchv1alpha1.CollectorsHealth{
+		Total:             4,
+		Unhealthy:         3,
+		UnhealthyClusters: []string{"cluster-b", "cluster-c", "cluster-d"},
+	}, stored.Status.Collectors)
+}
+
+func TestCollectorsHealth_ListsFirstClusters(t *testing.T) {
+	var addons []addonapiv1alpha1.ManagedClusterAddOn
+	for i := 0; i < 12; i++ {
+		addons = append(addons, *newCollectorAddon(addon.SearchAddonName, fmt.Sprintf("cluster-%02d", i), metav1.ConditionFalse))
+	}
+
+	health := collectorsHealth(addons)
+
+	assert.Equal(t, 12, health.Unhealthy)
+	assert.Len(t, health.UnhealthyClusters, maxListedUnhealthyClusters)
+	assert.Equal(t, "cluster-00", health.UnhealthyClusters[0])
+}
+
+func TestCollectorAddonHealthPred(t *testing.T) {
+	healthy := newCollectorAddon(addon.SearchAddonName, "cluster-a", metav1.ConditionTrue)
+	unhealthy := newCollectorAddon(addon.SearchAddonName, "cluster-a", metav1.ConditionFalse)
+	relabeled := healthy.DeepCopy()
+	relabeled.Labels = map[string]string{"foo": "bar"}
+
+	assert.True(t, collectorAddonHealthPred.Update(event.UpdateEvent{ObjectOld: healthy, ObjectNew: unhealthy}))
+	assert.False(t, collectorAddonHealthPred.Update(event.UpdateEvent{ObjectOld: healthy, ObjectNew: relabeled}))
+	assert.True(t, collectorAddonHealthPred.Create(event.CreateEvent{Object: healthy}))
+	assert.False(t, collectorAddonHealthPred.Create(event.CreateEvent{
+		Object: newCollectorAddon("other-addon", "cluster-a", metav1.ConditionTrue)}))
+}
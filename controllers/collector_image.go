@@ -0,0 +1,65 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/stolostron/search-v2-operator/addon"
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// collectorImageAnnotation records on each search-collector ManagedClusterAddOn the collector image
+// it should run. Changing it makes the addon framework render the addon again.
+const collectorImageAnnotation = "search.open-cluster-management.io/collector-image"
+
+// searchGetter reads the Search CR in namespace for the addon, which renders the collector image
+// from it (see addon.CollectorImage).
+func (r *SearchReconciler) searchGetter(namespace string) addon.SearchGetter {
+	return func(ctx context.Context) (*searchv1alpha1.Search, error) {
+		instance := &searchv1alpha1.Search{}
+		if err := r.Get(ctx, types.NamespacedName{Name: OperatorName, Namespace: namespace}, instance); err != nil {
+			return nil, err
+		}
+		return instance, nil
+	}
+}
+
+// reconcileCollectorImage annotates the search-collector ManagedClusterAddOns with the collector
+// image of instance. The addon framework only renders an addon again when its ManagedClusterAddOn,
+// ManifestWork or cluster changes, so this is what rolls out a new imageOverride.
+func (r *SearchReconciler) reconcileCollectorImage(ctx context.Context,
+	instance *searchv1alpha1.Search) (*reconcile.Result, error) {
+	list := &addonapiv1alpha1.ManagedClusterAddOnList{}
+	if err := r.List(ctx, list); err != nil {
+		if apimeta.IsNoMatchError(err) {
+			// The addon API is not installed, so there are no collectors to update.
+			return nil, nil
+		}
+		log.Error(err, "Could not list ManagedClusterAddOns to update the collector image")
+		return &reconcile.Result{}, err
+	}
+	image := addon.CollectorImage(instance)
+	for i := range list.Items {
+		mca := &list.Items[i]
+		if mca.Name != addon.SearchAddonName || mca.Annotations[collectorImageAnnotation] == image {
+			continue
+		}
+		patch := client.MergeFrom(mca.DeepCopy())
+		if mca.Annotations == nil {
+			mca.Annotations = map[string]string{}
+		}
+		mca.Annotations[collectorImageAnnotation] = image
+		if err := r.Patch(ctx, mca, patch); err != nil {
+			log.Error(err, "Failed to update the collector image of ManagedClusterAddOn", "cluster", mca.Namespace)
+			return &reconcile.Result{}, err
+		}
+		log.V(2).Info("Updated the collector image of ManagedClusterAddOn", "cluster", mca.Namespace, "image", image)
+	}
+	return nil, nil
+}
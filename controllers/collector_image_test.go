@@ -0,0 +1,55 @@
+// Copyright Contributors to the Open Cluster Management project
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stolostron/search-v2-operator/addon"
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconcileCollectorImage(t *testing.T) {
+	s := scheme.Scheme
+	_ = searchv1alpha1.SchemeBuilder.AddToScheme(s)
+	_ = addonapiv1alpha1.AddToScheme(s)
+	addon.SearchCollectorImage = "quay.io/stolostron/search-collector:2.16.0"
+	search := newSearchInstance()
+	search.Spec.Deployments.Collector.ImageOverride = "quay.io/stolostron/search-collector:2.17.0"
+	r := &SearchReconciler{Client: fake.NewClientBuilder().WithRuntimeObjects(search,
+		newCollectorAddon(addon.SearchAddonName, "cluster-a", metav1.ConditionTrue),
+		newCollectorAddon("other-addon", "cluster-a", metav1.ConditionTrue),
+	).WithStatusSubresource(&searchv1alpha1.Search{}).Build(), Scheme: s}
+	// The addons are in the cluster namespaces, outside the operator namespace.
+	scopeToCache(t, r)
+
+	// The addon reads the live Search CR.
+	instance, err := r.searchGetter(testNamespace)(context.TODO())
+	require.NoError(t, err)
+	assert.Equal(t, "quay.io/stolostron/search-collector:2.17.0", addon.CollectorImage(instance))
+
+	result, err := r.reconcileCollectorImage(context.TODO(), instance)
+	require.NoError(t, err)
+	assert.Nil(t, result)
+
+	mca := &addonapiv1alpha1.ManagedClusterAddOn{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: addon.SearchAddonName, Namespace: "cluster-a"}, mca))
+	assert.Equal(t, "quay.io/stolostron/search-collector:2.17.0", mca.Annotations[collectorImageAnnotation])
+	other := &addonapiv1alpha1.ManagedClusterAddOn{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: "other-addon", Namespace: "cluster-a"}, other))
+	assert.NotContains(t, other.Annotations, collectorImageAnnotation)
+
+	// An untrusted override falls back to the operator image.
+	instance.Spec.Deployments.Collector.ImageOverride = "quay.io/test/search-collector:test"
+	_, err = r.reconcileCollectorImage(context.TODO(), instance)
+	require.NoError(t, err)
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: addon.SearchAddonName, Namespace: "cluster-a"}, mca))
+	assert.Equal(t, "quay.io/stolostron/search-collector:2.16.0", mca.Annotations[collectorImageAnnotation])
+}
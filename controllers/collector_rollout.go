@@ -0,0 +1,324 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/stolostron/search-v2-operator/addon"
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// collectorRolloutLabel marks the AddOnDeploymentConfigs created for spec.collectorRollout.
+	collectorRolloutLabel = "search.open-cluster-management.io/collector-rollout"
+
+	// managedInstallStrategyAnnotation marks a ClusterManagementAddOn whose install strategy is set
+	// from spec.collectorRollout, so it is only reset when the operator set it.
+	managedInstallStrategyAnnotation = "search.open-cluster-management.io/managed-install-strategy"
+
+	// maxListedFailedClusters limits status.collectorRollout.failedClusters.
+	maxListedFailedClusters = 10
+)
+
+var addonDeploymentConfigResource = addonapiv1alpha1.ConfigGroupResource{
+	Group:    addonapiv1alpha1.GroupName,
+	Resource: "addondeploymentconfigs",
+}
+
+// collectorRolloutConfig returns the AddOnDeploymentConfig for the collector image and settings
+// being rolled out: the spec of the deploymentConfig named in spec.collectorRollout, with the image
+// in the addon.ImageVariable. The name is derived from the spec, so every change to the image or
+// settings is a new config that the ClusterManagementAddOn rolls out through the placements.
+func collectorRolloutConfig(instance *searchv1alpha1.Search, userConfig *addonapiv1alpha1.AddOnDeploymentConfig,
+	image string) (*addonapiv1alpha1.AddOnDeploymentConfig, error) {
+	config := &addonapiv1alpha1.AddOnDeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: instance.GetNamespace(),
+			Labels:    map[string]string{collectorRolloutLabel: "true"},
+		},
+	}
+	if userConfig != nil {
+		userConfig.Spec.DeepCopyInto(&config.Spec)
+	}
+	variables := []addonapiv1alpha1.CustomizedVariable{}
+	for _, variable := range config.Spec.CustomizedVariables {
+		if variable.Name != addon.ImageVariable {
+			variables = append(variables, variable)
+		}
+	}
+	config.Spec.CustomizedVariables = append(variables,
+		addonapiv1alpha1.CustomizedVariable{Name: addon.ImageVariable, Value: image})
+
+	spec, err := json.Marshal(config.Spec)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(spec)
+	config.Name = fmt.Sprintf("%s-%s", addon.SearchAddonName, hex.EncodeToString(hash[:])[:10])
+	return config, nil
+}
+
+// collectorInstallStrategy installs the addon through the placements in spec.collectorRollout, each
+// with its rollout strategy and the AddOnDeploymentConfig from collectorRolloutConfig.
+func collectorInstallStrategy(rollout *searchv1alpha1.CollectorRollout,
+	configName, namespace string) addonapiv1alpha1.InstallStrategy {
+	strategy := addonapiv1alpha1.InstallStrategy{Type: addonapiv1alpha1.AddonInstallStrategyPlacements}
+	for _, placement := range rollout.Placements {
+		strategy.Placements = append(strategy.Placements, addonapiv1alpha1.PlacementStrategy{
+			PlacementRef: addonapiv1alpha1.PlacementRef{Name: placement.Name, Namespace: placement.Namespace},
+			Configs: []addonapiv1alpha1.AddOnConfig{{
+				ConfigGroupResource: addonDeploymentConfigResource,
+				ConfigReferent:      addonapiv1alpha1.ConfigReferent{Name: configName, Namespace: namespace},
+			}},
+			RolloutStrategy: *placement.RolloutStrategy.DeepCopy(),
+		})
+	}
+	return strategy
+}
+
+// deploymentConfigRef returns the AddOnDeploymentConfig reference of a ManagedClusterAddOn.
+func deploymentConfigRef(mca *addonapiv1alpha1.ManagedClusterAddOn) *addonapiv1alpha1.ConfigReference {
+	for i, ref := range mca.Status.ConfigReferences {
+		if ref.ConfigGroupResource == addonDeploymentConfigResource {
+			return &mca.Status.ConfigReferences[i]
+		}
+	}
+	return nil
+}
+
+// collectorRolloutStatus reports the rollout of config from the ClusterManagementAddOn install
+// progressions and the search-collector ManagedClusterAddOns. A cluster is updated when the addon
+// framework applied the config, and failed when its Progressing condition reason is Failed.
+func collectorRolloutStatus(image string, config *addonapiv1alpha1.AddOnDeploymentConfig,
+	cma *addonapiv1alpha1.ClusterManagementAddOn,
+	addons []addonapiv1alpha1.ManagedClusterAddOn) *searchv1alpha1.CollectorRolloutStatus {
+	status := &searchv1alpha1.CollectorRolloutStatus{Image: image, DeploymentConfig: config.Name}
+	for _, progression := range cma.Status.InstallProgressions {
+		placement := searchv1alpha1.CollectorRolloutPlacementStatus{
+			Name: progression.Name, Namespace: progression.Namespace,
+		}
+		if cond := apimeta.FindStatusCondition(progression.Conditions,
+			addonapiv1alpha1.ManagedClusterAddOnConditionProgressing); cond != nil {
+			placement.Reason = cond.Reason
+			placement.Message = cond.Message
+		}
+		status.Placements = append(status.Placements, placement)
+	}
+
+	var failed []string
+	for i := range addons {
+		mca := &addons[i]
+		if mca.Name != addon.SearchAddonName {
+			continue
+		}
+		status.Total++
+		if ref := deploymentConfigRef(mca); ref != nil && ref.DesiredConfig != nil && ref.LastAppliedConfig != nil &&
+			ref.DesiredConfig.Name == config.Name && ref.DesiredConfig.Namespace == config.Namespace &&
+			*ref.LastAppliedConfig == *ref.DesiredConfig {
+			status.Updated++
+		}
+		if cond := apimeta.FindStatusCondition(mca.Status.Conditions,
+			addonapiv1alpha1.ManagedClusterAddOnConditionProgressing); cond != nil &&
+			cond.Reason == addonapiv1alpha1.ProgressingReasonFailed {
+			failed = append(failed, mca.Namespace)
+		}
+	}
+	sort.Strings(failed)
+	status.Failed = len(failed)
+	if len(failed) > maxListedFailedClusters {
+		failed = failed[:maxListedFailedClusters]
+	}
+	status.FailedClusters = failed
+	return status
+}
+
+// reconcileCollectorRollout sets the install strategy of the search-collector ClusterManagementAddOn
+// from spec.collectorRollout, and reports the rollout progress in status.collectorRollout.
+func (r *SearchReconciler) reconcileCollectorRollout(ctx context.Context,
+	instance *searchv1alpha1.Search) (*reconcile.Result, error) {
+	cma := &addonapiv1alpha1.ClusterManagementAddOn{}
+	if err := r.Get(ctx, types.NamespacedName{Name: addon.SearchAddonName}, cma); err != nil {
+		if errors.IsNotFound(err) || apimeta.IsNoMatchError(err) {
+			// The addon is not installed, so there is nothing to roll out.
+			return nil, nil
+		}
+		log.Error(err, "Failed to get ClusterManagementAddOn", "name", addon.SearchAddonName)
+		return &reconcile.Result{}, err
+	}
+	addons := &addonapiv1alpha1.ManagedClusterAddOnList{}
+	if err := r.List(ctx, addons); err != nil {
+		log.Error(err, "Could not list ManagedClusterAddOns to report the collector rollout")
+		return &reconcile.Result{}, err
+	}
+
+	rollout := instance.Spec.CollectorRollout
+	if rollout == nil {
+		if _, ok := cma.Annotations[managedInstallStrategyAnnotation]; ok {
+			patch := client.MergeFrom(cma.DeepCopy())
+			cma.Spec.InstallStrategy = addonapiv1alpha1.InstallStrategy{Type: addonapiv1alpha1.AddonInstallStrategyManual}
+			delete(cma.Annotations, managedInstallStrategyAnnotation)
+			if err := r.Patch(ctx, cma, patch); err != nil {
+				log.Error(err, "Failed to reset the install strategy of ClusterManagementAddOn", "name", cma.Name)
+				return &reconcile.Result{}, err
+			}
+			log.Info("Reset the install strategy of ClusterManagementAddOn", "name", cma.Name)
+		}
+		if err := r.deleteCollectorRolloutConfigs(ctx, instance, "", addons.Items); err != nil {
+			return &reconcile.Result{}, err
+		}
+		if instance.Status.CollectorRollout == nil {
+			return nil, nil
+		}
+		instance.Status.CollectorRollout = nil
+		if err := r.commitSearchCRInstanceState(ctx, instance); err != nil {
+			return &reconcile.Result{}, err
+		}
+		return nil, nil
+	}
+
+	var userConfig *addonapiv1alpha1.AddOnDeploymentConfig
+	if rollout.DeploymentConfig != "" {
+		userConfig = &addonapiv1alpha1.AddOnDeploymentConfig{}
+		if err := r.Get(ctx, types.NamespacedName{Name: rollout.DeploymentConfig,
+			Namespace: instance.GetNamespace()}, userConfig); err != nil {
+			log.Error(err, "Failed to get the collector rollout AddOnDeploymentConfig", "name", rollout.DeploymentConfig)
+			return &reconcile.Result{}, err
+		}
+	}
+	image := addon.CollectorImage(instance)
+	config, err := collectorRolloutConfig(instance, userConfig, image)
+	if err != nil {
+		return &reconcile.Result{}, err
+	}
+	if err := controllerutil.SetControllerReference(instance, config, r.Scheme); err != nil {
+		log.V(2).Info("Could not set control for collector rollout AddOnDeploymentConfig", "name", config.Name)
+	}
+	if err := r.Create(ctx, config); err != nil && !errors.IsAlreadyExists(err) {
+		log.Error(err, "Failed to create the collector rollout AddOnDeploymentConfig", "name", config.Name)
+		return &reconcile.Result{}, err
+	}
+
+	strategy := collectorInstallStrategy(rollout, config.Name, config.Namespace)
+	supported := false
+	for _, meta := range cma.Spec.SupportedConfigs {
+		supported = supported || meta.ConfigGroupResource == addonDeploymentConfigResource
+	}
+	if _, ok := cma.Annotations[managedInstallStrategyAnnotation]; !ok || !supported ||
+		!equality.Semantic.DeepEqual(cma.Spec.InstallStrategy, strategy) {
+		patch := client.MergeFrom(cma.DeepCopy())
+		cma.Spec.InstallStrategy = strategy
+		if !supported {
+			cma.Spec.SupportedConfigs = append(cma.Spec.SupportedConfigs,
+				addonapiv1alpha1.ConfigMeta{ConfigGroupResource: addonDeploymentConfigResource})
+		}
+		if cma.Annotations == nil {
+			cma.Annotations = map[string]string{}
+		}
+		cma.Annotations[managedInstallStrategyAnnotation] = "true"
+		if err := r.Patch(ctx, cma, patch); err != nil {
+			log.Error(err, "Failed to set the install strategy of ClusterManagementAddOn", "name", cma.Name)
+			return &reconcile.Result{}, err
+		}
+		log.Info("Rolling out the collector addon", "deploymentConfig", config.Name, "image", image)
+	}
+
+	if err := r.deleteCollectorRolloutConfigs(ctx, instance, config.Name, addons.Items); err != nil {
+		return &reconcile.Result{}, err
+	}
+
+	status := collectorRolloutStatus(image, config, cma, addons.Items)
+	if equality.Semantic.DeepEqual(instance.Status.CollectorRollout, status) {
+		return nil, nil
+	}
+	instance.Status.CollectorRollout = status
+	if err := r.commitSearchCRInstanceState(ctx, instance); err != nil {
+		return &reconcile.Result{}, err
+	}
+	log.V(2).Info("Updated collector rollout in Search status", "updated", status.Updated,
+		"total", status.Total, "failed", status.Failed)
+	return nil, nil
+}
+
+// deleteCollectorRolloutConfigs deletes the AddOnDeploymentConfigs created for earlier rollouts,
+// except current and the configs that a ManagedClusterAddOn still wants or runs with, so clusters
+// that have not moved on yet keep their config.
+func (r *SearchReconciler) deleteCollectorRolloutConfigs(ctx context.Context, instance *searchv1alpha1.Search,
+	current string, addons []addonapiv1alpha1.ManagedClusterAddOn) error {
+	configs := &addonapiv1alpha1.AddOnDeploymentConfigList{}
+	if err := r.List(ctx, configs, client.InNamespace(instance.GetNamespace()),
+		client.MatchingLabels{collectorRolloutLabel: "true"}); err != nil {
+		log.Error(err, "Could not list collector rollout AddOnDeploymentConfigs")
+		return err
+	}
+	inUse := map[string]bool{current: true}
+	for i := range addons {
+		ref := deploymentConfigRef(&addons[i])
+		if ref == nil {
+			continue
+		}
+		for _, used := range []*addonapiv1alpha1.ConfigSpecHash{ref.DesiredConfig, ref.LastAppliedConfig} {
+			if used != nil && used.Namespace == instance.GetNamespace() {
+				inUse[used.Name] = true
+			}
+		}
+	}
+	for i := range configs.Items {
+		config := &configs.Items[i]
+		if inUse[config.Name] {
+			continue
+		}
+		if err := r.Delete(ctx, config); err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Failed to delete collector rollout AddOnDeploymentConfig", "name", config.Name)
+			return err
+		}
+		log.V(2).Info("Deleted collector rollout AddOnDeploymentConfig", "name", config.Name)
+	}
+	return nil
+}
+
+// collectorAddonRolloutPred triggers when the install progressions of the search-collector
+// ClusterManagementAddOn change.
+var collectorAddonRolloutPred = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		return e.Object.GetName() == addon.SearchAddonName
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldCMA, okOld := e.ObjectOld.(*addonapiv1alpha1.ClusterManagementAddOn)
+		newCMA, okNew := e.ObjectNew.(*addonapiv1alpha1.ClusterManagementAddOn)
+		if !okOld || !okNew || newCMA.Name != addon.SearchAddonName {
+			return false
+		}
+		return !equality.Semantic.DeepEqual(oldCMA.Status.InstallProgressions, newCMA.Status.InstallProgressions) ||
+			!equality.Semantic.DeepEqual(oldCMA.Spec.InstallStrategy, newCMA.Spec.InstallStrategy)
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return false
+	},
+	GenericFunc: func(e event.GenericEvent) bool {
+		return false
+	},
+}
+
+// collectorRolloutConfigPred triggers on the AddOnDeploymentConfigs in the operator namespace that
+// spec.collectorRollout.deploymentConfig can name, but not on the ones the operator creates.
+var collectorRolloutConfigPred = predicate.NewPredicateFuncs(func(o client.Object) bool {
+	return o.GetNamespace() == os.Getenv("POD_NAMESPACE") && o.GetLabels()[collectorRolloutLabel] == ""
+})
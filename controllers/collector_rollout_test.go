@@ -0,0 +1,245 @@
+// Copyright Contributors to the Open Cluster Management project
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stolostron/search-v2-operator/addon"
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/scheme"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newCollectorCMA() *addonapiv1alpha1.ClusterManagementAddOn {
+	return &addonapiv1alpha1.ClusterManagementAddOn{
+		ObjectMeta: metav1.ObjectMeta{Name: addon.SearchAddonName},
+		Spec: addonapiv1alpha1.ClusterManagementAddOnSpec{
+			InstallStrategy: addonapiv1alpha1.InstallStrategy{Type: addonapiv1alpha1.AddonInstallStrategyManual},
+		},
+	}
+}
+
+// newRolloutAddon returns a search-collector ManagedClusterAddOn that wants the desired config and
+// last applied the applied config.
+func newRolloutAddon(cluster, desired, applied, progressing string) *addonapiv1alpha1.ManagedClusterAddOn {
+	mca := newCollectorAddon(addon.SearchAddonName, cluster, metav1.ConditionTrue)
+	ref := addonapiv1alpha1.ConfigReference{ConfigGroupResource: addonDeploymentConfigResource}
+	if desired != "" {
+		ref.DesiredConfig = &addonapiv1alpha1.ConfigSpecHash{SpecHash: "hash-" + desired,
+			ConfigReferent: addonapiv1alpha1.ConfigReferent{Name: desired, Namespace: testNamespace}}
+	}
+	if applied != "" {
+		ref.LastAppliedConfig = &addonapiv1alpha1.ConfigSpecHash{SpecHash: "hash-" + applied,
+			ConfigReferent: addonapiv1alpha1.ConfigReferent{Name: applied, Namespace: testNamespace}}
+	}
+	mca.Status.ConfigReferences = []addonapiv1alpha1.ConfigReference{ref}
+	if progressing != "" {
+		mca.Status.Conditions = append(mca.Status.Conditions, metav1.Condition{
+			Type: addonapiv1alpha1.ManagedClusterAddOnConditionProgressing, Status: metav1.ConditionFalse,
+			Reason: progressing, LastTransitionTime: metav1.Now(),
+		})
+	}
+	return mca
+}
+
+func newRolloutConfig(name string) *addonapiv1alpha1.AddOnDeploymentConfig {
+	return &addonapiv1alpha1.AddOnDeploymentConfig{ObjectMeta: metav1.ObjectMeta{
+		Name: name, Namespace: testNamespace, Labels: map[string]string{collectorRolloutLabel: "true"},
+	}}
+}
+
+func newRolloutReconciler(t *testing.T, rollout *searchv1alpha1.CollectorRollout,
+	objs ...runtime.Object) (*SearchReconciler, *searchv1alpha1.Search) {
+	s := scheme.Scheme
+	_ = searchv1alpha1.SchemeBuilder.AddToScheme(s)
+	_ = addonapiv1alpha1.AddToScheme(s)
+	search := newSearchInstance()
+	search.Spec.CollectorRollout = rollout
+	r := &SearchReconciler{Client: fake.NewClientBuilder().WithRuntimeObjects(append(objs, search)...).
+		WithStatusSubresource(&searchv1alpha1.Search{}).Build(), Scheme: s}
+	// The addons are in the cluster namespaces, outside the operator namespace.
+	scopeToCache(t, r)
+	instance := &searchv1alpha1.Search{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: OperatorName, Namespace: testNamespace}, instance))
+	return r, instance
+}
+
+func TestReconcileCollectorRollout(t *testing.T) {
+	addon.SearchCollectorImage = "quay.io/stolostron/search-collector:2.17.0"
+	userConfig := &addonapiv1alpha1.AddOnDeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "collector-settings", Namespace: testNamespace},
+		Spec: addonapiv1alpha1.AddOnDeploymentConfigSpec{CustomizedVariables: []addonapiv1alpha1.CustomizedVariable{
+			{Name: addon.SettingMemoryLimit, Value: "1Gi"},
+			{Name: addon.ImageVariable, Value: "quay.io/stolostron/search-collector:ignored"},
+		}},
+	}
+	maxConcurrency := intstr.FromString("25%")
+	rollout := &searchv1alpha1.CollectorRollout{
+		Placements: []searchv1alpha1.CollectorRolloutPlacement{
+			{Name: "canary", Namespace: "open-cluster-management-global-set",
+				RolloutStrategy: clusterv1alpha1.RolloutStrategy{Type: clusterv1alpha1.All}},
+			{Name: "fleet", Namespace: "open-cluster-management-global-set",
+				RolloutStrategy: clusterv1alpha1.RolloutStrategy{Type: clusterv1alpha1.Progressive,
+					Progressive: &clusterv1alpha1.RolloutProgressive{MaxConcurrency: maxConcurrency}}},
+		},
+		DeploymentConfig: "collector-settings",
+	}
+	expected, err := collectorRolloutConfig(newSearchInstance(), userConfig, addon.SearchCollectorImage)
+	require.NoError(t, err)
+
+	cma := newCollectorCMA()
+	cma.Status.InstallProgressions = []addonapiv1alpha1.InstallProgression{{
+		PlacementRef: addonapiv1alpha1.PlacementRef{Name: "fleet", Namespace: "open-cluster-management-global-set"},
+		Conditions: []metav1.Condition{{
+			Type: addonapiv1alpha1.ManagedClusterAddOnConditionProgressing, Status: metav1.ConditionTrue,
+			Reason: "Upgrading", Message: "1/3 upgrading..., 1 timeout.", LastTransitionTime: metav1.Now(),
+		}},
+	}}
+	r, instance := newRolloutReconciler(t, rollout, cma, userConfig,
+		newRolloutConfig("search-collector-old"),
+		newRolloutConfig("search-collector-older"),
+		newRolloutAddon("cluster-a", expected.Name, expected.Name, addonapiv1alpha1.ProgressingReasonCompleted),
+		newRolloutAddon("cluster-b", expected.Name, "search-collector-old", addonapiv1alpha1.ProgressingReasonFailed),
+		newRolloutAddon("cluster-c", expected.Name, "search-collector-old", addonapiv1alpha1.ProgressingReasonProgressing),
+	)
+
+	result, err := r.reconcileCollectorRollout(context.TODO(), instance)
+	require.NoError(t, err)
+	assert.Nil(t, result)
+
+	// The config carries the user settings and the operator image.
+	config := &addonapiv1alpha1.AddOnDeploymentConfig{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: expected.Name, Namespace: testNamespace}, config))
+	assert.Equal(t, []addonapiv1alpha1.CustomizedVariable{
+		{Name: addon.SettingMemoryLimit, Value: "1Gi"},
+		{Name: addon.ImageVariable, Value: "quay.io/stolostron/search-collector:2.17.0"},
+	}, config.Spec.CustomizedVariables)
+	assert.Equal(t, OperatorName, config.OwnerReferences[0].Name)
+
+	// Configs still applied on a cluster are kept until the cluster moves on.
+	configs := &addonapiv1alpha1.AddOnDeploymentConfigList{}
+	require.NoError(t, r.List(context.TODO(), configs, client.MatchingLabels{collectorRolloutLabel: "true"}))
+	var names []string
+	for _, c := range configs.Items {
+		names = append(names, c.Name)
+	}
+	assert.ElementsMatch(t, []string{expected.Name, "search-collector-old"}, names)
+
+	storedCMA := &addonapiv1alpha1.ClusterManagementAddOn{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: addon.SearchAddonName}, storedCMA))
+	assert.Equal(t, "true", storedCMA.Annotations[managedInstallStrategyAnnotation])
+	assert.Equal(t, addonapiv1alpha1.AddonInstallStrategyPlacements, storedCMA.Spec.InstallStrategy.Type)
+	require.Len(t, storedCMA.Spec.InstallStrategy.Placements, 2)
+	fleet := storedCMA.Spec.InstallStrategy.Placements[1]
+	assert.Equal(t, "fleet", fleet.Name)
+	assert.Equal(t, clusterv1alpha1.Progressive, fleet.RolloutStrategy.Type)
+	assert.Equal(t, expected.Name, fleet.Configs[0].Name)
+	assert.Equal(t, []addonapiv1alpha1.ConfigMeta{{ConfigGroupResource: addonDeploymentConfigResource}},
+		storedCMA.Spec.SupportedConfigs)
+
+	stored := &searchv1alpha1.Search{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: OperatorName, Namespace: testNamespace}, stored))
+	assert.Equal(t, &searchv1alpha1.CollectorRolloutStatus{
+		Image:            "quay.io/stolostron/search-collector:2.17.0",
+		DeploymentConfig: expected.Name,
+		Total:            3,
+		Updated:          1,
+		Failed:           1,
+		FailedClusters:   []string{"cluster-b"},
+		Placements: []searchv1alpha1.CollectorRolloutPlacementStatus{{
+			Name: "fleet", Namespace: "open-cluster-management-global-set",
+			Reason: "Upgrading", Message: "1/3 upgrading..., 1 timeout.",
+		}},
+	}, stored.Status.CollectorRollout)
+}
+
+func TestReconcileCollectorRollout_NewImage(t *testing.T) {
+	rollout := &searchv1alpha1.CollectorRollout{Placements: []searchv1alpha1.CollectorRolloutPlacement{
+		{Name: "fleet", Namespace: "open-cluster-management-global-set"},
+	}}
+	addon.SearchCollectorImage = "quay.io/stolostron/search-collector:2.16.0"
+	old, err := collectorRolloutConfig(newSearchInstance(), nil, addon.SearchCollectorImage)
+	require.NoError(t, err)
+	addon.SearchCollectorImage = "quay.io/stolostron/search-collector:2.17.0"
+	current, err := collectorRolloutConfig(newSearchInstance(), nil, addon.SearchCollectorImage)
+	require.NoError(t, err)
+	assert.NotEqual(t, old.Name, current.Name)
+
+	r, instance := newRolloutReconciler(t, rollout, newCollectorCMA(), newRolloutConfig(old.Name),
+		newRolloutAddon("cluster-a", old.Name, old.Name, addonapiv1alpha1.ProgressingReasonCompleted))
+	_, err = r.reconcileCollectorRollout(context.TODO(), instance)
+	require.NoError(t, err)
+
+	storedCMA := &addonapiv1alpha1.ClusterManagementAddOn{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: addon.SearchAddonName}, storedCMA))
+	assert.Equal(t, current.Name, storedCMA.Spec.InstallStrategy.Placements[0].Configs[0].Name)
+	assert.Equal(t, 0, instance.Status.CollectorRollout.Updated)
+	assert.Equal(t, 1, instance.Status.CollectorRollout.Total)
+}
+
+func TestReconcileCollectorRollout_Disabled(t *testing.T) {
+	cma := newCollectorCMA()
+	cma.Annotations = map[string]string{managedInstallStrategyAnnotation: "true"}
+	cma.Spec.InstallStrategy = addonapiv1alpha1.InstallStrategy{Type: addonapiv1alpha1.AddonInstallStrategyPlacements,
+		Placements: []addonapiv1alpha1.PlacementStrategy{{
+			PlacementRef: addonapiv1alpha1.PlacementRef{Name: "fleet", Namespace: "open-cluster-management-global-set"},
+		}}}
+	r, instance := newRolloutReconciler(t, nil, cma, newRolloutConfig("search-collector-old"))
+	instance.Status.CollectorRollout = &searchv1alpha1.CollectorRolloutStatus{DeploymentConfig: "search-collector-old"}
+
+	result, err := r.reconcileCollectorRollout(context.TODO(), instance)
+	require.NoError(t, err)
+	assert.Nil(t, result)
+
+	storedCMA := &addonapiv1alpha1.ClusterManagementAddOn{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: addon.SearchAddonName}, storedCMA))
+	assert.Equal(t, addonapiv1alpha1.InstallStrategy{Type: addonapiv1alpha1.AddonInstallStrategyManual},
+		storedCMA.Spec.InstallStrategy)
+	assert.NotContains(t, storedCMA.Annotations, managedInstallStrategyAnnotation)
+	configs := &addonapiv1alpha1.AddOnDeploymentConfigList{}
+	require.NoError(t, r.List(context.TODO(), configs))
+	assert.Empty(t, configs.Items)
+	assert.Nil(t, instance.Status.CollectorRollout)
+}
+
+func TestReconcileCollectorRollout_UnmanagedStrategyKept(t *testing.T) {
+	cma := newCollectorCMA()
+	cma.Spec.InstallStrategy = addonapiv1alpha1.InstallStrategy{Type: addonapiv1alpha1.AddonInstallStrategyPlacements,
+		Placements: []addonapiv1alpha1.PlacementStrategy{{
+			PlacementRef: addonapiv1alpha1.PlacementRef{Name: "global", Namespace: "open-cluster-management-global-set"},
+		}}}
+	r, instance := newRolloutReconciler(t, nil, cma)
+
+	_, err := r.reconcileCollectorRollout(context.TODO(), instance)
+	require.NoError(t, err)
+
+	storedCMA := &addonapiv1alpha1.ClusterManagementAddOn{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: addon.SearchAddonName}, storedCMA))
+	assert.Equal(t, cma.Spec.InstallStrategy, storedCMA.Spec.InstallStrategy)
+}
+
+func TestCollectorRolloutStatus_ListsFirstClusters(t *testing.T) {
+	config := newRolloutConfig("search-collector-new")
+	var addons []addonapiv1alpha1.ManagedClusterAddOn
+	for i := 0; i < 12; i++ {
+		addons = append(addons, *newRolloutAddon(fmt.Sprintf("cluster-%02d", i), config.Name, "",
+			addonapiv1alpha1.ProgressingReasonFailed))
+	}
+
+	status := collectorRolloutStatus("image", config, newCollectorCMA(), addons)
+
+	assert.Equal(t, 12, status.Failed)
+	assert.Len(t, status.FailedClusters, maxListedFailedClusters)
+	assert.Equal(t, "cluster-00", status.FailedClusters[0])
+}
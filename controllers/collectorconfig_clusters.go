@@ -0,0 +1,263 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// scopedRule returns a copy of a rule from the given CollectorConfig with the config-level
+// clusterSelector applied when the rule has none, and the Placement namespace defaulted to the
+// config's namespace, so the merged rule can be evaluated without its source.
+func scopedRule(cc *searchv1alpha1.CollectorConfig, rule searchv1alpha1.CollectionRule) searchv1alpha1.CollectionRule {
+	scoped := *rule.DeepCopy()
+	if scoped.ClusterSelector == nil && cc.Spec.ClusterSelector != nil {
+		scoped.ClusterSelector = cc.Spec.ClusterSelector.DeepCopy()
+	}
+	if scoped.ClusterSelector != nil && scoped.ClusterSelector.PlacementRef != nil &&
+		scoped.ClusterSelector.PlacementRef.Namespace == "" {
+		scoped.ClusterSelector.PlacementRef.Namespace = cc.Namespace
+	}
+	return scoped
+}
+
+// usesClusterSelectors returns true when any merged rule, or a namespace selector override, is
+// limited to selected clusters.
+func usesClusterSelectors(spec searchv1alpha1.CollectorConfigSpec) bool {
+	if spec.CollectNamespaces != nil && len(spec.CollectNamespaces.ClusterOverrides) > 0 {
+		return true
+	}
+	for _, rule := range spec.CollectionRules {
+		if rule.ClusterSelector != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// clusterMatcher evaluates ClusterSelectors, reading each Placement's decisions once.
+type clusterMatcher struct {
+	client     client.Client
+	placements map[types.NamespacedName]sets.Set[string]
+}
+
+// matches reports whether the selector selects the managed cluster. An invalid label selector
+// selects nothing. A Placement whose decisions cannot be read is an error, so its rules are not
+// dropped from the clusters it selects.
+func (m *clusterMatcher) matches(ctx context.Context, sel *searchv1alpha1.ClusterSelector,
+	mc *clusterv1.ManagedCluster) (bool, error) {
+	if sel == nil {
+		return true, nil
+	}
+	if sel.LabelSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(sel.LabelSelector)
+		if err != nil {
+			log.Error(err, "Ignoring rule with an invalid cluster label selector")
+			return false, nil
+		}
+		return selector.Matches(labels.Set(mc.GetLabels())), nil
+	}
+	if sel.PlacementRef != nil {
+		key := types.NamespacedName{Name: sel.PlacementRef.Name, Namespace: sel.PlacementRef.Namespace}
+		decided, ok := m.placements[key]
+		if !ok {
+			var err error
+			if decided, err = m.placementDecisions(ctx, key); err != nil {
+				return false, err
+			}
+			m.placements[key] = decided
+		}
+		return decided.Has(mc.Name), nil
+	}
+	return false, nil
+}
+
+// placementDecisions returns the names of the clusters selected by a Placement.
+func (m *clusterMatcher) placementDecisions(ctx context.Context,
+	placement types.NamespacedName) (sets.Set[string], error) {
+	decisions := &clusterv1beta1.PlacementDecisionList{}
+	err := m.client.List(ctx, decisions,
+		client.InNamespace(placement.Namespace),
+		client.MatchingLabels{clusterv1beta1.PlacementLabel: placement.Name},
+	)
+	if err != nil {
+		log.Error(err, "Could not list PlacementDecisions", "placement", placement.String())
+		return nil, err
+	}
+	decided := sets.New[string]()
+	for _, d := range decisions.Items {
+		for _, cd := range d.Status.Decisions {
+			decided.Insert(cd.ClusterName)
+		}
+	}
+	return decided, nil
+}
+
+// ClusterCollectorConfig returns the effective merged-collector-config for one managed cluster: the
+// merged rules whose clusterSelector selects the cluster, without the selector. ruleSources is the
+// provenance of the merged rules and is filtered the same way for the provenance annotation.
+func (r *SearchReconciler) ClusterCollectorConfig(ctx context.Context, matcher *clusterMatcher,
+	merged *searchv1alpha1.CollectorConfig, mc *clusterv1.ManagedCluster) (*searchv1alpha1.CollectorConfig, error) {
+	spec := searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{},
+	}
+	collectNamespaces, err := clusterCollectNamespaces(ctx, matcher, merged.Spec.CollectNamespaces, mc)
+	if err != nil {
+		return nil, err
+	}
+	spec.CollectNamespaces = collectNamespaces
+	// Redaction rules and relationships apply on every cluster.
+	for _, rule := range merged.Spec.Redact {
+		spec.Redact = append(spec.Redact, *rule.DeepCopy())
+	}
+	for _, rel := range merged.Spec.Relationships {
+		spec.Relationships = append(spec.Relationships, *rel.DeepCopy())
+	}
+	var ruleSources []searchv1alpha1.RuleSource
+	for i, rule := range merged.Spec.CollectionRules {
+		selected, err := matcher.matches(ctx, rule.ClusterSelector, mc)
+		if err != nil {
+			return nil, err
+		}
+		if !selected {
+			continue
+		}
+		rule = *rule.DeepCopy()
+		rule.ClusterSelector = nil
+		spec.CollectionRules = append(spec.CollectionRules, rule)
+		if i < len(merged.Status.RuleSources) {
+			ruleSources = append(ruleSources, merged.Status.RuleSources[i])
+		}
+	}
+	return &searchv1alpha1.CollectorConfig{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "CollectorConfig",
+			APIVersion: searchv1alpha1.GroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      mergedCollectorConfigName,
+			Namespace: mc.Name,
+			Labels: map[string]string{
+				searchv1alpha1.IntegrationTeamLabel: searchv1alpha1.ClusterConfigLabelValue,
+			},
+			Annotations: map[string]string{
+				searchv1alpha1.AnnotationMergeProvenance: provenanceAnnotation(ruleSources),
+			},
+		},
+		Spec: spec,
+	}, nil
+}
+
+// reconcileClusterCollectorConfigs writes the effective merged-collector-config of every managed
+// cluster to the cluster namespace when merged rules use a clusterSelector, where the cluster's
+// collector reads it with the addon's hub credentials. When no rule uses a clusterSelector all
+// per-cluster configs are removed and every collector uses merged-collector-config.
+//
+// The per-cluster configs cannot be owned by the Search CR because they live in other namespaces;
+// they are found by the config-type label instead.
+func (r *SearchReconciler) reconcileClusterCollectorConfigs(ctx context.Context,
+	instance *searchv1alpha1.Search) (*reconcile.Result, error) {
+	merged := &searchv1alpha1.CollectorConfig{}
+	err := r.Get(ctx, types.NamespacedName{Name: mergedCollectorConfigName, Namespace: instance.GetNamespace()}, merged)
+	if err != nil {
+		log.Error(err, "Could not get merged-collector-config to compute per-cluster configs")
+		return &reconcile.Result{}, err
+	}
+
+	existing := &searchv1alpha1.CollectorConfigList{}
+	err = r.List(ctx, existing,
+		client.MatchingLabels{searchv1alpha1.IntegrationTeamLabel: searchv1alpha1.ClusterConfigLabelValue})
+	if err != nil {
+		log.Error(err, "Could not list per-cluster CollectorConfigs")
+		return &reconcile.Result{}, err
+	}
+
+	wanted := sets.New[string]()
+	if usesClusterSelectors(merged.Spec) {
+		clusters := &clusterv1.ManagedClusterList{}
+		if err := r.List(ctx, clusters); err != nil {
+			log.Error(err, "Could not list ManagedClusters for per-cluster CollectorConfigs")
+			return &reconcile.Result{}, err
+		}
+		matcher := &clusterMatcher{client: r.Client, placements: map[types.NamespacedName]sets.Set[string]{}}
+		for i := range clusters.Items {
+			mc := &clusters.Items[i]
+			if !mc.DeletionTimestamp.IsZero() {
+				continue
+			}
+			wanted.Insert(mc.Name)
+			cc, err := r.ClusterCollectorConfig(ctx, matcher, merged, mc)
+			if err != nil {
+				log.Error(err, "Could not compute the per-cluster CollectorConfig", "namespace", mc.Name)
+				return &reconcile.Result{}, err
+			}
+			if err := r.createOrUpdateClusterCollectorConfig(ctx, cc); err != nil {
+				return &reconcile.Result{}, err
+			}
+		}
+	}
+
+	for i := range existing.Items {
+		cc := &existing.Items[i]
+		if cc.Name != mergedCollectorConfigName || wanted.Has(cc.Namespace) {
+			continue
+		}
+		if err := r.Delete(ctx, cc); err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Could not delete per-cluster CollectorConfig", "namespace", cc.Namespace)
+			return &reconcile.Result{}, err
+		}
+		log.V(2).Info("Deleted per-cluster CollectorConfig", "namespace", cc.Namespace)
+	}
+	return nil, nil
+}
+
+// createOrUpdateClusterCollectorConfig writes a per-cluster config when it changed. A cluster
+// namespace that does not exist yet is skipped; the ManagedCluster watch retries later.
+func (r *SearchReconciler) createOrUpdateClusterCollectorConfig(ctx context.Context,
+	cc *searchv1alpha1.CollectorConfig) error {
+	found := &searchv1alpha1.CollectorConfig{}
+	err := r.Get(ctx, types.NamespacedName{Name: cc.Name, Namespace: cc.Namespace}, found)
+	if errors.IsNotFound(err) {
+		if err := r.Create(ctx, cc); err != nil {
+			if errors.IsNotFound(err) {
+				log.V(2).Info("Cluster namespace not found, skipping per-cluster CollectorConfig", "namespace", cc.Namespace)
+				return nil
+			}
+			log.Error(err, "Could not create per-cluster CollectorConfig", "namespace", cc.Namespace)
+			return err
+		}
+		log.V(2).Info("Created per-cluster CollectorConfig", "namespace", cc.Namespace,
+			"ruleCount", len(cc.Spec.CollectionRules))
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(found.Spec, cc.Spec) &&
+		equality.Semantic.DeepEqual(found.Labels, cc.Labels) &&
+		equality.Semantic.DeepEqual(found.Annotations, cc.Annotations) {
+		return nil
+	}
+	found.Spec = cc.Spec
+	found.Labels = cc.Labels
+	found.Annotations = cc.Annotations
+	if err := r.Update(ctx, found); err != nil {
+		log.Error(err, "Could not update per-cluster CollectorConfig", "namespace", cc.Namespace)
+		return err
+	}
+	log.V(2).Info("Updated per-cluster CollectorConfig", "namespace", cc.Namespace,
+		"ruleCount", len(cc.Spec.CollectionRules))
+	return nil
+}
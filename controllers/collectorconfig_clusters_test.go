@@ -0,0 +1,344 @@
+// Copyright Contributors to the Open Cluster Management project
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var argoSelector = searchv1alpha1.ResourceSelector{APIGroups: []string{"argoproj.io"}, Kinds: []string{"*"}}
+
+func newManagedCluster(name string, labels map[string]string) *clusterv1.ManagedCluster {
+	return &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func newNamespace(name string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+}
+
+// setupClusterReconciler uses its own scheme; registering the cluster types in scheme.Scheme would
+// change how the global search tests' fake dynamic client lists ManagedClusters.
+func setupClusterReconciler(t *testing.T, objs ...runtime.Object) *SearchReconciler {
+	t.Helper()
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, searchv1alpha1.AddToScheme(s))
+	require.NoError(t, clusterv1.AddToScheme(s))
+	require.NoError(t, clusterv1beta1.AddToScheme(s))
+	cl := fake.NewClientBuilder().
+		WithScheme(s).
+		WithRuntimeObjects(objs...).
+		WithStatusSubresource(&searchv1alpha1.CollectorConfig{}).
+		Build()
+	return &SearchReconciler{Client: cl, Scheme: s}
+}
+
+// scopeToCache makes the reconciler's client read like the manager cache built from
+// CacheOptions(testNamespace), as the operator runs with WATCH_NAMESPACE set: namespaced types that
+// are not cached in all namespaces can only be read in testNamespace. The fake client has no such
+// restriction, so without this a read in a cluster namespace passes in tests and fails on a hub.
+func scopeToCache(t *testing.T, r *SearchReconciler) {
+	t.Helper()
+	allNamespaces := sets.New[schema.GroupVersionKind]()
+	for obj, byObject := range CacheOptions(testNamespace).ByObject {
+		// Types missing from the test's scheme cannot be read anyway.
+		if gvk, err := apiutil.GVKForObject(obj, r.Scheme); err == nil {
+			if _, ok := byObject.Namespaces[cache.AllNamespaces]; ok {
+				allNamespaces.Insert(gvk)
+			}
+		}
+	}
+	cachedInAllNamespaces := func(obj runtime.Object) bool {
+		gvk, err := apiutil.GVKForObject(obj, r.Scheme)
+		if err != nil {
+			return false
+		}
+		if apimeta.IsListType(obj) {
+			gvk.Kind = gvk.Kind[:len(gvk.Kind)-len("List")]
+		}
+		return allNamespaces.Has(gvk)
+	}
+	r.Client = interceptor.NewClient(r.Client.(client.WithWatch), interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object,
+			opts ...client.GetOption) error {
+			if key.Namespace != "" && key.Namespace != testNamespace && !cachedInAllNamespaces(obj) {
+				return fmt.Errorf("unable to get: %v because of unknown namespace for the cache", key)
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			namespace := (&client.ListOptions{}).ApplyOptions(opts).Namespace
+			if cachedInAllNamespaces(list) || namespace == testNamespace {
+				return c.List(ctx, list, opts...)
+			}
+			if namespace != "" {
+				return fmt.Errorf("unable to list: %v because of unknown namespace for the cache", namespace)
+			}
+			if err := c.List(ctx, list, opts...); err != nil {
+				return err
+			}
+			// Cluster-scoped objects, and namespaced objects in the cached namespace.
+			items, err := apimeta.ExtractList(list)
+			if err != nil {
+				return err
+			}
+			var cached []runtime.Object
+			for _, item := range items {
+				if ns := item.(client.Object).GetNamespace(); ns == "" || ns == testNamespace {
+					cached = append(cached, item)
+				}
+			}
+			return apimeta.SetList(list, cached)
+		},
+	})
+}
+
+func getClusterConfig(t *testing.T, r *SearchReconciler, cluster string) (*searchv1alpha1.CollectorConfig, error) {
+	t.Helper()
+	cc := &searchv1alpha1.CollectorConfig{}
+	err := r.Get(context.TODO(), types.NamespacedName{Name: mergedCollectorConfigName, Namespace: cluster}, cc)
+	return cc, err
+}
+
+func mergeAndScope(t *testing.T, r *SearchReconciler, instance *searchv1alpha1.Search) {
+	t.Helper()
+	_, err := r.createOrUpdateMergedCollectorConfig(context.TODO(), instance)
+	require.NoError(t, err)
+	result, err := r.reconcileClusterCollectorConfigs(context.TODO(), instance)
+	require.NoError(t, err)
+	require.Nil(t, result)
+}
+
+func TestClusterConfigs_LabelSelector(t *testing.T) {
+	instance := newSearchInstance()
+	userCC := newCollectorConfig(userCollectorConfigName, searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{
+				Action:           searchv1alpha1.ActionInclude,
+				ResourceSelector: argoSelector,
+				ClusterSelector: &searchv1alpha1.ClusterSelector{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"gitops": "true"}},
+				},
+			},
+			{
+				Action:           searchv1alpha1.ActionInclude,
+				ResourceSelector: searchv1alpha1.ResourceSelector{APIGroups: []string{"apps"}, Kinds: []string{"Deployment"}},
+			},
+		},
+	})
+	r := setupClusterReconciler(t, instance, userCC,
+		newManagedCluster("gitops-hub", map[string]string{"gitops": "true"}), newNamespace("gitops-hub"),
+		newManagedCluster("edge-1", nil), newNamespace("edge-1"))
+
+	mergeAndScope(t, r, instance)
+
+	gitops, err := getClusterConfig(t, r, "gitops-hub")
+	require.NoError(t, err)
+	require.Len(t, gitops.Spec.CollectionRules, 2)
+	assert.Nil(t, gitops.Spec.CollectionRules[0].ClusterSelector, "selector is resolved by the operator")
+	assert.Equal(t, searchv1alpha1.ClusterConfigLabelValue, gitops.Labels[searchv1alpha1.IntegrationTeamLabel])
+
+	edge, err := getClusterConfig(t, r, "edge-1")
+	require.NoError(t, err)
+	require.Len(t, edge.Spec.CollectionRules, 1)
+	assert.Equal(t, []string{"Deployment"}, edge.Spec.CollectionRules[0].ResourceSelector.Kinds)
+	provenance := []searchv1alpha1.RuleSource{}
+	require.NoError(t, json.Unmarshal([]byte(edge.Annotations[searchv1alpha1.AnnotationMergeProvenance]), &provenance))
+	assert.Equal(t, []searchv1alpha1.RuleSource{{Source: userCollectorConfigName, Index: 1}}, provenance)
+
+	// The hub-wide merged config keeps the selector for visibility.
+	merged, err := getMergedConfig(r)
+	require.NoError(t, err)
+	assert.NotNil(t, merged.Spec.CollectionRules[0].ClusterSelector)
+}
+
+func TestClusterConfigs_PlacementAndConfigLevelSelector(t *testing.T) {
+	instance := newSearchInstance()
+	edgeCC := newIntegrationTeamConfig("edge-integration", searchv1alpha1.CollectorConfigSpec{
+		ClusterSelector: &searchv1alpha1.ClusterSelector{
+			PlacementRef: &searchv1alpha1.PlacementRef{Name: "edge-clusters"},
+		},
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{
+				Action:           searchv1alpha1.ActionExclude,
+				ResourceSelector: searchv1alpha1.ResourceSelector{APIGroups: []string{"events.k8s.io"}, Kinds: []string{"Event"}},
+			},
+		},
+	})
+	decision := &clusterv1beta1.PlacementDecision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "edge-clusters-decision-1",
+			Namespace: testNamespace,
+			Labels:    map[string]string{clusterv1beta1.PlacementLabel: "edge-clusters"},
+		},
+		Status: clusterv1beta1.PlacementDecisionStatus{
+			Decisions: []clusterv1beta1.ClusterDecision{{ClusterName: "edge-1"}},
+		},
+	}
+	r := setupClusterReconciler(t, instance, edgeCC, decision,
+		newManagedCluster("edge-1", nil), newNamespace("edge-1"),
+		newManagedCluster("core-1", nil), newNamespace("core-1"))
+
+	mergeAndScope(t, r, instance)
+
+	merged, err := getMergedConfig(r)
+	require.NoError(t, err)
+	require.Len(t, merged.Spec.CollectionRules, 1)
+	assert.Equal(t, testNamespace, merged.Spec.CollectionRules[0].ClusterSelector.PlacementRef.Namespace,
+		"placement namespace defaults to the source config namespace")
+
+	edge, err := getClusterConfig(t, r, "edge-1")
+	require.NoError(t, err)
+	assert.Len(t, edge.Spec.CollectionRules, 1)
+	core, err := getClusterConfig(t, r, "core-1")
+	require.NoError(t, err)
+	assert.Empty(t, core.Spec.CollectionRules)
+}
+
+func TestClusterConfigs_RemovedWhenSelectorsUnused(t *testing.T) {
+	instance := newSearchInstance()
+	userCC := newCollectorConfig(userCollectorConfigName, searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{
+				Action:           searchv1alpha1.ActionInclude,
+				ResourceSelector: argoSelector,
+				ClusterSelector: &searchv1alpha1.ClusterSelector{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"gitops": "true"}},
+				},
+			},
+		},
+	})
+	r := setupClusterReconciler(t, instance, userCC, newManagedCluster("edge-1", nil), newNamespace("edge-1"))
+	ctx := context.TODO()
+
+	mergeAndScope(t, r, instance)
+	_, err := getClusterConfig(t, r, "edge-1")
+	require.NoError(t, err)
+
+	current := &searchv1alpha1.CollectorConfig{}
+	require.NoError(t, r.Get(ctx, types.NamespacedName{Name: userCollectorConfigName, Namespace: testNamespace}, current))
+	current.Spec.CollectionRules[0].ClusterSelector = nil
+	require.NoError(t, r.Update(ctx, current))
+
+	mergeAndScope(t, r, instance)
+	_, err = getClusterConfig(t, r, "edge-1")
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestClusterConfigs_NoSelectorsNoConfigs(t *testing.T) {
+	instance := newSearchInstance()
+	r := setupClusterReconciler(t, instance, newManagedCluster("edge-1", nil), newNamespace("edge-1"))
+
+	mergeAndScope(t, r, instance)
+	_, err := getClusterConfig(t, r, "edge-1")
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestClusterConfigs_NamespaceScopedCache(t *testing.T) {
+	instance := newSearchInstance()
+	userCC := newCollectorConfig(userCollectorConfigName, searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{
+				Action:           searchv1alpha1.ActionInclude,
+				ResourceSelector: argoSelector,
+				ClusterSelector: &searchv1alpha1.ClusterSelector{
+					PlacementRef: &searchv1alpha1.PlacementRef{Name: "gitops-clusters", Namespace: "gitops"},
+				},
+			},
+		},
+	})
+	decision := &clusterv1beta1.PlacementDecision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "gitops-clusters-decision-1",
+			Namespace: "gitops",
+			Labels:    map[string]string{clusterv1beta1.PlacementLabel: "gitops-clusters"},
+		},
+		Status: clusterv1beta1.PlacementDecisionStatus{
+			Decisions: []clusterv1beta1.ClusterDecision{{ClusterName: "edge-1"}},
+		},
+	}
+	// The per-cluster config of a cluster that was detached.
+	stale := newCollectorConfig(mergedCollectorConfigName, searchv1alpha1.CollectorConfigSpec{})
+	stale.Namespace = "detached-1"
+	stale.Labels = map[string]string{searchv1alpha1.IntegrationTeamLabel: searchv1alpha1.ClusterConfigLabelValue}
+	r := setupClusterReconciler(t, instance, userCC, decision, stale,
+		newManagedCluster("edge-1", nil), newNamespace("edge-1"),
+		newManagedCluster("core-1", nil), newNamespace("core-1"))
+	scopeToCache(t, r)
+
+	mergeAndScope(t, r, instance)
+
+	edge, err := getClusterConfig(t, r, "edge-1")
+	require.NoError(t, err)
+	assert.Len(t, edge.Spec.CollectionRules, 1)
+	core, err := getClusterConfig(t, r, "core-1")
+	require.NoError(t, err)
+	assert.Empty(t, core.Spec.CollectionRules)
+	_, err = getClusterConfig(t, r, "detached-1")
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestClusterConfigs_PlacementReadError(t *testing.T) {
+	instance := newSearchInstance()
+	userCC := newCollectorConfig(userCollectorConfigName, searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{
+				Action:           searchv1alpha1.ActionInclude,
+				ResourceSelector: argoSelector,
+				ClusterSelector: &searchv1alpha1.ClusterSelector{
+					PlacementRef: &searchv1alpha1.PlacementRef{Name: "gitops-clusters"},
+				},
+			},
+		},
+	})
+	decision := &clusterv1beta1.PlacementDecision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "gitops-clusters-decision-1",
+			Namespace: testNamespace,
+			Labels:    map[string]string{clusterv1beta1.PlacementLabel: "gitops-clusters"},
+		},
+		Status: clusterv1beta1.PlacementDecisionStatus{
+			Decisions: []clusterv1beta1.ClusterDecision{{ClusterName: "edge-1"}},
+		},
+	}
+	r := setupClusterReconciler(t, instance, userCC, decision, newManagedCluster("edge-1", nil), newNamespace("edge-1"))
+	mergeAndScope(t, r, instance)
+
+	r.Client = interceptor.NewClient(r.Client.(client.WithWatch), interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			if _, ok := list.(*clusterv1beta1.PlacementDecisionList); ok {
+				return fmt.Errorf("placementdecisions is forbidden")
+			}
+			return c.List(ctx, list, opts...)
+		},
+	})
+	_, err := r.reconcileClusterCollectorConfigs(context.TODO(), instance)
+	assert.Error(t, err)
+
+	// The rule is not dropped from the cluster while the Placement cannot be read.
+	edge, err := getClusterConfig(t, r, "edge-1")
+	require.NoError(t, err)
+	assert.Len(t, edge.Spec.CollectionRules, 1)
+}
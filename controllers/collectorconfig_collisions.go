@@ -0,0 +1,284 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ruleCollision describes a conflict between rules of two integration team CollectorConfigs and
+// how it was resolved.
+type ruleCollision struct {
+	// configs are the names of the two CollectorConfigs involved, in merge order.
+	configs [2]string
+	message string
+}
+
+// sourcedRule is a rule in the merged spec together with the CollectorConfig and the index it
+// came from.
+type sourcedRule struct {
+	config  string
+	index   int
+	rule    searchv1alpha1.CollectionRule
+	dropped bool
+}
+
+// effectiveFieldName returns the key the collector stores a field under, honouring fieldSuffix.
+func effectiveFieldName(rule searchv1alpha1.CollectionRule, f searchv1alpha1.Field) string {
+	if rule.FieldSuffix != "" {
+		return f.Name + "." + rule.FieldSuffix
+	}
+	return f.Name
+}
+
+// fieldType returns the field's data type, treating an empty type as the string default.
+func fieldType(f searchv1alpha1.Field) searchv1alpha1.DataType {
+	if f.Type == "" {
+		return searchv1alpha1.DataTypeString
+	}
+	return f.Type
+}
+
+// describeSelector formats a ResourceSelector for collision messages.
+func describeSelector(s searchv1alpha1.ResourceSelector) string {
+	desc := fmt.Sprintf("kinds %v (apiGroups %v)", s.Kinds, s.APIGroups)
+	if s.LabelSelector != nil {
+		desc += " with labels " + metav1.FormatLabelSelector(s.LabelSelector)
+	}
+	if s.AnnotationSelector != nil {
+		desc += " with annotations " + metav1.FormatLabelSelector(s.AnnotationSelector)
+	}
+	return desc
+}
+
+// describeField formats a Field definition for collision messages.
+func describeField(f searchv1alpha1.Field) string {
+	desc := fmt.Sprintf("%s (%s)", f.JSONPath, fieldType(f))
+	if len(f.Transforms) > 0 {
+		desc += fmt.Sprintf(" with %d transforms", len(f.Transforms))
+	}
+	return desc
+}
+
+// mergeIntegrationRules concatenates the rules of the integration team configs, which must be
+// sorted by name, and resolves collisions between rules from different configs whose selectors
+// overlap:
+//
+//   - An include and an exclude for the same resources: the include wins. The exclude rule is
+//     narrowed to the apiGroups and kinds the include does not select, which can split it in two
+//     rules (see subtractSelector), or dropped when nothing is left.
+//   - The same field name (after fieldSuffix) with a different jsonPath or type: the definition
+//     from the config that sorts first wins and the later field is dropped.
+//   - collectAnnotations set to true by one config and false by the other: annotations are
+//     collected, so the false rule is changed to true.
+//
+// Rules within a single config are never compared; the webhook validates each config on its own.
+// The returned provenance has one entry per returned rule.
+func mergeIntegrationRules(teamConfigs []searchv1alpha1.CollectorConfig) ([]searchv1alpha1.CollectionRule,
+	[]searchv1alpha1.RuleSource, []ruleCollision) {
+	var merged []*sourcedRule
+	var collisions []ruleCollision
+
+	for _, tc := range teamConfigs {
+		for i, rule := range tc.Spec.CollectionRules {
+			pending := []*sourcedRule{{config: tc.Name, index: i, rule: scopedRule(&tc, rule)}}
+			for len(pending) > 0 {
+				current := pending[0]
+				pending = pending[1:]
+				for _, prev := range merged {
+					if prev.dropped || prev.config == current.config ||
+						!rulesOverlap(prev.rule.ResourceSelector, current.rule.ResourceSelector) {
+						continue
+					}
+					resolved, split := resolveRuleCollision(prev, current)
+					collisions = append(collisions, resolved...)
+					// Rules split off a narrowed exclude are compared with the merged rules too.
+					pending = append(pending, split...)
+					if current.dropped {
+						break
+					}
+				}
+				merged = append(merged, current)
+			}
+		}
+	}
+
+	var rules []searchv1alpha1.CollectionRule
+	var provenance []searchv1alpha1.RuleSource
+	for _, sr := range merged {
+		if !sr.dropped {
+			rules = append(rules, sr.rule)
+			provenance = append(provenance, searchv1alpha1.RuleSource{Source: sr.config, Index: sr.index})
+		}
+	}
+	return rules, provenance, collisions
+}
+
+// resolveRuleCollision applies the resolution policy to two overlapping rules from different
+// configs, modifying them in place. prev is earlier in merge order than current. When an exclude
+// rule is narrowed to two selectors, the second one is returned as a new rule.
+func resolveRuleCollision(prev, current *sourcedRule) ([]ruleCollision, []*sourcedRule) {
+	configs := [2]string{prev.config, current.config}
+
+	if prev.rule.Action != current.rule.Action {
+		exclude, include := prev, current
+		if prev.rule.Action == searchv1alpha1.ActionInclude {
+			exclude, include = current, prev
+		}
+		excluded := describeSelector(exclude.rule.ResourceSelector)
+		parts := subtractSelector(exclude.rule.ResourceSelector, include.rule.ResourceSelector)
+		if len(parts) == 0 {
+			exclude.dropped = true
+			return []ruleCollision{{
+				configs: configs,
+				message: fmt.Sprintf("%s excludes %s that %s includes; the exclude rule was dropped",
+					exclude.config, excluded, include.config),
+			}}, nil
+		}
+		var split []*sourcedRule
+		descriptions := make([]string, len(parts))
+		for i, part := range parts {
+			descriptions[i] = describeSelector(part)
+			if i == 0 {
+				continue
+			}
+			rule := *exclude.rule.DeepCopy()
+			rule.ResourceSelector = part
+			split = append(split, &sourcedRule{config: exclude.config, index: exclude.index, rule: rule})
+		}
+		exclude.rule.ResourceSelector = parts[0]
+		return []ruleCollision{{
+			configs: configs,
+			message: fmt.Sprintf("%s excludes %s that %s includes; the exclude rule was narrowed to %s",
+				exclude.config, excluded, include.config, strings.Join(descriptions, " and ")),
+		}}, split
+	}
+	if current.rule.Action != searchv1alpha1.ActionInclude {
+		return nil, nil
+	}
+
+	var collisions []ruleCollision
+	prevFields := map[string]searchv1alpha1.Field{}
+	for _, f := range prev.rule.Fields {
+		prevFields[effectiveFieldName(prev.rule, f)] = f
+	}
+	var fields []searchv1alpha1.Field
+	for _, f := range current.rule.Fields {
+		name := effectiveFieldName(current.rule, f)
+		existing, ok := prevFields[name]
+		if ok && (existing.JSONPath != f.JSONPath || fieldType(existing) != fieldType(f) ||
+			!equality.Semantic.DeepEqual(existing.Transforms, f.Transforms)) {
+			collisions = append(collisions, ruleCollision{
+				configs: configs,
+				message: fmt.Sprintf("field %q is %s in %s and %s in %s; the definition from %s was dropped",
+					name, describeField(existing), prev.config, describeField(f), current.config, current.config),
+			})
+			continue
+		}
+		fields = append(fields, f)
+	}
+	if len(fields) != len(current.rule.Fields) {
+		current.rule.Fields = fields
+	}
+
+	a, b := prev.rule.CollectAnnotations, current.rule.CollectAnnotations
+	if a != nil && b != nil && *a != *b {
+		falseRule := current
+		if !*a {
+			falseRule = prev
+		}
+		collect := true
+		falseRule.rule.CollectAnnotations = &collect
+		collisions = append(collisions, ruleCollision{
+			configs: configs,
+			message: fmt.Sprintf("%s and %s set different collectAnnotations for %s; annotations are collected",
+				prev.config, current.config, describeSelector(current.rule.ResourceSelector)),
+		})
+	}
+	return collisions, nil
+}
+
+// subtractSelector returns the selectors of the resources that exclude selects and include does
+// not: exclude limited to the apiGroups include does not select, and exclude limited to the kinds
+// include does not select. A wildcard can't be narrowed, so exclude loses the part that would
+// need "every kind but", or "every apiGroup but", the ones of include. The label and annotation
+// selectors of include are not subtracted, so the overlap is given up for all of exclude's objects.
+func subtractSelector(exclude, include searchv1alpha1.ResourceSelector) []searchv1alpha1.ResourceSelector {
+	var parts []searchv1alpha1.ResourceSelector
+	if groups := subtractValues(exclude.APIGroups, include.APIGroups); len(groups) > 0 {
+		part := *exclude.DeepCopy()
+		part.APIGroups = groups
+		parts = append(parts, part)
+	}
+	if kinds := subtractValues(exclude.Kinds, include.Kinds); len(kinds) > 0 {
+		part := *exclude.DeepCopy()
+		part.Kinds = kinds
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// subtractValues returns the values of a that are not in b. It returns nothing when b has "*",
+// and when a has "*", which can't be narrowed.
+func subtractValues(a, b []string) []string {
+	if slices.Contains(a, "*") || slices.Contains(b, "*") {
+		return nil
+	}
+	var diff []string
+	for _, v := range a {
+		if !slices.Contains(b, v) {
+			diff = append(diff, v)
+		}
+	}
+	return diff
+}
+
+// collisionMessages returns the messages of the collisions that involve the named config, or all
+// messages when name is empty.
+func collisionMessages(collisions []ruleCollision, name string) []string {
+	var msgs []string
+	for _, c := range collisions {
+		if name == "" || c.configs[0] == name || c.configs[1] == name {
+			msgs = append(msgs, c.message)
+		}
+	}
+	return msgs
+}
+
+// ruleCollisionsCondition returns the RuleCollisions condition for the given collision messages.
+func ruleCollisionsCondition(messages []string) metav1.Condition {
+	newCondition := metav1.Condition{
+		Type:    searchv1alpha1.CollectorConfigConditionRuleCollisions,
+		Status:  metav1.ConditionFalse,
+		Reason:  searchv1alpha1.CollectorConfigReasonNoCollisions,
+		Message: "No collisions with other integration CollectorConfigs.",
+	}
+	if len(messages) > 0 {
+		newCondition.Status = metav1.ConditionTrue
+		newCondition.Reason = searchv1alpha1.CollectorConfigReasonCollisionsResolved
+		newCondition.Message = strings.Join(messages, "; ")
+	}
+	return newCondition
+}
+
+// setRuleCollisionsCondition sets the RuleCollisions condition on a CollectorConfig and writes the
+// status when the condition changed, so the status update does not retrigger the reconcile loop.
+func (r *SearchReconciler) setRuleCollisionsCondition(ctx context.Context,
+	cc *searchv1alpha1.CollectorConfig, messages []string) error {
+	newCondition := ruleCollisionsCondition(messages)
+	existing := apimeta.FindStatusCondition(cc.Status.Conditions, newCondition.Type)
+	if existing != nil && existing.Status == newCondition.Status &&
+		existing.Reason == newCondition.Reason && existing.Message == newCondition.Message {
+		return nil
+	}
+	apimeta.SetStatusCondition(&cc.Status.Conditions, newCondition)
+	return r.Status().Update(ctx, cc)
+}
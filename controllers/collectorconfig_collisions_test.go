@@ -0,0 +1,315 @@
+// Copyright Contributors to the Open Cluster Management project
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var policySelector = searchv1alpha1.ResourceSelector{
+	APIGroups: []string{"policy.open-cluster-management.io"},
+	Kinds:     []string{"Policy"},
+}
+
+func getCollisionCondition(t *testing.T, r *SearchReconciler, name string) *metav1.Condition {
+	t.Helper()
+	cc := &searchv1alpha1.CollectorConfig{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: testNamespace}, cc))
+	return apimeta.FindStatusCondition(cc.Status.Conditions, searchv1alpha1.CollectorConfigConditionRuleCollisions)
+}
+
+func TestCollisions_IncludeWinsOverExclude(t *testing.T) {
+	instance := newSearchInstance()
+	grc := newIntegrationTeamConfig("grc-integration", searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{Action: searchv1alpha1.ActionInclude, ResourceSelector: policySelector},
+		},
+	})
+	kyverno := newIntegrationTeamConfig("kyverno-integration", searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{
+				Action: searchv1alpha1.ActionExclude,
+				ResourceSelector: searchv1alpha1.ResourceSelector{
+					APIGroups: []string{"policy.open-cluster-management.io"}, Kinds: []string{"*"},
+				},
+			},
+		},
+	})
+	argo := newIntegrationTeamConfig("argo-integration", searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{
+				Action:           searchv1alpha1.ActionInclude,
+				ResourceSelector: searchv1alpha1.ResourceSelector{APIGroups: []string{"argoproj.io"}, Kinds: []string{"Application"}},
+			},
+		},
+	})
+	r := setupReconciler(instance, grc, kyverno, argo)
+
+	result, err := r.createOrUpdateMergedCollectorConfig(context.TODO(), instance)
+	assert.Nil(t, err)
+	assert.Nil(t, result)
+
+	merged, err := getMergedConfig(r)
+	assert.Nil(t, err)
+	require.Len(t, merged.Spec.CollectionRules, 2, "kyverno exclude should be dropped")
+	for _, rule := range merged.Spec.CollectionRules {
+		assert.Equal(t, searchv1alpha1.ActionInclude, rule.Action)
+	}
+
+	for _, name := range []string{"grc-integration", "kyverno-integration", mergedCollectorConfigName} {
+		cond := getCollisionCondition(t, r, name)
+		require.NotNil(t, cond, name)
+		assert.Equal(t, metav1.ConditionTrue, cond.Status, name)
+		assert.Equal(t, searchv1alpha1.CollectorConfigReasonCollisionsResolved, cond.Reason, name)
+		assert.Contains(t, cond.Message, "kyverno-integration excludes", name)
+	}
+	cond := getCollisionCondition(t, r, "argo-integration")
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, searchv1alpha1.CollectorConfigReasonNoCollisions, cond.Reason)
+}
+
+func TestCollisions_ExcludeBeforeInclude(t *testing.T) {
+	a := newIntegrationTeamConfig("a-integration", searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{Action: searchv1alpha1.ActionExclude, ResourceSelector: policySelector},
+		},
+	})
+	b := newIntegrationTeamConfig("b-integration", searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{Action: searchv1alpha1.ActionInclude, ResourceSelector: policySelector},
+		},
+	})
+
+	rules, _, collisions := mergeIntegrationRules([]searchv1alpha1.CollectorConfig{*a, *b})
+	require.Len(t, rules, 1)
+	assert.Equal(t, searchv1alpha1.ActionInclude, rules[0].Action)
+	require.Len(t, collisions, 1)
+	assert.Equal(t, [2]string{"a-integration", "b-integration"}, collisions[0].configs)
+}
+
+func TestCollisions_WildcardExcludeNarrowed(t *testing.T) {
+	instance := newSearchInstance()
+	grc := newIntegrationTeamConfig("grc-integration", searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{Action: searchv1alpha1.ActionInclude, ResourceSelector: policySelector},
+		},
+	})
+	// Only the policy.open-cluster-management.io part of the exclude overlaps the include.
+	kyverno := newIntegrationTeamConfig("kyverno-integration", searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{
+				Action: searchv1alpha1.ActionExclude,
+				ResourceSelector: searchv1alpha1.ResourceSelector{
+					APIGroups: []string{"policy.open-cluster-management.io", "kyverno.io"}, Kinds: []string{"*"},
+				},
+			},
+		},
+	})
+	r := setupReconciler(instance, grc, kyverno)
+
+	result, err := r.createOrUpdateMergedCollectorConfig(context.TODO(), instance)
+	assert.Nil(t, err)
+	assert.Nil(t, result)
+
+	merged, err := getMergedConfig(r)
+	require.NoError(t, err)
+	require.Len(t, merged.Spec.CollectionRules, 2)
+	exclude := merged.Spec.CollectionRules[1]
+	assert.Equal(t, searchv1alpha1.ActionExclude, exclude.Action)
+	assert.Equal(t, []string{"kyverno.io"}, exclude.ResourceSelector.APIGroups)
+	assert.Equal(t, []string{"*"}, exclude.ResourceSelector.Kinds)
+	assert.Equal(t, 0, merged.Status.DroppedRules)
+
+	cond := getCollisionCondition(t, r, "kyverno-integration")
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
+	assert.Contains(t, cond.Message, "the exclude rule was narrowed to kinds [*] (apiGroups [kyverno.io])")
+}
+
+func TestCollisions_ExcludeSplit(t *testing.T) {
+	a := newIntegrationTeamConfig("a-integration", searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{
+				Action: searchv1alpha1.ActionExclude,
+				ResourceSelector: searchv1alpha1.ResourceSelector{
+					APIGroups: []string{"policy.open-cluster-management.io", "kyverno.io"},
+					Kinds:     []string{"Policy", "ClusterPolicy"},
+				},
+			},
+		},
+	})
+	b := newIntegrationTeamConfig("b-integration", searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{Action: searchv1alpha1.ActionInclude, ResourceSelector: policySelector},
+		},
+	})
+
+	rules, provenance, collisions := mergeIntegrationRules([]searchv1alpha1.CollectorConfig{*a, *b})
+	require.Len(t, rules, 3)
+	// The exclude keeps everything but policy.open-cluster-management.io Policies.
+	assert.Equal(t, []string{"kyverno.io"}, rules[0].ResourceSelector.APIGroups)
+	assert.Equal(t, []string{"Policy", "ClusterPolicy"}, rules[0].ResourceSelector.Kinds)
+	assert.Equal(t, searchv1alpha1.ActionInclude, rules[1].Action)
+	assert.Equal(t, searchv1alpha1.ActionExclude, rules[2].Action)
+	assert.Equal(t, []string{"policy.open-cluster-management.io", "kyverno.io"}, rules[2].ResourceSelector.APIGroups)
+	assert.Equal(t, []string{"ClusterPolicy"}, rules[2].ResourceSelector.Kinds)
+	assert.Equal(t, []searchv1alpha1.RuleSource{
+		{Source: "a-integration", Index: 0}, {Source: "b-integration", Index: 0}, {Source: "a-integration", Index: 0},
+	}, provenance)
+	require.Len(t, collisions, 1)
+}
+
+func TestCollisions_ConflictingFields(t *testing.T) {
+	a := newIntegrationTeamConfig("a-integration", searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{
+				Action:           searchv1alpha1.ActionInclude,
+				ResourceSelector: policySelector,
+				Fields: []searchv1alpha1.Field{
+					{Name: "severity", JSONPath: "{.spec.severity}"},
+					{Name: "remediation", JSONPath: "{.spec.remediationAction}"},
+				},
+			},
+		},
+	})
+	b := newIntegrationTeamConfig("b-integration", searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{
+				Action:           searchv1alpha1.ActionInclude,
+				ResourceSelector: policySelector,
+				Fields: []searchv1alpha1.Field{
+					// Same definition as a-integration (empty type defaults to string): not a collision.
+					{Name: "severity", JSONPath: "{.spec.severity}", Type: searchv1alpha1.DataTypeString},
+					// Same name, different type: collision.
+					{Name: "remediation", JSONPath: "{.spec.remediationAction}", Type: searchv1alpha1.DataTypeInteger},
+				},
+			},
+			{
+				Action:           searchv1alpha1.ActionInclude,
+				ResourceSelector: policySelector,
+				FieldSuffix:      "b",
+				// The suffix makes this "severity.b", so it does not collide.
+				Fields: []searchv1alpha1.Field{{Name: "severity", JSONPath: "{.status.severity}"}},
+			},
+		},
+	})
+
+	rules, _, collisions := mergeIntegrationRules([]searchv1alpha1.CollectorConfig{*a, *b})
+	require.Len(t, rules, 3)
+	assert.Len(t, rules[0].Fields, 2)
+	require.Len(t, rules[1].Fields, 1)
+	assert.Equal(t, "severity", rules[1].Fields[0].Name)
+	assert.Len(t, rules[2].Fields, 1)
+	require.Len(t, collisions, 1)
+	assert.Contains(t, collisions[0].message, `field "remediation"`)
+
+	// The source configs are not modified.
+	assert.Len(t, b.Spec.CollectionRules[0].Fields, 2)
+}
+
+func TestCollisions_ConflictingFieldTransforms(t *testing.T) {
+	tagField := func(pattern string) []searchv1alpha1.Field {
+		return []searchv1alpha1.Field{{
+			Name:       "tag",
+			JSONPath:   "{.spec.image}",
+			Transforms: []searchv1alpha1.FieldTransform{{Regex: &searchv1alpha1.RegexTransform{Pattern: pattern}}},
+		}}
+	}
+	a := newIntegrationTeamConfig("a-integration", searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{Action: searchv1alpha1.ActionInclude, ResourceSelector: policySelector, Fields: tagField(`:(.+)$`)},
+		},
+	})
+	b := newIntegrationTeamConfig("b-integration", searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{Action: searchv1alpha1.ActionInclude, ResourceSelector: policySelector, Fields: tagField(`@(.+)$`)},
+		},
+	})
+
+	rules, _, collisions := mergeIntegrationRules([]searchv1alpha1.CollectorConfig{*a, *b})
+	require.Len(t, rules, 2)
+	assert.Empty(t, rules[1].Fields)
+	require.Len(t, collisions, 1)
+	assert.Contains(t, collisions[0].message, "with 1 transforms")
+
+	b.Spec.CollectionRules[0].Fields = tagField(`:(.+)$`)
+	_, _, collisions = mergeIntegrationRules([]searchv1alpha1.CollectorConfig{*a, *b})
+	assert.Empty(t, collisions, "identical transforms do not collide")
+}
+
+func TestCollisions_CollectAnnotations(t *testing.T) {
+	yes, no := true, false
+	a := newIntegrationTeamConfig("a-integration", searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{Action: searchv1alpha1.ActionInclude, ResourceSelector: policySelector, CollectAnnotations: &no},
+		},
+	})
+	b := newIntegrationTeamConfig("b-integration", searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{Action: searchv1alpha1.ActionInclude, ResourceSelector: policySelector, CollectAnnotations: &yes},
+		},
+	})
+
+	rules, _, collisions := mergeIntegrationRules([]searchv1alpha1.CollectorConfig{*a, *b})
+	require.Len(t, rules, 2)
+	assert.True(t, *rules[0].CollectAnnotations, "annotations are collected when configs disagree")
+	assert.True(t, *rules[1].CollectAnnotations)
+	require.Len(t, collisions, 1)
+	assert.Contains(t, collisions[0].message, "collectAnnotations")
+	assert.False(t, *a.Spec.CollectionRules[0].CollectAnnotations, "source config must not be modified")
+}
+
+func TestCollisions_SameConfigNotCompared(t *testing.T) {
+	a := newIntegrationTeamConfig("a-integration", searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{Action: searchv1alpha1.ActionInclude, ResourceSelector: policySelector},
+			{Action: searchv1alpha1.ActionExclude, ResourceSelector: policySelector},
+		},
+	})
+
+	rules, _, collisions := mergeIntegrationRules([]searchv1alpha1.CollectorConfig{*a})
+	assert.Len(t, rules, 2)
+	assert.Empty(t, collisions)
+}
+
+func TestCollisions_ConditionClearedWhenResolved(t *testing.T) {
+	instance := newSearchInstance()
+	a := newIntegrationTeamConfig("a-integration", searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{Action: searchv1alpha1.ActionInclude, ResourceSelector: policySelector},
+		},
+	})
+	b := newIntegrationTeamConfig("b-integration", searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{Action: searchv1alpha1.ActionExclude, ResourceSelector: policySelector},
+		},
+	})
+	r := setupReconciler(instance, a, b)
+	ctx := context.TODO()
+
+	_, err := r.createOrUpdateMergedCollectorConfig(ctx, instance)
+	require.NoError(t, err)
+	assert.Equal(t, metav1.ConditionTrue, getCollisionCondition(t, r, mergedCollectorConfigName).Status)
+
+	// Remove the conflicting exclude from b-integration.
+	current := &searchv1alpha1.CollectorConfig{}
+	require.NoError(t, r.Get(ctx, types.NamespacedName{Name: "b-integration", Namespace: testNamespace}, current))
+	current.Spec.CollectionRules = nil
+	require.NoError(t, r.Update(ctx, current))
+
+	_, err = r.createOrUpdateMergedCollectorConfig(ctx, instance)
+	require.NoError(t, err)
+	for _, name := range []string{"a-integration", "b-integration", mergedCollectorConfigName} {
+		cond := getCollisionCondition(t, r, name)
+		require.NotNil(t, cond, name)
+		assert.Equal(t, metav1.ConditionFalse, cond.Status, name)
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"github.com/stolostron/search-v2-operator/pkg/schemacheck"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// discoveryCheckInterval is how often CollectorConfigDiscoveryChecker compares the rules with
+	// the hub's discovery data and CRD schemas.
+	discoveryCheckInterval = 5 * time.Minute
+	// maxListedProblems limits how many problems a condition message lists.
+	maxListedProblems = 10
+)
+
+// CollectorConfigDiscoveryChecker compares the rules of the CollectorConfigs in the Search
+// namespace with the hub's discovery data and CRD schemas (pkg/schemacheck), and sets two
+// conditions:
+//   - VersionsServed, on configs whose rules pin API versions (resourceSelector.versions)
+//   - MatchesHubSchema, on configs with rules
+//
+// A CRD can change without any CollectorConfig changing, so the check runs on a timer (see
+// collectorConfigTimer). Only the hub is checked: a kind that is not served on the hub at all may
+// still exist on managed clusters, so its pinned versions are not flagged.
+type CollectorConfigDiscoveryChecker struct {
+	collectorConfigTimer
+
+	Client  client.Client
+	Checker *schemacheck.Checker
+}
+
+// Start implements manager.Runnable. It checks every discoveryCheckInterval until ctx is done.
+func (c *CollectorConfigDiscoveryChecker) Start(ctx context.Context) error {
+	c.run(ctx, discoveryCheckInterval, "discovery check", c.checkAll)
+	return nil
+}
+
+// checkAll updates the conditions of every CollectorConfig. A condition that no longer applies,
+// because the config has no pinned versions or no rules, is removed.
+func (c *CollectorConfigDiscoveryChecker) checkAll(ctx context.Context) error {
+	configs, err := c.collectorConfigs(ctx, c.Client, "discovery check")
+	if err != nil {
+		return err
+	}
+	var snapshot *schemacheck.Snapshot
+	var discoveryErr error
+	for _, cc := range configs {
+		if len(cc.Spec.CollectionRules) > 0 && snapshot == nil && discoveryErr == nil {
+			snapshot, discoveryErr = c.Checker.Snapshot()
+		}
+		base := cc.DeepCopy()
+		setOrRemoveCondition(&cc.Status.Conditions, searchv1alpha1.CollectorConfigConditionVersionsServed,
+			pinsVersions(cc), func() metav1.Condition { return versionsServedCondition(cc, snapshot, discoveryErr) })
+		setOrRemoveCondition(&cc.Status.Conditions, searchv1alpha1.CollectorConfigConditionMatchesHubSchema,
+			len(cc.Spec.CollectionRules) > 0,
+			func() metav1.Condition { return hubSchemaCondition(ctx, cc, snapshot, discoveryErr) })
+		if equality.Semantic.DeepEqual(base.Status, cc.Status) {
+			continue
+		}
+		if err := c.Client.Status().Patch(ctx, cc, client.MergeFrom(base)); err != nil {
+			log.Error(err, "Could not update CollectorConfig discovery conditions", "name", cc.Name)
+		}
+	}
+	return nil
+}
+
+// setOrRemoveCondition sets the condition built by condition when set is true, and removes the
+// condition type otherwise.
+func setOrRemoveCondition(conditions *[]metav1.Condition, conditionType string, set bool,
+	condition func() metav1.Condition) {
+	if set {
+		apimeta.SetStatusCondition(conditions, condition())
+	} else {
+		apimeta.RemoveStatusCondition(conditions, conditionType)
+	}
+}
+
+func pinsVersions(cc *searchv1alpha1.CollectorConfig) bool {
+	for _, rule := range cc.Spec.CollectionRules {
+		if len(rule.ResourceSelector.Versions) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// discoveryFailedCondition is the condition of the given type when discovery failed.
+func discoveryFailedCondition(conditionType string, cc *searchv1alpha1.CollectorConfig, err error) metav1.Condition {
+	return metav1.Condition{
+		Type:               conditionType,
+		Status:             metav1.ConditionUnknown,
+		ObservedGeneration: cc.Generation,
+		Reason:             searchv1alpha1.CollectorConfigReasonDiscoveryFailed,
+		Message:            fmt.Sprintf("Could not discover the resources served on the hub: %v", err),
+	}
+}
+
+// versionsServedCondition reports the rules of cc that pin a version the hub no longer serves
+// for one of their kinds. Wildcard apiGroups and kinds are not checked.
+func versionsServedCondition(cc *searchv1alpha1.CollectorConfig, snapshot *schemacheck.Snapshot,
+	discoveryErr error) metav1.Condition {
+	if discoveryErr != nil {
+		return discoveryFailedCondition(searchv1alpha1.CollectorConfigConditionVersionsServed, cc, discoveryErr)
+	}
+	condition := metav1.Condition{
+		Type:               searchv1alpha1.CollectorConfigConditionVersionsServed,
+		ObservedGeneration: cc.Generation,
+	}
+	var messages []string
+	for i, rule := range cc.Spec.CollectionRules {
+		sel := rule.ResourceSelector
+		for _, group := range sel.APIGroups {
+			for _, kind := range sel.Kinds {
+				served, ok := snapshot.ServedVersions(group, kind)
+				if group == "*" || kind == "*" || !ok {
+					continue
+				}
+				for _, version := range sel.Versions {
+					if !slices.Contains(served, version) {
+						messages = append(messages, fmt.Sprintf("rule %d: %s is not served in version %s (served: %s)",
+							i, qualifiedKind(kind, group), version, strings.Join(served, ", ")))
+					}
+				}
+			}
+		}
+	}
+	if len(messages) == 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = searchv1alpha1.CollectorConfigReasonVersionsServed
+		condition.Message = "All pinned API versions are served."
+		return condition
+	}
+	condition.Status = metav1.ConditionFalse
+	condition.Reason = searchv1alpha1.CollectorConfigReasonVersionsNotServed
+	condition.Message = strings.Join(messages, "; ")
+	return condition
+}
+
+// hubSchemaCondition reports the rules of cc that name apiGroups or kinds the hub does not serve,
+// or custom fields that do not match the CRD schema.
+func hubSchemaCondition(ctx context.Context, cc *searchv1alpha1.CollectorConfig, snapshot *schemacheck.Snapshot,
+	discoveryErr error) metav1.Condition {
+	if discoveryErr != nil {
+		return discoveryFailedCondition(searchv1alpha1.CollectorConfigConditionMatchesHubSchema, cc, discoveryErr)
+	}
+	condition := metav1.Condition{
+		Type:               searchv1alpha1.CollectorConfigConditionMatchesHubSchema,
+		ObservedGeneration: cc.Generation,
+	}
+	var messages []string
+	for i, rule := range cc.Spec.CollectionRules {
+		for _, p := range snapshot.Check(ctx, searchv1alpha1.SchemaCheckRule(rule)) {
+			messages = append(messages, fmt.Sprintf("rule %d %s: %s", i, p.Field, p.Message))
+		}
+	}
+	if len(messages) == 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = searchv1alpha1.CollectorConfigReasonSchemaMatched
+		condition.Message = "All rules match the resources and schemas served on the hub."
+		return condition
+	}
+	total := len(messages)
+	if total > maxListedProblems {
+		messages = append(messages[:maxListedProblems:maxListedProblems], "...")
+	}
+	condition.Status = metav1.ConditionFalse
+	condition.Reason = searchv1alpha1.CollectorConfigReasonSchemaMismatch
+	condition.Message = fmt.Sprintf("%d problems on the hub (the resources may exist only on managed clusters): %s",
+		total, strings.Join(messages, "; "))
+	return condition
+}
@@ -0,0 +1,205 @@
+// Copyright Contributors to the Open Cluster Management project
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"github.com/stolostron/search-v2-operator/pkg/schemacheck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+// newTestVersionDiscovery serves MyApp.example.com in v1beta1 and v1, no longer in v1alpha1.
+func newTestVersionDiscovery() *fakediscovery.FakeDiscovery {
+	return &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: []*metav1.APIResourceList{
+		{GroupVersion: "example.com/v1beta1", APIResources: []metav1.APIResource{{Name: "myapps", Kind: "MyApp"}}},
+		{GroupVersion: "example.com/v1", APIResources: []metav1.APIResource{
+			{Name: "myapps", Kind: "MyApp"},
+			{Name: "myapps/status", Kind: "MyApp"},
+		}},
+	}}}
+}
+
+// newTestDiscoveryChecker checks against newTestVersionDiscovery and a MyApp CRD whose v1 schema
+// defines spec.replicas as an integer.
+func newTestDiscoveryChecker(disc *fakediscovery.FakeDiscovery) *schemacheck.Checker {
+	crd := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]interface{}{"name": "myapps.example.com"},
+		"spec": map[string]interface{}{
+			"group": "example.com",
+			"names": map[string]interface{}{"plural": "myapps", "kind": "MyApp"},
+			"versions": []interface{}{map[string]interface{}{
+				"name": "v1", "served": true, "storage": true,
+				"schema": map[string]interface{}{"openAPIV3Schema": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{"spec": map[string]interface{}{
+						"type":       "object",
+						"properties": map[string]interface{}{"replicas": map[string]interface{}{"type": "integer"}},
+					}},
+				}},
+			}},
+		},
+	}}
+	dyn := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}: "CustomResourceDefinitionList",
+	}, crd)
+	return &schemacheck.Checker{Discovery: disc, Dynamic: dyn}
+}
+
+func pinnedRule(kind string, versions ...string) searchv1alpha1.CollectionRule {
+	return searchv1alpha1.CollectionRule{
+		Action: searchv1alpha1.ActionInclude,
+		ResourceSelector: searchv1alpha1.ResourceSelector{
+			APIGroups: []string{"example.com"}, Kinds: []string{kind}, Versions: versions,
+		},
+	}
+}
+
+func getVersionsServed(t *testing.T, r *SearchReconciler, name string) *metav1.Condition {
+	t.Helper()
+	cc := &searchv1alpha1.CollectorConfig{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: testNamespace}, cc))
+	return apimeta.FindStatusCondition(cc.Status.Conditions, searchv1alpha1.CollectorConfigConditionVersionsServed)
+}
+
+func getMatchesHubSchema(t *testing.T, r *SearchReconciler, name string) *metav1.Condition {
+	t.Helper()
+	cc := &searchv1alpha1.CollectorConfig{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: testNamespace}, cc))
+	return apimeta.FindStatusCondition(cc.Status.Conditions, searchv1alpha1.CollectorConfigConditionMatchesHubSchema)
+}
+
+func TestCollectorConfigDiscoveryChecker(t *testing.T) {
+	userCC := newCollectorConfig(userCollectorConfigName, searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			pinnedRule("MyApp", "v1beta1"),
+			pinnedRule("MyApp", "v1alpha1"),
+			// Not on the hub; it may be served on managed clusters.
+			pinnedRule("EdgeApp", "v1alpha1"),
+		},
+	})
+	servedCC := newCollectorConfig("served", searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{pinnedRule("MyApp", "v1")},
+	})
+	unpinned := newCollectorConfig("unpinned", searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{pinnedRule("MyApp")},
+	})
+	r := setupReconciler(userCC, servedCC, unpinned)
+	c := &CollectorConfigDiscoveryChecker{Client: r.Client, Checker: newTestDiscoveryChecker(newTestVersionDiscovery()),
+		collectorConfigTimer: collectorConfigTimer{Namespace: testNamespace}}
+
+	require.NoError(t, c.checkAll(context.TODO()))
+
+	cond := getVersionsServed(t, r, userCollectorConfigName)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, searchv1alpha1.CollectorConfigReasonVersionsNotServed, cond.Reason)
+	assert.Equal(t, "rule 1: MyApp.example.com is not served in version v1alpha1 (served: v1, v1beta1)", cond.Message)
+
+	cond = getVersionsServed(t, r, "served")
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
+
+	assert.Nil(t, getVersionsServed(t, r, "unpinned"))
+}
+
+func TestCollectorConfigDiscoveryChecker_RemovesConditionWhenUnpinned(t *testing.T) {
+	userCC := newCollectorConfig(userCollectorConfigName, searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{pinnedRule("MyApp")},
+	})
+	userCC.Status.Conditions = []metav1.Condition{{
+		Type: searchv1alpha1.CollectorConfigConditionVersionsServed, Status: metav1.ConditionFalse,
+		Reason: searchv1alpha1.CollectorConfigReasonVersionsNotServed, LastTransitionTime: metav1.Now(),
+	}}
+	r := setupReconciler(userCC)
+	c := &CollectorConfigDiscoveryChecker{Client: r.Client, Checker: newTestDiscoveryChecker(newTestVersionDiscovery()),
+		collectorConfigTimer: collectorConfigTimer{Namespace: testNamespace}}
+
+	require.NoError(t, c.checkAll(context.TODO()))
+
+	assert.Nil(t, getVersionsServed(t, r, userCollectorConfigName))
+}
+
+func TestCollectorConfigDiscoveryChecker_DiscoveryFailure(t *testing.T) {
+	userCC := newCollectorConfig(userCollectorConfigName, searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{pinnedRule("MyApp", "v1")},
+	})
+	r := setupReconciler(userCC)
+	disc := newTestVersionDiscovery()
+	disc.PrependReactor("get", "group", func(clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("discovery unavailable")
+	})
+	c := &CollectorConfigDiscoveryChecker{Client: r.Client, Checker: newTestDiscoveryChecker(disc),
+		collectorConfigTimer: collectorConfigTimer{Namespace: testNamespace}}
+
+	require.NoError(t, c.checkAll(context.TODO()))
+
+	cond := getVersionsServed(t, r, userCollectorConfigName)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionUnknown, cond.Status)
+	assert.Equal(t, searchv1alpha1.CollectorConfigReasonDiscoveryFailed, cond.Reason)
+
+	cond = getMatchesHubSchema(t, r, userCollectorConfigName)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionUnknown, cond.Status)
+	assert.Equal(t, searchv1alpha1.CollectorConfigReasonDiscoveryFailed, cond.Reason)
+}
+
+func TestCollectorConfigDiscoveryChecker_MatchesHubSchema(t *testing.T) {
+	fieldRule := func(jsonPath string, dataType searchv1alpha1.DataType) searchv1alpha1.CollectionRule {
+		rule := pinnedRule("MyApp", "v1")
+		rule.Fields = []searchv1alpha1.Field{{Name: "f", JSONPath: jsonPath, Type: dataType}}
+		return rule
+	}
+	userCC := newCollectorConfig(userCollectorConfigName, searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			fieldRule("{.spec.replicas}", searchv1alpha1.DataTypeInteger),
+			fieldRule("{.spec.replica}", searchv1alpha1.DataTypeInteger),
+			fieldRule("{.spec.replicas}", searchv1alpha1.DataTypeBoolean),
+			pinnedRule("MyAp"),
+		},
+	})
+	matching := newCollectorConfig("matching", searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{fieldRule("{.spec.replicas}", searchv1alpha1.DataTypeInteger)},
+	})
+	noRules := newCollectorConfig("no-rules", searchv1alpha1.CollectorConfigSpec{})
+	noRules.Status.Conditions = []metav1.Condition{{
+		Type: searchv1alpha1.CollectorConfigConditionMatchesHubSchema, Status: metav1.ConditionFalse,
+		Reason: searchv1alpha1.CollectorConfigReasonSchemaMismatch, LastTransitionTime: metav1.Now(),
+	}}
+	r := setupReconciler(userCC, matching, noRules)
+	c := &CollectorConfigDiscoveryChecker{Client: r.Client, Checker: newTestDiscoveryChecker(newTestVersionDiscovery()),
+		collectorConfigTimer: collectorConfigTimer{Namespace: testNamespace}}
+
+	require.NoError(t, c.checkAll(context.TODO()))
+
+	cond := getMatchesHubSchema(t, r, userCollectorConfigName)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, searchv1alpha1.CollectorConfigReasonSchemaMismatch, cond.Reason)
+	assert.Contains(t, cond.Message, "3 problems")
+	assert.Contains(t, cond.Message, "rule 1 fields[0].jsonPath: {.spec.replica} does not resolve")
+	assert.Contains(t, cond.Message, "rule 2 fields[0].type:")
+	assert.Contains(t, cond.Message, `rule 3 resourceSelector.kinds: kind "MyAp" is not served`)
+
+	cond = getMatchesHubSchema(t, r, "matching")
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
+	assert.Equal(t, searchv1alpha1.CollectorConfigReasonSchemaMatched, cond.Reason)
+
+	assert.Nil(t, getMatchesHubSchema(t, r, "no-rules"))
+}
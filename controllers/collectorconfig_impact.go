@@ -0,0 +1,113 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"github.com/stolostron/search-v2-operator/pkg/impact"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// impactCheckInterval is how often CollectorConfigImpactEstimator looks for CollectorConfigs
+	// whose estimate is missing or out of date.
+	impactCheckInterval = 10 * time.Minute
+	// impactRefreshInterval is how old an estimate can get before the objects are counted again,
+	// even if the CollectorConfig did not change.
+	impactRefreshInterval = time.Hour
+	// maxImpactErrorLength truncates the error recorded on a rule, which lists every resource
+	// type that could not be counted.
+	maxImpactErrorLength = 512
+)
+
+// CollectorConfigImpactEstimator writes status.impact on the CollectorConfigs in the Search
+// namespace: the number of hub objects, and their approximate size, matched by each include rule.
+// It counts with discovery and paged list calls (pkg/impact), so it runs on a timer instead of in
+// the reconcile loop (see collectorConfigTimer).
+type CollectorConfigImpactEstimator struct {
+	collectorConfigTimer
+
+	Client    client.Client
+	Estimator *impact.Estimator
+
+	// Refresh overrides impactRefreshInterval when non-zero.
+	Refresh time.Duration
+}
+
+// Start implements manager.Runnable. It estimates every impactCheckInterval until ctx is done.
+func (e *CollectorConfigImpactEstimator) Start(ctx context.Context) error {
+	e.run(ctx, impactCheckInterval, "impact estimate", e.estimateAll)
+	return nil
+}
+
+// estimateAll refreshes the estimate of every CollectorConfig whose estimate is missing, older
+// than its generation, or older than the refresh interval.
+func (e *CollectorConfigImpactEstimator) estimateAll(ctx context.Context) error {
+	configs, err := e.collectorConfigs(ctx, e.Client, "impact estimate")
+	if err != nil {
+		return err
+	}
+	var due []*searchv1alpha1.CollectorConfig
+	for _, cc := range configs {
+		if e.isDue(cc) {
+			due = append(due, cc)
+		}
+	}
+	if len(due) == 0 {
+		return nil
+	}
+
+	// merged-collector-config repeats the rules of its sources; the cache counts each rule once.
+	estimator := e.Estimator.WithCache()
+	resources, err := estimator.Resources()
+	if err != nil {
+		return err
+	}
+	for _, cc := range due {
+		base := cc.DeepCopy()
+		cc.Status.Impact = estimateImpact(ctx, estimator, resources, cc)
+		if err := e.Client.Status().Patch(ctx, cc, client.MergeFrom(base)); err != nil {
+			log.Error(err, "Could not update CollectorConfig impact estimate", "name", cc.Name)
+		}
+	}
+	return nil
+}
+
+// isDue reports whether the estimate of cc should be computed again.
+func (e *CollectorConfigImpactEstimator) isDue(cc *searchv1alpha1.CollectorConfig) bool {
+	refresh := e.Refresh
+	if refresh <= 0 {
+		refresh = impactRefreshInterval
+	}
+	est := cc.Status.Impact
+	return est == nil || est.ObservedGeneration != cc.Generation || est.LastEstimateTime == nil ||
+		time.Since(est.LastEstimateTime.Time) >= refresh
+}
+
+// estimateImpact counts the objects matched by each include rule of cc.
+func estimateImpact(ctx context.Context, estimator *impact.Estimator, resources []impact.Resource,
+	cc *searchv1alpha1.CollectorConfig) *searchv1alpha1.ImpactEstimate {
+	now := metav1.Now()
+	result := &searchv1alpha1.ImpactEstimate{ObservedGeneration: cc.Generation, LastEstimateTime: &now}
+	for i, rule := range cc.Spec.CollectionRules {
+		if rule.Action != searchv1alpha1.ActionInclude {
+			continue
+		}
+		est, err := estimator.Estimate(ctx, resources, searchv1alpha1.ImpactSelector(rule.ResourceSelector))
+		ruleImpact := searchv1alpha1.RuleImpact{Index: i, Objects: est.Objects, Bytes: est.Bytes, Partial: est.Partial}
+		if err != nil {
+			ruleImpact.Error = err.Error()
+			if len(ruleImpact.Error) > maxImpactErrorLength {
+				ruleImpact.Error = ruleImpact.Error[:maxImpactErrorLength] + "..."
+			}
+		}
+		result.Rules = append(result.Rules, ruleImpact)
+		result.Objects += est.Objects
+		result.Bytes += est.Bytes
+	}
+	return result
+}
@@ -0,0 +1,134 @@
+// Copyright Contributors to the Open Cluster Management project
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"github.com/stolostron/search-v2-operator/pkg/impact"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+// newTestImpactEstimator returns an estimator for a hub with two ConfigMaps and one Secret.
+func newTestImpactEstimator() (*impact.Estimator, *fakedynamic.FakeDynamicClient) {
+	var objs []runtime.Object
+	for _, o := range []struct{ kind, name string }{{"ConfigMap", "a"}, {"ConfigMap", "b"}, {"Secret", "c"}} {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("v1")
+		u.SetKind(o.kind)
+		u.SetNamespace("default")
+		u.SetName(o.name)
+		objs = append(objs, u)
+	}
+	dyn := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		{Version: "v1", Resource: "configmaps"}: "ConfigMapList",
+		{Version: "v1", Resource: "secrets"}:    "SecretList",
+	}, objs...)
+	disc := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{
+			{Name: "configmaps", Kind: "ConfigMap", Namespaced: true, Verbs: []string{"list"}},
+			{Name: "secrets", Kind: "Secret", Namespaced: true, Verbs: []string{"list"}},
+		}},
+	}}}
+	return &impact.Estimator{Discovery: disc, Dynamic: dyn}, dyn
+}
+
+func listCount(dyn *fakedynamic.FakeDynamicClient) int {
+	n := 0
+	for _, a := range dyn.Actions() {
+		if a.GetVerb() == "list" {
+			n++
+		}
+	}
+	return n
+}
+
+func TestCollectorConfigImpactEstimator_WritesStatus(t *testing.T) {
+	userCC := newCollectorConfig(userCollectorConfigName, searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{Action: searchv1alpha1.ActionInclude, ResourceSelector: searchv1alpha1.ResourceSelector{
+				APIGroups: []string{""}, Kinds: []string{"ConfigMap"}}},
+			{Action: searchv1alpha1.ActionExclude, ResourceSelector: searchv1alpha1.ResourceSelector{
+				APIGroups: []string{""}, Kinds: []string{"Secret"}}},
+			{Action: searchv1alpha1.ActionInclude, ResourceSelector: searchv1alpha1.ResourceSelector{
+				APIGroups: []string{"*"}, Kinds: []string{"*"}}},
+		},
+	})
+	clusterCC := newCollectorConfig(mergedCollectorConfigName, searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: userCC.Spec.CollectionRules,
+	})
+	clusterCC.Labels = map[string]string{searchv1alpha1.IntegrationTeamLabel: searchv1alpha1.ClusterConfigLabelValue}
+	r := setupReconciler(userCC, clusterCC)
+	estimator, dyn := newTestImpactEstimator()
+	e := &CollectorConfigImpactEstimator{Client: r.Client, Estimator: estimator,
+		collectorConfigTimer: collectorConfigTimer{Namespace: testNamespace}}
+
+	require.NoError(t, e.estimateAll(context.TODO()))
+
+	cc := &searchv1alpha1.CollectorConfig{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: userCollectorConfigName, Namespace: testNamespace}, cc))
+	require.NotNil(t, cc.Status.Impact)
+	assert.NotNil(t, cc.Status.Impact.LastEstimateTime)
+	assert.Equal(t, int64(5), cc.Status.Impact.Objects, "objects are counted once per include rule")
+	assert.Positive(t, cc.Status.Impact.Bytes)
+	require.Len(t, cc.Status.Impact.Rules, 2, "exclude rules are not estimated")
+	assert.Equal(t, 0, cc.Status.Impact.Rules[0].Index)
+	assert.Equal(t, int64(2), cc.Status.Impact.Rules[0].Objects)
+	assert.Equal(t, 2, cc.Status.Impact.Rules[1].Index)
+	assert.Equal(t, int64(3), cc.Status.Impact.Rules[1].Objects)
+	assert.Equal(t, 2, listCount(dyn), "the ConfigMap count is reused across rules")
+
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: mergedCollectorConfigName, Namespace: testNamespace}, cc))
+	assert.Nil(t, cc.Status.Impact, "per-cluster configs are not estimated")
+
+	// A current estimate is not computed again.
+	require.NoError(t, e.estimateAll(context.TODO()))
+	assert.Equal(t, 2, listCount(dyn))
+}
+
+func TestCollectorConfigImpactEstimator_IsDue(t *testing.T) {
+	e := &CollectorConfigImpactEstimator{}
+	cc := newCollectorConfig(userCollectorConfigName, searchv1alpha1.CollectorConfigSpec{})
+	cc.Generation = 2
+	assert.True(t, e.isDue(cc), "no estimate yet")
+
+	now := metav1.Now()
+	cc.Status.Impact = &searchv1alpha1.ImpactEstimate{ObservedGeneration: 2, LastEstimateTime: &now}
+	assert.False(t, e.isDue(cc))
+
+	cc.Generation = 3
+	assert.True(t, e.isDue(cc), "the spec changed")
+
+	cc.Generation = 2
+	old := metav1.NewTime(now.Add(-2 * impactRefreshInterval))
+	cc.Status.Impact.LastEstimateTime = &old
+	assert.True(t, e.isDue(cc), "the estimate is stale")
+}
+
+func TestCollectorConfigImpactEstimator_SkipsWhenPaused(t *testing.T) {
+	instance := newSearchInstance()
+	instance.Annotations = map[string]string{AnnotationSearchPause: "true"}
+	r := setupReconciler(instance, newCollectorConfig(userCollectorConfigName, searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{{Action: searchv1alpha1.ActionInclude,
+			ResourceSelector: searchv1alpha1.ResourceSelector{APIGroups: []string{""}, Kinds: []string{"ConfigMap"}}}},
+	}))
+	estimator, dyn := newTestImpactEstimator()
+	e := &CollectorConfigImpactEstimator{Client: r.Client, Estimator: estimator}
+
+	require.NoError(t, e.estimateAll(context.TODO()))
+	assert.Zero(t, listCount(dyn))
+}
+
+func TestCollectorConfigImpactEstimator_NeedsLeaderElection(t *testing.T) {
+	assert.True(t, (&CollectorConfigImpactEstimator{}).NeedLeaderElection())
+}
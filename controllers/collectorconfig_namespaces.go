@@ -0,0 +1,128 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+)
+
+// mergeCollectNamespaces merges the collectNamespaces of the integration configs and
+// user-collector-config (nil when there is none):
+//   - required namespaces of all configs add up
+//   - the namespace selector of user-collector-config takes precedence; without one, the first
+//     integration config with a selector provides it
+//   - the cluster overrides of user-collector-config come first, then those of the integration
+//     configs, so a user override wins for the clusters it selects
+//
+// Exclude patterns that match a required namespace are dropped. It returns a message for each
+// pattern dropped from user-collector-config. What it drops from integration configs is only
+// logged at V(2), since every merge drops it again.
+func mergeCollectNamespaces(teamConfigs []searchv1alpha1.CollectorConfig,
+	userCC *searchv1alpha1.CollectorConfig) (*searchv1alpha1.CollectNamespaces, []string) {
+	sources := make([]*searchv1alpha1.CollectorConfig, 0, len(teamConfigs)+1)
+	if userCC != nil {
+		sources = append(sources, userCC)
+	}
+	for i := range teamConfigs {
+		sources = append(sources, &teamConfigs[i])
+	}
+
+	merged := &searchv1alpha1.CollectNamespaces{}
+	requiredBy := map[string]string{}
+	selectorOwner := ""
+	var overrideOwners []string
+	for _, cc := range sources {
+		cn := cc.Spec.CollectNamespaces
+		if cn == nil {
+			continue
+		}
+		for _, ns := range cn.Required {
+			if _, ok := requiredBy[ns]; !ok {
+				requiredBy[ns] = cc.Name
+				merged.Required = append(merged.Required, ns)
+			}
+		}
+		if merged.NamespaceSelector == nil && cn.NamespaceSelector != nil {
+			merged.NamespaceSelector = cn.NamespaceSelector.DeepCopy()
+			selectorOwner = cc.Name
+		} else if cn.NamespaceSelector != nil {
+			log.V(2).Info("Ignoring CollectorConfig namespace selector — another config takes precedence",
+				"name", cc.Name, "selector", selectorOwner)
+		}
+		for _, override := range cn.ClusterOverrides {
+			override = *override.DeepCopy()
+			if ref := override.ClusterSelector.PlacementRef; ref != nil && ref.Namespace == "" {
+				ref.Namespace = cc.Namespace
+			}
+			merged.ClusterOverrides = append(merged.ClusterOverrides, override)
+			overrideOwners = append(overrideOwners, cc.Name)
+		}
+	}
+	if merged.NamespaceSelector == nil && len(merged.Required) == 0 && len(merged.ClusterOverrides) == 0 {
+		return nil, nil
+	}
+
+	var skipped []string
+	dropExcludes := func(sel *searchv1alpha1.NamespaceSelector, owner string) {
+		if sel == nil {
+			return
+		}
+		var kept []string
+		for _, pattern := range sel.Exclude {
+			excluded := searchv1alpha1.RequiredNamespacesExcluded(pattern, merged.Required)
+			if len(excluded) == 0 {
+				kept = append(kept, pattern)
+				continue
+			}
+			var reasons []string
+			for _, ns := range excluded {
+				reasons = append(reasons, ns+" is required by "+requiredBy[ns])
+			}
+			msg := fmt.Sprintf("namespace exclude %q was not applied — %s", pattern, strings.Join(reasons, ", "))
+			if userCC != nil && owner == userCC.Name {
+				skipped = append(skipped, msg)
+			} else {
+				log.V(2).Info("Skipping CollectorConfig namespace exclude", "name", owner, "detail", msg)
+			}
+		}
+		sel.Exclude = kept
+	}
+	dropExcludes(merged.NamespaceSelector, selectorOwner)
+	for i := range merged.ClusterOverrides {
+		dropExcludes(&merged.ClusterOverrides[i].NamespaceSelector, overrideOwners[i])
+	}
+	return merged, skipped
+}
+
+// clusterCollectNamespaces returns the collectNamespaces for one managed cluster: the namespace
+// selector of the first cluster override that selects the cluster, or the merged selector, and
+// the required namespaces.
+func clusterCollectNamespaces(ctx context.Context, matcher *clusterMatcher,
+	cn *searchv1alpha1.CollectNamespaces, mc *clusterv1.ManagedCluster) (*searchv1alpha1.CollectNamespaces, error) {
+	if cn == nil {
+		return nil, nil
+	}
+	result := &searchv1alpha1.CollectNamespaces{
+		Required: append([]string(nil), cn.Required...),
+	}
+	if cn.NamespaceSelector != nil {
+		result.NamespaceSelector = cn.NamespaceSelector.DeepCopy()
+	}
+	for i := range cn.ClusterOverrides {
+		override := &cn.ClusterOverrides[i]
+		selected, err := matcher.matches(ctx, &override.ClusterSelector, mc)
+		if err != nil {
+			return nil, err
+		}
+		if selected {
+			result.NamespaceSelector = override.NamespaceSelector.DeepCopy()
+			break
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,108 @@
+// Copyright Contributors to the Open Cluster Management project
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const clusterSetLabel = "cluster.open-cluster-management.io/clusterset"
+
+// Required namespaces add up, the user selector takes precedence, and user excludes of a
+// required namespace are dropped and reported.
+func TestMerge_CollectNamespaces(t *testing.T) {
+	instance := newSearchInstance()
+	cnv := newIntegrationTeamConfig("cnv-integration", searchv1alpha1.CollectorConfigSpec{
+		CollectNamespaces: &searchv1alpha1.CollectNamespaces{
+			NamespaceSelector: &searchv1alpha1.NamespaceSelector{Include: []string{"openshift-*"}},
+			Required:          []string{"openshift-cnv"},
+		},
+	})
+	grc := newIntegrationTeamConfig("grc-integration", searchv1alpha1.CollectorConfigSpec{
+		CollectNamespaces: &searchv1alpha1.CollectNamespaces{Required: []string{"open-cluster-management-policies"}},
+	})
+	userCC := newCollectorConfig(userCollectorConfigName, searchv1alpha1.CollectorConfigSpec{
+		CollectNamespaces: &searchv1alpha1.CollectNamespaces{
+			NamespaceSelector: &searchv1alpha1.NamespaceSelector{Exclude: []string{"openshift-*", "kube-*"}},
+		},
+	})
+	r := setupReconciler(instance, cnv, grc, userCC)
+
+	_, err := r.createOrUpdateMergedCollectorConfig(context.TODO(), instance)
+	require.NoError(t, err)
+
+	merged, err := getMergedConfig(r)
+	require.NoError(t, err)
+	assert.Equal(t, &searchv1alpha1.CollectNamespaces{
+		NamespaceSelector: &searchv1alpha1.NamespaceSelector{Exclude: []string{"kube-*"}},
+		Required:          []string{"openshift-cnv", "open-cluster-management-policies"},
+	}, merged.Spec.CollectNamespaces)
+
+	updated := &searchv1alpha1.CollectorConfig{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: userCollectorConfigName, Namespace: testNamespace}, updated))
+	cond := apimeta.FindStatusCondition(updated.Status.Conditions, searchv1alpha1.CollectorConfigConditionApplied)
+	require.NotNil(t, cond)
+	assert.Contains(t, cond.Message, `namespace exclude "openshift-*" was not applied — openshift-cnv is required by cnv-integration`)
+}
+
+// Without user-collector-config, integration configs provide the selector and required namespaces.
+func TestMerge_CollectNamespacesWithoutUserConfig(t *testing.T) {
+	instance := newSearchInstance()
+	cnv := newIntegrationTeamConfig("cnv-integration", searchv1alpha1.CollectorConfigSpec{
+		CollectNamespaces: &searchv1alpha1.CollectNamespaces{Required: []string{"openshift-cnv"}},
+	})
+	r := setupReconciler(instance, cnv)
+
+	_, err := r.createOrUpdateMergedCollectorConfig(context.TODO(), instance)
+	require.NoError(t, err)
+
+	merged, err := getMergedConfig(r)
+	require.NoError(t, err)
+	assert.Equal(t, &searchv1alpha1.CollectNamespaces{Required: []string{"openshift-cnv"}},
+		merged.Spec.CollectNamespaces)
+}
+
+// A cluster override replaces the namespace selector on the clusters of a cluster set; the
+// required namespaces apply everywhere.
+func TestClusterConfigs_NamespaceOverrides(t *testing.T) {
+	instance := newSearchInstance()
+	cnv := newIntegrationTeamConfig("cnv-integration", searchv1alpha1.CollectorConfigSpec{
+		CollectNamespaces: &searchv1alpha1.CollectNamespaces{Required: []string{"openshift-cnv"}},
+	})
+	userCC := newCollectorConfig(userCollectorConfigName, searchv1alpha1.CollectorConfigSpec{
+		CollectNamespaces: &searchv1alpha1.CollectNamespaces{
+			NamespaceSelector: &searchv1alpha1.NamespaceSelector{Exclude: []string{"kube-*"}},
+			ClusterOverrides: []searchv1alpha1.NamespaceSelectorOverride{{
+				ClusterSelector: searchv1alpha1.ClusterSelector{LabelSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{clusterSetLabel: "edge"}}},
+				NamespaceSelector: searchv1alpha1.NamespaceSelector{Include: []string{"edge-*"}, Exclude: []string{"openshift-cnv"}},
+			}},
+		},
+	})
+	r := setupClusterReconciler(t, instance, cnv, userCC,
+		newManagedCluster("edge-1", map[string]string{clusterSetLabel: "edge"}), newNamespace("edge-1"),
+		newManagedCluster("core-1", map[string]string{clusterSetLabel: "default"}), newNamespace("core-1"))
+
+	mergeAndScope(t, r, instance)
+
+	edge, err := getClusterConfig(t, r, "edge-1")
+	require.NoError(t, err)
+	assert.Equal(t, &searchv1alpha1.CollectNamespaces{
+		NamespaceSelector: &searchv1alpha1.NamespaceSelector{Include: []string{"edge-*"}},
+		Required:          []string{"openshift-cnv"},
+	}, edge.Spec.CollectNamespaces)
+
+	core, err := getClusterConfig(t, r, "core-1")
+	require.NoError(t, err)
+	assert.Equal(t, &searchv1alpha1.CollectNamespaces{
+		NamespaceSelector: &searchv1alpha1.NamespaceSelector{Exclude: []string{"kube-*"}},
+		Required:          []string{"openshift-cnv"},
+	}, core.Spec.CollectNamespaces)
+}
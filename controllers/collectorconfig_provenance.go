@@ -0,0 +1,132 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// maxListedCollectors limits how many lagging collectors are named in the ObservedByCollectors message.
+const maxListedCollectors = 10
+
+// mergeProvenance records where the rules and settings of merged-collector-config came from.
+type mergeProvenance struct {
+	sources      []searchv1alpha1.CollectorConfigSource
+	ruleSources  []searchv1alpha1.RuleSource
+	droppedRules int
+	collisions   []ruleCollision
+	edgeTypes    []searchv1alpha1.EdgeType
+}
+
+// addSource records a CollectorConfig that was merged, with the resourceVersion it was read at.
+func (p *mergeProvenance) addSource(cc *searchv1alpha1.CollectorConfig) {
+	p.sources = append(p.sources, searchv1alpha1.CollectorConfigSource{
+		Name:            cc.Name,
+		ResourceVersion: cc.ResourceVersion,
+	})
+}
+
+// provenanceAnnotation renders the per-rule provenance for the AnnotationMergeProvenance annotation.
+func provenanceAnnotation(ruleSources []searchv1alpha1.RuleSource) string {
+	if ruleSources == nil {
+		ruleSources = []searchv1alpha1.RuleSource{}
+	}
+	data, err := json.Marshal(ruleSources)
+	if err != nil {
+		// RuleSource only holds a string and an int, so this cannot fail.
+		log.Error(err, "Could not encode merge provenance")
+		return ""
+	}
+	return string(data)
+}
+
+// observedByCollectorsCondition compares the generations acknowledged by the collectors in the
+// status with the current generation of merged-collector-config.
+func observedByCollectorsCondition(cc *searchv1alpha1.CollectorConfig) metav1.Condition {
+	condition := metav1.Condition{
+		Type: searchv1alpha1.CollectorConfigConditionObservedByCollectors,
+	}
+	if len(cc.Status.Collectors) == 0 {
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = searchv1alpha1.CollectorConfigReasonNoAcknowledgements
+		condition.Message = "No collector has acknowledged this configuration yet."
+		return condition
+	}
+
+	var lagging []string
+	for _, ack := range cc.Status.Collectors {
+		if ack.ObservedGeneration < cc.Generation {
+			lagging = append(lagging, ack.Name)
+		}
+	}
+	if len(lagging) == 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = searchv1alpha1.CollectorConfigReasonObserved
+		condition.Message = fmt.Sprintf("%d collectors loaded generation %d.", len(cc.Status.Collectors), cc.Generation)
+		return condition
+	}
+
+	sort.Strings(lagging)
+	listed := lagging
+	if len(listed) > maxListedCollectors {
+		listed = append(listed[:maxListedCollectors:maxListedCollectors], "...")
+	}
+	condition.Status = metav1.ConditionFalse
+	condition.Reason = searchv1alpha1.CollectorConfigReasonPending
+	condition.Message = fmt.Sprintf("%d of %d collectors have not loaded generation %d: %s",
+		len(lagging), len(cc.Status.Collectors), cc.Generation, strings.Join(listed, ", "))
+	return condition
+}
+
+// observedByCollectorsChanged reports whether the ObservedByCollectors condition on
+// merged-collector-config is out of date with the collector acknowledgements. The watch uses it
+// to reconcile when a collector acknowledges, without reacting to the operator's own writes.
+func observedByCollectorsChanged(cc *searchv1alpha1.CollectorConfig) bool {
+	desired := observedByCollectorsCondition(cc)
+	existing := apimeta.FindStatusCondition(cc.Status.Conditions, desired.Type)
+	return existing == nil || existing.Status != desired.Status ||
+		existing.Reason != desired.Reason || existing.Message != desired.Message
+}
+
+// updateMergedCCStatus writes the provenance, edge type, collision and acknowledgement status of
+// merged-collector-config. lastMergeTime only moves when the merge result or its sources change,
+// and nothing is written when the status is already current.
+func (r *SearchReconciler) updateMergedCCStatus(ctx context.Context, merged *searchv1alpha1.CollectorConfig,
+	provenance *mergeProvenance) error {
+	base := merged.DeepCopy()
+	status := merged.Status.DeepCopy()
+	status.Sources = provenance.sources
+	status.RuleSources = provenance.ruleSources
+	status.DroppedRules = provenance.droppedRules
+	status.MergedGeneration = merged.Generation
+	status.EdgeTypes = provenance.edgeTypes
+	if status.LastMergeTime == nil ||
+		!equality.Semantic.DeepEqual(status.Sources, merged.Status.Sources) ||
+		!equality.Semantic.DeepEqual(status.RuleSources, merged.Status.RuleSources) ||
+		status.DroppedRules != merged.Status.DroppedRules ||
+		status.MergedGeneration != merged.Status.MergedGeneration {
+		now := metav1.Now()
+		status.LastMergeTime = &now
+	}
+
+	apimeta.SetStatusCondition(&status.Conditions, ruleCollisionsCondition(collisionMessages(provenance.collisions, "")))
+	apimeta.SetStatusCondition(&status.Conditions, observedByCollectorsCondition(merged))
+
+	if equality.Semantic.DeepEqual(*status, merged.Status) {
+		return nil
+	}
+	merged.Status = *status
+	// The ObservedByCollectors condition depends on the collector acknowledgements, so the patch
+	// fails with a conflict when they, or anything else, changed since merged was read.
+	return r.Status().Patch(ctx, merged, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{}))
+}
@@ -0,0 +1,212 @@
+// Copyright Contributors to the Open Cluster Management project
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestMergedStatus_Provenance(t *testing.T) {
+	instance := newSearchInstance()
+	grc := newIntegrationTeamConfig("grc-integration", searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{Action: searchv1alpha1.ActionInclude, ResourceSelector: policySelector},
+		},
+	})
+	kyverno := newIntegrationTeamConfig("kyverno-integration", searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{Action: searchv1alpha1.ActionExclude, ResourceSelector: policySelector},
+			{
+				Action:           searchv1alpha1.ActionInclude,
+				ResourceSelector: searchv1alpha1.ResourceSelector{APIGroups: []string{"kyverno.io"}, Kinds: []string{"Policy"}},
+			},
+		},
+	})
+	userCC := newCollectorConfig(userCollectorConfigName, searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			// Dropped: grc-integration includes Policy.
+			{Action: searchv1alpha1.ActionExclude, ResourceSelector: policySelector},
+			{
+				Action:           searchv1alpha1.ActionInclude,
+				ResourceSelector: searchv1alpha1.ResourceSelector{APIGroups: []string{"apps"}, Kinds: []string{"Deployment"}},
+			},
+		},
+	})
+	r := setupReconciler(instance, grc, kyverno, userCC)
+
+	result, err := r.createOrUpdateMergedCollectorConfig(context.TODO(), instance)
+	require.NoError(t, err)
+	assert.Nil(t, result)
+
+	merged, err := getMergedConfig(r)
+	require.NoError(t, err)
+	require.Len(t, merged.Spec.CollectionRules, 3)
+	expected := []searchv1alpha1.RuleSource{
+		{Source: "grc-integration", Index: 0},
+		{Source: "kyverno-integration", Index: 1},
+		{Source: userCollectorConfigName, Index: 1},
+	}
+	assert.Equal(t, expected, merged.Status.RuleSources)
+	assert.Equal(t, 2, merged.Status.DroppedRules)
+	assert.Equal(t, merged.Generation, merged.Status.MergedGeneration)
+	assert.NotNil(t, merged.Status.LastMergeTime)
+
+	names := []string{}
+	for _, s := range merged.Status.Sources {
+		names = append(names, s.Name)
+		assert.NotEmpty(t, s.ResourceVersion)
+	}
+	assert.Equal(t, []string{"grc-integration", "kyverno-integration", userCollectorConfigName}, names)
+
+	annotated := []searchv1alpha1.RuleSource{}
+	require.NoError(t, json.Unmarshal([]byte(merged.Annotations[searchv1alpha1.AnnotationMergeProvenance]), &annotated))
+	assert.Equal(t, expected, annotated)
+
+	cond := apimeta.FindStatusCondition(merged.Status.Conditions, searchv1alpha1.CollectorConfigConditionObservedByCollectors)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionUnknown, cond.Status)
+}
+
+func TestMergedStatus_NoWriteWhenCurrent(t *testing.T) {
+	instance := newSearchInstance()
+	grc := newIntegrationTeamConfig("grc-integration", searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{Action: searchv1alpha1.ActionInclude, ResourceSelector: policySelector},
+		},
+	})
+	r := setupReconciler(instance, grc)
+	ctx := context.TODO()
+
+	_, err := r.createOrUpdateMergedCollectorConfig(ctx, instance)
+	require.NoError(t, err)
+	first, err := getMergedConfig(r)
+	require.NoError(t, err)
+
+	_, err = r.createOrUpdateMergedCollectorConfig(ctx, instance)
+	require.NoError(t, err)
+	second, err := getMergedConfig(r)
+	require.NoError(t, err)
+	assert.Equal(t, first.ResourceVersion, second.ResourceVersion, "unchanged merge must not write")
+}
+
+func TestObservedByCollectorsCondition(t *testing.T) {
+	cc := newCollectorConfig(mergedCollectorConfigName, searchv1alpha1.CollectorConfigSpec{})
+	cc.Generation = 3
+
+	cond := observedByCollectorsCondition(cc)
+	assert.Equal(t, metav1.ConditionUnknown, cond.Status)
+	assert.True(t, observedByCollectorsChanged(cc))
+
+	cc.Status.Collectors = []searchv1alpha1.CollectorAcknowledgement{
+		{Name: "local-cluster", ObservedGeneration: 3},
+		{Name: "cluster-b", ObservedGeneration: 2},
+		{Name: "cluster-a", ObservedGeneration: 1},
+	}
+	cond = observedByCollectorsCondition(cc)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, searchv1alpha1.CollectorConfigReasonPending, cond.Reason)
+	assert.Equal(t, "2 of 3 collectors have not loaded generation 3: cluster-a, cluster-b", cond.Message)
+
+	cc.Status.Collectors[1].ObservedGeneration = 3
+	cc.Status.Collectors[2].ObservedGeneration = 3
+	cond = observedByCollectorsCondition(cc)
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
+
+	apimeta.SetStatusCondition(&cc.Status.Conditions, cond)
+	assert.False(t, observedByCollectorsChanged(cc), "no reconcile once the condition is current")
+}
+
+func TestMergedStatus_CollectorAcknowledgement(t *testing.T) {
+	instance := newSearchInstance()
+	r := setupReconciler(instance)
+	ctx := context.TODO()
+
+	_, err := r.createOrUpdateMergedCollectorConfig(ctx, instance)
+	require.NoError(t, err)
+
+	// A collector acknowledges the current generation.
+	merged, err := getMergedConfig(r)
+	require.NoError(t, err)
+	merged.Status.Collectors = []searchv1alpha1.CollectorAcknowledgement{
+		{Name: "local-cluster", ObservedGeneration: merged.Generation},
+	}
+	require.NoError(t, r.Status().Update(ctx, merged))
+
+	_, err = r.createOrUpdateMergedCollectorConfig(ctx, instance)
+	require.NoError(t, err)
+	merged, err = getMergedConfig(r)
+	require.NoError(t, err)
+	require.Len(t, merged.Status.Collectors, 1, "operator must keep collector acknowledgements")
+	cond := apimeta.FindStatusCondition(merged.Status.Conditions, searchv1alpha1.CollectorConfigConditionObservedByCollectors)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
+}
+
+func TestMergedStatus_KeepsConcurrentAcknowledgement(t *testing.T) {
+	instance := newSearchInstance()
+	r := setupReconciler(instance)
+	ctx := context.TODO()
+
+	_, err := r.createOrUpdateMergedCollectorConfig(ctx, instance)
+	require.NoError(t, err)
+	stale, err := getMergedConfig(r)
+	require.NoError(t, err)
+
+	// A collector acknowledges after the operator read merged-collector-config.
+	acked := stale.DeepCopy()
+	acked.Status.Collectors = []searchv1alpha1.CollectorAcknowledgement{
+		{Name: "local-cluster", ObservedGeneration: acked.Generation},
+	}
+	require.NoError(t, r.Status().Update(ctx, acked))
+
+	// The status computed from the stale read is not written; the acknowledgement is kept.
+	result, err := r.commitMergedCCStatus(ctx, stale, &mergeProvenance{droppedRules: 1})
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.NotZero(t, result.RequeueAfter)
+	merged, err := getMergedConfig(r)
+	require.NoError(t, err)
+	assert.Zero(t, merged.Status.DroppedRules)
+	assert.Len(t, merged.Status.Collectors, 1, "the status write must not drop the acknowledgement")
+
+	// The requeued merge reads the acknowledgement and writes its status.
+	require.NoError(t, r.updateMergedCCStatus(ctx, merged, &mergeProvenance{droppedRules: 1}))
+	merged, err = getMergedConfig(r)
+	require.NoError(t, err)
+	assert.Equal(t, 1, merged.Status.DroppedRules)
+	assert.Len(t, merged.Status.Collectors, 1)
+}
+
+func TestMergedStatus_RequeueOnConflict(t *testing.T) {
+	instance := newSearchInstance()
+	r := setupReconciler(instance)
+	ctx := context.TODO()
+
+	_, err := r.createOrUpdateMergedCollectorConfig(ctx, instance)
+	require.NoError(t, err)
+	merged, err := getMergedConfig(r)
+	require.NoError(t, err)
+
+	r.Client = interceptor.NewClient(r.Client.(client.WithWatch), interceptor.Funcs{
+		SubResourcePatch: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object,
+			patch client.Patch, opts ...client.SubResourcePatchOption) error {
+			return apierrors.NewConflict(searchv1alpha1.GroupVersion.WithResource("collectorconfigs").GroupResource(),
+				obj.GetName(), errors.New("modified"))
+		},
+	})
+	result, err := r.commitMergedCCStatus(ctx, merged, &mergeProvenance{droppedRules: 1})
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.NotZero(t, result.RequeueAfter)
+}
@@ -0,0 +1,32 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"fmt"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+)
+
+// appendRedactionRules adds the redaction rules of a source CollectorConfig to the merged rules.
+// Redaction rules only add up: a rule whose name is already merged is skipped, so a config that
+// sorts later, or user-collector-config, cannot replace or weaken a rule of an integration config.
+// It returns a collision for each skipped rule.
+func appendRedactionRules(merged []searchv1alpha1.RedactionRule, cc *searchv1alpha1.CollectorConfig,
+	owners map[string]string) ([]searchv1alpha1.RedactionRule, []ruleCollision) {
+	var skipped []ruleCollision
+	for _, rule := range cc.Spec.Redact {
+		if owner, ok := owners[rule.Name]; ok {
+			skipped = append(skipped, ruleCollision{
+				configs: [2]string{owner, cc.Name},
+				message: fmt.Sprintf(
+					"redaction rule %s was not applied — %s already defines a redaction rule with that name",
+					rule.Name, owner),
+			})
+			continue
+		}
+		owners[rule.Name] = cc.Name
+		merged = append(merged, rule)
+	}
+	return merged, skipped
+}
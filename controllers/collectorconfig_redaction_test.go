@@ -0,0 +1,104 @@
+// Copyright Contributors to the Open Cluster Management project
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func redactionRule(name, keyPattern string) searchv1alpha1.RedactionRule {
+	return searchv1alpha1.RedactionRule{
+		Name: name, Target: searchv1alpha1.RedactionTargetAnnotations, KeyPattern: keyPattern,
+	}
+}
+
+// Redaction rules of all configs are merged; a user rule can't replace an integration rule.
+func TestMerge_RedactionRules(t *testing.T) {
+	instance := newSearchInstance()
+	teamA := newIntegrationTeamConfig("redaction-integration", searchv1alpha1.CollectorConfigSpec{
+		Redact: []searchv1alpha1.RedactionRule{redactionRule("tokens", "(?i)token")},
+	})
+	teamB := newIntegrationTeamConfig("team-b", searchv1alpha1.CollectorConfigSpec{
+		Redact: []searchv1alpha1.RedactionRule{redactionRule("tokens", "^never$"), redactionRule("keys", "(?i)key")},
+	})
+	userCC := newCollectorConfig(userCollectorConfigName, searchv1alpha1.CollectorConfigSpec{
+		Redact: []searchv1alpha1.RedactionRule{redactionRule("tokens", "^never$"), redactionRule("pins", "(?i)pin")},
+	})
+	r := setupReconciler(instance, teamA, teamB, userCC)
+
+	_, err := r.createOrUpdateMergedCollectorConfig(context.TODO(), instance)
+	require.NoError(t, err)
+
+	merged, err := getMergedConfig(r)
+	require.NoError(t, err)
+	assert.Equal(t, []searchv1alpha1.RedactionRule{
+		redactionRule("tokens", "(?i)token"),
+		redactionRule("keys", "(?i)key"),
+		redactionRule("pins", "(?i)pin"),
+	}, merged.Spec.Redact)
+
+	updated := &searchv1alpha1.CollectorConfig{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: userCollectorConfigName, Namespace: testNamespace}, updated))
+	cond := apimeta.FindStatusCondition(updated.Status.Conditions, searchv1alpha1.CollectorConfigConditionApplied)
+	require.NotNil(t, cond)
+	assert.Equal(t, searchv1alpha1.CollectorConfigReasonRulesSkipped, cond.Reason)
+	assert.Contains(t, cond.Message, "redaction rule tokens was not applied — redaction-integration")
+}
+
+// A redaction rule of an integration config that reuses a name is reported as a collision.
+func TestMerge_RedactionRuleCollision(t *testing.T) {
+	instance := newSearchInstance()
+	teamA := newIntegrationTeamConfig("redaction-integration", searchv1alpha1.CollectorConfigSpec{
+		Redact: []searchv1alpha1.RedactionRule{redactionRule("tokens", "(?i)token")},
+	})
+	teamB := newIntegrationTeamConfig("team-b", searchv1alpha1.CollectorConfigSpec{
+		Redact: []searchv1alpha1.RedactionRule{redactionRule("tokens", "^never$")},
+	})
+	r := setupReconciler(instance, teamA, teamB)
+
+	_, err := r.createOrUpdateMergedCollectorConfig(context.TODO(), instance)
+	require.NoError(t, err)
+
+	for _, name := range []string{"redaction-integration", "team-b"} {
+		cc := &searchv1alpha1.CollectorConfig{}
+		require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: testNamespace}, cc))
+		cond := apimeta.FindStatusCondition(cc.Status.Conditions, searchv1alpha1.CollectorConfigConditionRuleCollisions)
+		require.NotNil(t, cond, name)
+		assert.Equal(t, metav1.ConditionTrue, cond.Status, name)
+		assert.Equal(t, "redaction rule tokens was not applied — redaction-integration already defines a "+
+			"redaction rule with that name", cond.Message, name)
+	}
+}
+
+// Per-cluster configs carry every redaction rule.
+func TestClusterConfigs_RedactionRules(t *testing.T) {
+	instance := newSearchInstance()
+	userCC := newCollectorConfig(userCollectorConfigName, searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{
+				Action:           searchv1alpha1.ActionInclude,
+				ResourceSelector: argoSelector,
+				ClusterSelector: &searchv1alpha1.ClusterSelector{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"gitops": "true"}},
+				},
+			},
+		},
+		Redact: []searchv1alpha1.RedactionRule{redactionRule("tokens", "(?i)token")},
+	})
+	r := setupClusterReconciler(t, instance, userCC,
+		newManagedCluster("edge-1", nil), newNamespace("edge-1"))
+
+	mergeAndScope(t, r, instance)
+
+	edge, err := getClusterConfig(t, r, "edge-1")
+	require.NoError(t, err)
+	assert.Empty(t, edge.Spec.CollectionRules)
+	assert.Equal(t, []searchv1alpha1.RedactionRule{redactionRule("tokens", "(?i)token")}, edge.Spec.Redact)
+}
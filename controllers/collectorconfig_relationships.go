@@ -0,0 +1,73 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+)
+
+// appendRelationships adds the relationships of a source CollectorConfig to the merged
+// relationships. Like redaction rules, relationships only add up: one whose name is already merged
+// is skipped, so user-collector-config cannot replace a relationship of an integration config.
+// It returns a message for each skipped relationship.
+func appendRelationships(merged []searchv1alpha1.Relationship, cc *searchv1alpha1.CollectorConfig,
+	owners map[string]string) ([]searchv1alpha1.Relationship, []string) {
+	var skipped []string
+	for _, rel := range cc.Spec.Relationships {
+		if owner, ok := owners[rel.Name]; ok {
+			skipped = append(skipped, fmt.Sprintf(
+				"relationship %s was not applied — %s already defines a relationship with that name",
+				rel.Name, owner))
+			continue
+		}
+		owners[rel.Name] = cc.Name
+		merged = append(merged, rel)
+	}
+	return merged, skipped
+}
+
+// edgeTypes summarizes the merged relationships by edge type for the merged-collector-config
+// status. owners maps each relationship name to the CollectorConfig that declares it.
+func edgeTypes(relationships []searchv1alpha1.Relationship, owners map[string]string) []searchv1alpha1.EdgeType {
+	byName := map[string]*searchv1alpha1.EdgeType{}
+	for _, rel := range relationships {
+		et, ok := byName[rel.EdgeType]
+		if !ok {
+			et = &searchv1alpha1.EdgeType{Name: rel.EdgeType}
+			byName[rel.EdgeType] = et
+		}
+		et.SourceKinds = appendSorted(et.SourceKinds, qualifiedKind(rel.Source.Kind, rel.Source.APIGroup))
+		if rel.Target.Kind != "" {
+			et.TargetKinds = appendSorted(et.TargetKinds, qualifiedKind(rel.Target.Kind, rel.Target.APIGroup))
+		}
+		et.Sources = appendSorted(et.Sources, owners[rel.Name])
+	}
+
+	var result []searchv1alpha1.EdgeType
+	for _, et := range byName {
+		result = append(result, *et)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// qualifiedKind renders a kind as Kind, or Kind.apiGroup outside the core group.
+func qualifiedKind(kind, apiGroup string) string {
+	if apiGroup == "" {
+		return kind
+	}
+	return kind + "." + apiGroup
+}
+
+// appendSorted inserts value into the sorted list unless it is already there.
+func appendSorted(list []string, value string) []string {
+	i, found := slices.BinarySearch(list, value)
+	if found {
+		return list
+	}
+	return slices.Insert(list, i, value)
+}
@@ -0,0 +1,92 @@
+// Copyright Contributors to the Open Cluster Management project
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func relationship(name, edgeType, sourceKind, targetKind string) searchv1alpha1.Relationship {
+	return searchv1alpha1.Relationship{
+		Name:     name,
+		EdgeType: edgeType,
+		Source:   searchv1alpha1.RelationshipSource{APIGroup: "example.com", Kind: sourceKind},
+		Target: searchv1alpha1.RelationshipTarget{
+			JSONPath: "{.spec.workloadRef}", APIGroup: "apps", Kind: targetKind,
+		},
+	}
+}
+
+// Relationships of all configs are merged; a user relationship can't replace an integration one.
+// The edge types are recorded in the merged-collector-config status.
+func TestMerge_Relationships(t *testing.T) {
+	instance := newSearchInstance()
+	team := newIntegrationTeamConfig("team-a", searchv1alpha1.CollectorConfigSpec{
+		Relationships: []searchv1alpha1.Relationship{relationship("myapp-workload", "usesWorkload", "MyApp", "Deployment")},
+	})
+	userCC := newCollectorConfig(userCollectorConfigName, searchv1alpha1.CollectorConfigSpec{
+		Relationships: []searchv1alpha1.Relationship{
+			relationship("myapp-workload", "replaced", "MyApp", "StatefulSet"),
+			relationship("myjob-workload", "usesWorkload", "MyJob", ""),
+		},
+	})
+	r := setupReconciler(instance, team, userCC)
+
+	_, err := r.createOrUpdateMergedCollectorConfig(context.TODO(), instance)
+	require.NoError(t, err)
+
+	merged, err := getMergedConfig(r)
+	require.NoError(t, err)
+	assert.Equal(t, []searchv1alpha1.Relationship{
+		relationship("myapp-workload", "usesWorkload", "MyApp", "Deployment"),
+		relationship("myjob-workload", "usesWorkload", "MyJob", ""),
+	}, merged.Spec.Relationships)
+	assert.Equal(t, []searchv1alpha1.EdgeType{
+		{
+			Name:        "usesWorkload",
+			SourceKinds: []string{"MyApp.example.com", "MyJob.example.com"},
+			TargetKinds: []string{"Deployment.apps"},
+			Sources:     []string{"team-a", userCollectorConfigName},
+		},
+	}, merged.Status.EdgeTypes)
+
+	updated := &searchv1alpha1.CollectorConfig{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: userCollectorConfigName, Namespace: testNamespace}, updated))
+	cond := apimeta.FindStatusCondition(updated.Status.Conditions, searchv1alpha1.CollectorConfigConditionApplied)
+	require.NotNil(t, cond)
+	assert.Contains(t, cond.Message, "relationship myapp-workload was not applied — team-a")
+}
+
+// Per-cluster configs carry every relationship.
+func TestClusterConfigs_Relationships(t *testing.T) {
+	instance := newSearchInstance()
+	userCC := newCollectorConfig(userCollectorConfigName, searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{
+				Action:           searchv1alpha1.ActionInclude,
+				ResourceSelector: argoSelector,
+				ClusterSelector: &searchv1alpha1.ClusterSelector{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"gitops": "true"}},
+				},
+			},
+		},
+		Relationships: []searchv1alpha1.Relationship{relationship("myapp-workload", "usesWorkload", "MyApp", "Deployment")},
+	})
+	r := setupClusterReconciler(t, instance, userCC,
+		newManagedCluster("edge-1", nil), newNamespace("edge-1"))
+
+	mergeAndScope(t, r, instance)
+
+	edge, err := getClusterConfig(t, r, "edge-1")
+	require.NoError(t, err)
+	assert.Equal(t, []searchv1alpha1.Relationship{relationship("myapp-workload", "usesWorkload", "MyApp", "Deployment")},
+		edge.Spec.Relationships)
+	assert.Empty(t, edge.Status.EdgeTypes)
+}
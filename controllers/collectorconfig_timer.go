@@ -0,0 +1,73 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// collectorConfigTimer is embedded by the manager.Runnables (see main.go's mgr.Add calls) that
+// update the status of the CollectorConfigs in the Search namespace on a timer, because what they
+// report can change without any CollectorConfig changing: CollectorConfigImpactEstimator and
+// CollectorConfigDiscoveryChecker.
+type collectorConfigTimer struct {
+	// Namespace is used if non-empty, otherwise it is discovered from the Search CR. See
+	// IntegrationCollectorConfigSeeder.Namespace.
+	Namespace string
+
+	// Interval overrides the default interval of the runnable when non-zero.
+	Interval time.Duration
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. The runnables write status, so
+// only the leader runs them.
+func (t collectorConfigTimer) NeedLeaderElection() bool {
+	return true
+}
+
+// run calls update every Interval, or defaultInterval when Interval is zero, until ctx is done. An
+// error is logged and update runs again on the next tick. task names the update in the logs.
+func (t collectorConfigTimer) run(ctx context.Context, defaultInterval time.Duration, task string,
+	update func(ctx context.Context) error) {
+	interval := t.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := update(ctx); err != nil {
+			log.Error(err, "Could not update CollectorConfigs, will retry", "task", task)
+		}
+	}, interval)
+}
+
+// collectorConfigs returns the CollectorConfigs in the Search namespace, or none when Search
+// reconciliation is paused. The per-cluster configs are left out; their rules are copies of
+// merged-collector-config's.
+func (t collectorConfigTimer) collectorConfigs(ctx context.Context, c client.Client,
+	task string) ([]*searchv1alpha1.CollectorConfig, error) {
+	searchCR, paused, err := findSearch(ctx, c, t.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	if paused {
+		log.V(2).Info("Search reconciliation is paused, skipping CollectorConfig updates", "task", task)
+		return nil, nil
+	}
+
+	list := &searchv1alpha1.CollectorConfigList{}
+	if err := c.List(ctx, list, client.InNamespace(searchCR.GetNamespace())); err != nil {
+		return nil, err
+	}
+	var configs []*searchv1alpha1.CollectorConfig
+	for i := range list.Items {
+		if list.Items[i].Labels[searchv1alpha1.IntegrationTeamLabel] != searchv1alpha1.ClusterConfigLabelValue {
+			configs = append(configs, &list.Items[i])
+		}
+	}
+	return configs, nil
+}
@@ -0,0 +1,48 @@
+// Copyright Contributors to the Open Cluster Management project
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// An update error is retried on the next tick, until ctx is done.
+func TestCollectorConfigTimer_RunRetries(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls := 0
+	collectorConfigTimer{Interval: time.Millisecond}.run(ctx, time.Hour, "test", func(context.Context) error {
+		calls++
+		if calls == 3 {
+			cancel()
+		}
+		return errors.New("not yet")
+	})
+	assert.Equal(t, 3, calls)
+}
+
+// The per-cluster configs are left out, and nothing is listed while Search is paused.
+func TestCollectorConfigTimer_CollectorConfigs(t *testing.T) {
+	instance := newSearchInstance()
+	clusterCC := newCollectorConfig("cluster-config", searchv1alpha1.CollectorConfigSpec{})
+	clusterCC.Labels = map[string]string{searchv1alpha1.IntegrationTeamLabel: searchv1alpha1.ClusterConfigLabelValue}
+	r := setupReconciler(instance, clusterCC, newCollectorConfig(userCollectorConfigName, searchv1alpha1.CollectorConfigSpec{}))
+	timer := collectorConfigTimer{}
+
+	configs, err := timer.collectorConfigs(context.TODO(), r.Client, "test")
+	require.NoError(t, err)
+	require.Len(t, configs, 1)
+	assert.Equal(t, userCollectorConfigName, configs[0].Name)
+
+	instance.Annotations = map[string]string{AnnotationSearchPause: "true"}
+	require.NoError(t, r.Update(context.TODO(), instance))
+	configs, err = timer.collectorConfigs(context.TODO(), r.Client, "test")
+	require.NoError(t, err)
+	assert.Empty(t, configs)
+}
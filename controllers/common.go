@@ -3,8 +3,10 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 
 	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
@@ -41,12 +43,23 @@ const (
 	defaultPrometheusAlertMaxAppsCount            = "100"
 	defaultPrometheusAlertMaxManagedClustersCount = "10"
 	defaultPrometheusAlertMaxIndexerCountOver30m  = "100"
-
-	// Annotations on the search CR to override the default values for the Prometheus alerts above
+	defaultPrometheusAlertPVCUsageWarningPercent  = "80"
+	defaultPrometheusAlertPVCUsageCriticalPercent = "90"
+	defaultPrometheusAlertMaxPostgresRestarts1h   = "3"
+	defaultPrometheusAlertMaxQueryDurationSeconds = "300"
+	defaultPrometheusAlertMaxConnectionsPercent   = "80"
+	defaultPrometheusAlertMaxIndexerLatencySecs   = "30"
+	defaultPrometheusAlertMaxAPIErrorPercent      = "5"
+	defaultPrometheusAlertCollectorSilence        = "30m"
+
+	// Annotations on the search CR to override the default values of the SearchPVCNotPresentCritical
+	// thresholds. The other alerts are only configured through spec.monitoring.alerts.
+	// Deprecated: use spec.monitoring.alerts, which takes precedence over these and the env vars.
 	AnnotationPrometheusAlertMaxAppsCount            = "search.open-cluster-management.io/max-apps-count"
 	AnnotationPrometheusAlertMaxManagedClustersCount = "search.open-cluster-management.io/max-managed-clusters-count"
 	AnnotationPrometheusAlertMaxIndexerCountOver30m  = "search.open-cluster-management.io/max-indexer-count-over-30m"
-	// Annotation to disable the Search PVC critical alert entirely
+	// Annotation to disable the Search PVC critical alert entirely.
+	// Deprecated: set enabled: false for SearchPVCNotPresentCritical in spec.monitoring.alerts.
 	AnnotationPrometheusAlertSearchPVCCriticalDisable = "search.open-cluster-management.io/disable-pvc-critical-alert"
 
 	// backupLabel is the ACM backup label that causes a resource to be included in the
@@ -233,6 +246,7 @@ var operatorManagedSecrets = map[string]struct{}{
 	"search-postgres":     {},
 	apiReadonlySecretName: {},
 	mcpReadonlySecretName: {},
+	monitorSecretName:     {},
 }
 
 func getContainerEnvVar(deploymentName string, instance *searchv1alpha1.Search) []corev1.EnvVar {
@@ -477,62 +491,109 @@ func getImageSha(deploymentName string, instance *searchv1alpha1.Search) string
 		return trustedOverride(instance.Spec.Deployments.Indexer.ImageOverride, os.Getenv("INDEXER_IMAGE"))
 	case postgresDeploymentName:
 		return trustedOverride(instance.Spec.Deployments.Database.ImageOverride, os.Getenv("POSTGRES_IMAGE"))
+	case postgresExporterName:
+		return trustedOverride(instance.Spec.Monitoring.DatabaseExporter.ImageOverride, os.Getenv("POSTGRES_EXPORTER_IMAGE"))
 	}
 	log.V(2).Info("Unknown deployment ", "name", deploymentName)
 	return ""
 }
 
-func isPrometheusAlertSearchPVCCriticalDisabled(instance *searchv1alpha1.Search) bool {
-	if _, ok := instance.Annotations[AnnotationPrometheusAlertSearchPVCCriticalDisable]; ok {
-		// If the annotation is present (with any value), return true
-		return true
+// Threshold names accepted in spec.monitoring.alerts[].thresholds. The CRD restricts which names
+// each alert accepts.
+const (
+	alertThresholdMaxManagedClusters    = "maxManagedClusters"
+	alertThresholdMaxApps               = "maxApps"
+	alertThresholdMaxIndexerRequests30m = "maxIndexerRequests30m"
+	alertThresholdPercent               = "percent"
+	alertThresholdRestarts              = "restarts"
+	alertThresholdSeconds               = "seconds"
+	alertThresholdMinutes               = "minutes"
+)
+
+// getAlertConfig returns the spec.monitoring.alerts entry for the alert, if there is one.
+func getAlertConfig(instance *searchv1alpha1.Search, alert searchv1alpha1.SearchAlertName) (searchv1alpha1.AlertConfig, bool) {
+	for _, cfg := range instance.Spec.Monitoring.Alerts {
+		if cfg.Name == alert {
+			return cfg, true
+		}
 	}
-	return false
+	return searchv1alpha1.AlertConfig{}, false
 }
 
-func getPrometheusAlertMaxAppsCount(instance *searchv1alpha1.Search) string {
-	maxAppsCount := os.Getenv("PROMETHEUS_ALERT_MAX_APPS_COUNT")
-
-	// Override with value from annotation on search CR if present
-	if value, ok := instance.Annotations[AnnotationPrometheusAlertMaxAppsCount]; ok {
-		maxAppsCount = value
+// isPrometheusAlertEnabled returns false when the alert is disabled in spec.monitoring.alerts.
+// The deprecated disable-pvc-critical-alert annotation is still honoured when the spec is not set.
+func isPrometheusAlertEnabled(instance *searchv1alpha1.Search, alert searchv1alpha1.SearchAlertName) bool {
+	if cfg, ok := getAlertConfig(instance, alert); ok && cfg.Enabled != nil {
+		return *cfg.Enabled
 	}
-
-	// Use default value if not overridden by env var or annotation
-	if maxAppsCount == "" {
-		maxAppsCount = defaultPrometheusAlertMaxAppsCount
+	if alert == searchv1alpha1.AlertSearchPVCNotPresentCritical {
+		if _, ok := instance.Annotations[AnnotationPrometheusAlertSearchPVCCriticalDisable]; ok {
+			// If the annotation is present (with any value), the alert is disabled
+			return false
+		}
 	}
-	return maxAppsCount
+	return true
 }
 
-func getPrometheusAlertMaxManagedClustersCount(instance *searchv1alpha1.Search) string {
-	maxManagedClustersCount := os.Getenv("PROMETHEUS_ALERT_MAX_MANAGED_CLUSTERS_COUNT")
-
-	// Override with value from annotation on search CR if present
-	if value, ok := instance.Annotations[AnnotationPrometheusAlertMaxManagedClustersCount]; ok {
-		maxManagedClustersCount = value
+// getPrometheusAlertThreshold returns a numeric alert threshold from spec.monitoring.alerts, or
+// defaultValue when it is not set there.
+func getPrometheusAlertThreshold(instance *searchv1alpha1.Search, alert searchv1alpha1.SearchAlertName,
+	key, defaultValue string) string {
+	if cfg, ok := getAlertConfig(instance, alert); ok {
+		if v, ok := cfg.Thresholds[key]; ok {
+			return string(v)
+		}
 	}
+	return defaultValue
+}
 
-	// Use default value if not overridden by env var or annotation
-	if maxManagedClustersCount == "" {
-		maxManagedClustersCount = defaultPrometheusAlertMaxManagedClustersCount
+// getPrometheusAlertPVCCriticalThreshold returns a SearchPVCNotPresentCritical threshold. The value
+// in spec.monitoring.alerts takes precedence, then the deprecated annotation on the search CR, then
+// the deprecated operator env var. The value is inserted into a PromQL expression, so anything
+// that is not a number falls back to the default.
+func getPrometheusAlertPVCCriticalThreshold(instance *searchv1alpha1.Search,
+	key, envVar, annotation, defaultValue string) string {
+	value := os.Getenv(envVar)
+	if v, ok := instance.Annotations[annotation]; ok {
+		value = v
+	}
+	if value == "" {
+		value = defaultValue
+	} else if _, err := strconv.ParseFloat(value, 64); err != nil {
+		log.Info("Ignoring invalid Prometheus alert threshold", "setting", annotation, "value", value)
+		value = defaultValue
 	}
-	return maxManagedClustersCount
+	return getPrometheusAlertThreshold(instance, searchv1alpha1.AlertSearchPVCNotPresentCritical, key, value)
 }
 
-func getPrometheusAlertMaxIndexerCountOver30m(instance *searchv1alpha1.Search) string {
-	maxIndexerCountOver30m := os.Getenv("PROMETHEUS_ALERT_MAX_INDEXER_COUNT_OVER_30M")
+func getPrometheusAlertMaxAppsCount(instance *searchv1alpha1.Search) string {
+	return getPrometheusAlertPVCCriticalThreshold(instance, alertThresholdMaxApps, "PROMETHEUS_ALERT_MAX_APPS_COUNT",
+		AnnotationPrometheusAlertMaxAppsCount, defaultPrometheusAlertMaxAppsCount)
+}
 
-	// Override with value from annotation on search CR if present
-	if value, ok := instance.Annotations[AnnotationPrometheusAlertMaxIndexerCountOver30m]; ok {
-		maxIndexerCountOver30m = value
-	}
+func getPrometheusAlertMaxManagedClustersCount(instance *searchv1alpha1.Search) string {
+	return getPrometheusAlertPVCCriticalThreshold(instance, alertThresholdMaxManagedClusters,
+		"PROMETHEUS_ALERT_MAX_MANAGED_CLUSTERS_COUNT",
+		AnnotationPrometheusAlertMaxManagedClustersCount, defaultPrometheusAlertMaxManagedClustersCount)
+}
 
-	// Use default value if not overridden by env var or annotation
-	if maxIndexerCountOver30m == "" {
-		maxIndexerCountOver30m = defaultPrometheusAlertMaxIndexerCountOver30m
+func getPrometheusAlertMaxIndexerCountOver30m(instance *searchv1alpha1.Search) string {
+	return getPrometheusAlertPVCCriticalThreshold(instance, alertThresholdMaxIndexerRequests30m,
+		"PROMETHEUS_ALERT_MAX_INDEXER_COUNT_OVER_30M",
+		AnnotationPrometheusAlertMaxIndexerCountOver30m, defaultPrometheusAlertMaxIndexerCountOver30m)
+}
+
+// getPrometheusAlertCollectorSilence returns the window after which a silent collector alerts, as
+// a Prometheus duration. The spec threshold is in minutes.
+func getPrometheusAlertCollectorSilence(instance *searchv1alpha1.Search) string {
+	if cfg, ok := getAlertConfig(instance, searchv1alpha1.AlertSearchCollectorNotReporting); ok {
+		if v, ok := cfg.Thresholds[alertThresholdMinutes]; ok {
+			if minutes, err := strconv.ParseFloat(string(v), 64); err == nil && minutes >= 1 {
+				return fmt.Sprintf("%dm", int(minutes))
+			}
+		}
 	}
-	return maxIndexerCountOver30m
+	return defaultPrometheusAlertCollectorSilence
 }
 
 func (r *SearchReconciler) addEnvToSearchAPI(ctx context.Context,
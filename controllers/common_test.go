@@ -18,6 +18,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/yaml"
 )
@@ -1633,3 +1634,105 @@ func TestGetPrometheusAlertMaxIndexerCountOver30m(t *testing.T) {
 	search.Annotations = map[string]string{AnnotationPrometheusAlertMaxIndexerCountOver30m: "8000"}
 	assert.Equal(t, "8000", getPrometheusAlertMaxIndexerCountOver30m(search))
 }
+
+func TestGetPrometheusAlertThreshold(t *testing.T) {
+	search := &searchv1alpha1.Search{
+		TypeMeta:   metav1.TypeMeta{Kind: "Search"},
+		ObjectMeta: metav1.ObjectMeta{Name: "search-v2-operator", Namespace: "test-namespace"},
+		Spec:       searchv1alpha1.SearchSpec{},
+	}
+	get := func() string {
+		return getPrometheusAlertThreshold(search, searchv1alpha1.AlertSearchPVCUsageHigh, alertThresholdPercent,
+			defaultPrometheusAlertPVCUsageWarningPercent)
+	}
+
+	// Check default value (no spec set)
+	assert.Equal(t, defaultPrometheusAlertPVCUsageWarningPercent, get())
+
+	// Test case: a threshold of another alert is not used
+	search.Spec.Monitoring.Alerts = []searchv1alpha1.AlertConfig{{
+		Name:       searchv1alpha1.AlertSearchPVCUsageCritical,
+		Thresholds: map[string]searchv1alpha1.AlertThreshold{alertThresholdPercent: "95"},
+	}}
+	assert.Equal(t, defaultPrometheusAlertPVCUsageWarningPercent, get())
+
+	// Test case: spec.monitoring.alerts sets the threshold
+	search.Spec.Monitoring.Alerts = append(search.Spec.Monitoring.Alerts, searchv1alpha1.AlertConfig{
+		Name:       searchv1alpha1.AlertSearchPVCUsageHigh,
+		Thresholds: map[string]searchv1alpha1.AlertThreshold{alertThresholdPercent: "60"},
+	})
+	assert.Equal(t, "60", get())
+}
+
+func TestGetPrometheusAlertPVCCriticalThreshold_Invalid(t *testing.T) {
+	search := &searchv1alpha1.Search{
+		TypeMeta:   metav1.TypeMeta{Kind: "Search"},
+		ObjectMeta: metav1.ObjectMeta{Name: "search-v2-operator", Namespace: "test-namespace"},
+		Spec:       searchv1alpha1.SearchSpec{},
+	}
+
+	// Test case: Values that are not numbers are not inserted into the PromQL expression
+	search.Annotations = map[string]string{AnnotationPrometheusAlertMaxAppsCount: "1 or vector(1)"}
+	assert.Equal(t, defaultPrometheusAlertMaxAppsCount, getPrometheusAlertMaxAppsCount(search))
+}
+
+func TestGetPrometheusAlertCollectorSilence(t *testing.T) {
+	search := &searchv1alpha1.Search{
+		TypeMeta:   metav1.TypeMeta{Kind: "Search"},
+		ObjectMeta: metav1.ObjectMeta{Name: "search-v2-operator", Namespace: "test-namespace"},
+		Spec:       searchv1alpha1.SearchSpec{},
+	}
+	get := func() string { return getPrometheusAlertCollectorSilence(search) }
+
+	assert.Equal(t, defaultPrometheusAlertCollectorSilence, get())
+
+	// Test case: the spec threshold is in minutes
+	search.Spec.Monitoring.Alerts = []searchv1alpha1.AlertConfig{{
+		Name:       searchv1alpha1.AlertSearchCollectorNotReporting,
+		Thresholds: map[string]searchv1alpha1.AlertThreshold{alertThresholdMinutes: "45"},
+	}}
+	assert.Equal(t, "45m", get())
+
+	// Test case: less than a minute falls back to the default
+	search.Spec.Monitoring.Alerts[0].Thresholds[alertThresholdMinutes] = "0.5"
+	assert.Equal(t, defaultPrometheusAlertCollectorSilence, get())
+}
+
+func TestGetPrometheusAlertMaxAppsCount_Spec(t *testing.T) {
+	search := &searchv1alpha1.Search{
+		TypeMeta:   metav1.TypeMeta{Kind: "Search"},
+		ObjectMeta: metav1.ObjectMeta{Name: "search-v2-operator", Namespace: "test-namespace"},
+		Spec:       searchv1alpha1.SearchSpec{},
+	}
+	t.Setenv("PROMETHEUS_ALERT_MAX_APPS_COUNT", "70")
+	search.Annotations = map[string]string{AnnotationPrometheusAlertMaxAppsCount: "80"}
+	search.Spec.Monitoring.Alerts = []searchv1alpha1.AlertConfig{{
+		Name:       searchv1alpha1.AlertSearchPVCNotPresentCritical,
+		Thresholds: map[string]searchv1alpha1.AlertThreshold{alertThresholdMaxApps: "90"},
+	}}
+	assert.Equal(t, "90", getPrometheusAlertMaxAppsCount(search))
+	// Thresholds that are not set in the spec keep their previous source
+	assert.Equal(t, defaultPrometheusAlertMaxManagedClustersCount, getPrometheusAlertMaxManagedClustersCount(search))
+}
+
+func TestIsPrometheusAlertEnabled(t *testing.T) {
+	search := &searchv1alpha1.Search{
+		TypeMeta:   metav1.TypeMeta{Kind: "Search"},
+		ObjectMeta: metav1.ObjectMeta{Name: "search-v2-operator", Namespace: "test-namespace"},
+		Spec:       searchv1alpha1.SearchSpec{},
+	}
+	assert.True(t, isPrometheusAlertEnabled(search, searchv1alpha1.AlertSearchPVCNotPresentCritical))
+
+	// Deprecated annotation still disables the critical alert
+	search.Annotations = map[string]string{AnnotationPrometheusAlertSearchPVCCriticalDisable: "true"}
+	assert.False(t, isPrometheusAlertEnabled(search, searchv1alpha1.AlertSearchPVCNotPresentCritical))
+	assert.True(t, isPrometheusAlertEnabled(search, searchv1alpha1.AlertSearchPVCUsageHigh))
+
+	// The spec wins over the annotation
+	search.Spec.Monitoring.Alerts = []searchv1alpha1.AlertConfig{
+		{Name: searchv1alpha1.AlertSearchPVCNotPresentCritical, Enabled: ptr.To(true)},
+		{Name: searchv1alpha1.AlertSearchPVCUsageHigh, Enabled: ptr.To(false)},
+	}
+	assert.True(t, isPrometheusAlertEnabled(search, searchv1alpha1.AlertSearchPVCNotPresentCritical))
+	assert.False(t, isPrometheusAlertEnabled(search, searchv1alpha1.AlertSearchPVCUsageHigh))
+}
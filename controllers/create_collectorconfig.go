@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
@@ -104,13 +105,32 @@ func excludeOverlapsIntegrationIncludes(
 	return false
 }
 
+// protectionMessages returns the messages of the protection violations of an exclude rule that
+// have the given severity (see searchv1alpha1.ProtectionViolations). The merge drops user exclude
+// rules with Reject violations, so a config admitted before the Search CR's collectorConfigPolicy
+// was tightened, or that bypassed the webhook, still can't exclude protected resources.
+func protectionMessages(
+	excludeRule searchv1alpha1.CollectionRule,
+	protected []searchv1alpha1.ProtectedResource,
+	severity searchv1alpha1.ProtectionSeverity,
+) []string {
+	var messages []string
+	for _, v := range searchv1alpha1.ProtectionViolations(excludeRule.ResourceSelector, protected) {
+		if v.Severity == severity {
+			messages = append(messages, v.Message)
+		}
+	}
+	return messages
+}
+
 // rulesOverlap returns true when two ResourceSelectors could match the same resource.
-// Wildcards ("*") in either selector match all values on the other side.
+// Wildcards ("*") in either selector match all values on the other side. Label and annotation
+// selectors narrow the match: rules only stop overlapping when those selectors are disjoint.
 func rulesOverlap(a, b searchv1alpha1.ResourceSelector) bool {
-	if !setsIntersect(a.APIGroups, b.APIGroups) {
+	if !setsIntersect(a.APIGroups, b.APIGroups) || !setsIntersect(a.Kinds, b.Kinds) {
 		return false
 	}
-	return setsIntersect(a.Kinds, b.Kinds)
+	return !searchv1alpha1.MetadataSelectorsDisjoint(a, b)
 }
 
 // setsIntersect returns true when two string slices share at least one element,
@@ -184,23 +204,76 @@ func (r *SearchReconciler) createOrUpdateMergedCollectorConfig(
 		log.Error(err, "Could not list integration team CollectorConfigs")
 		return &reconcile.Result{}, err
 	}
+	configMapsVersion, err := integrationConfigMapsVersion(ctx, r.Client, namespace)
+	if err != nil {
+		log.Error(err, "Could not list integration CollectorConfig ConfigMaps")
+		return &reconcile.Result{}, err
+	}
+	if resetRequested(teamConfigs.Items) || configMapsVersion != r.integrationConfigMapsVersion {
+		// Restore the shipped defaults, or load the changed ConfigMaps, now instead of waiting
+		// for the next operator start.
+		if err := applyIntegrationCollectorConfigs(ctx, r.Client, r.Scheme, instance); err != nil {
+			return &reconcile.Result{}, err
+		}
+		r.integrationConfigMapsVersion = configMapsVersion
+		if err := r.List(ctx, teamConfigs, client.InNamespace(namespace),
+			client.MatchingLabels{searchv1alpha1.IntegrationTeamLabel: searchv1alpha1.IntegrationTeamLabelValue},
+		); err != nil {
+			return &reconcile.Result{}, err
+		}
+	}
 
 	// Sort by name for deterministic merge order.
 	sort.Slice(teamConfigs.Items, func(i, j int) bool {
 		return teamConfigs.Items[i].Name < teamConfigs.Items[j].Name
 	})
 
-	// FUTURE: detect and handle rule collisions between integration team configs.
-
-	// Build merged spec: integration team rules first, then user rules.
+	// Build merged spec: integration team rules first, then user rules. Collisions between
+	// integration team configs are resolved here and reported on the configs involved.
 	// Also ensure each source config carries the backup label so it survives hub backup/restore.
+	// The provenance of every merged rule is recorded for the merged-collector-config status.
 	mergedSpec := searchv1alpha1.CollectorConfigSpec{}
+	provenance := &mergeProvenance{}
+	integrationRuleCount := 0
+	for _, tc := range teamConfigs.Items {
+		integrationRuleCount += len(tc.Spec.CollectionRules)
+	}
+	mergedSpec.CollectionRules, provenance.ruleSources, provenance.collisions = mergeIntegrationRules(teamConfigs.Items)
+	// A narrowed exclude rule can be split in two merged rules from the same source.
+	mergedSources := map[searchv1alpha1.RuleSource]bool{}
+	for _, source := range provenance.ruleSources {
+		mergedSources[source] = true
+	}
+	provenance.droppedRules = integrationRuleCount - len(mergedSources)
+	for _, c := range provenance.collisions {
+		log.Info("Resolved integration CollectorConfig rule collision", "configs", c.configs, "detail", c.message)
+	}
+	// Redaction rules of every config apply; redactionOwners maps each merged rule name to its config.
+	// A redaction rule whose name is taken is reported as a collision on both configs.
+	// Relationships are merged the same way.
+	redactionOwners := map[string]string{}
+	relationshipOwners := map[string]string{}
+	for i := range teamConfigs.Items {
+		var redactionCollisions []ruleCollision
+		mergedSpec.Redact, redactionCollisions = appendRedactionRules(mergedSpec.Redact, &teamConfigs.Items[i],
+			redactionOwners)
+		provenance.collisions = append(provenance.collisions, redactionCollisions...)
+		var skipped []string
+		mergedSpec.Relationships, skipped = appendRelationships(mergedSpec.Relationships, &teamConfigs.Items[i],
+			relationshipOwners)
+		for _, msg := range skipped {
+			log.Info("Skipping integration CollectorConfig relationship", "name", teamConfigs.Items[i].Name, "detail", msg)
+		}
+	}
 	for i := range teamConfigs.Items {
 		tc := &teamConfigs.Items[i]
-		mergedSpec.CollectionRules = append(mergedSpec.CollectionRules, tc.Spec.CollectionRules...)
 		if err := r.addBackupLabel(ctx, tc); err != nil {
 			return &reconcile.Result{}, err
 		}
+		if err := r.setRuleCollisionsCondition(ctx, tc, collisionMessages(provenance.collisions, tc.Name)); err != nil {
+			log.Error(err, "Could not update CollectorConfig status with rule collisions", "name", tc.Name)
+		}
+		provenance.addSource(tc)
 	}
 
 	// Get user-collector-config. Not found is fine, user may not have created one.
@@ -214,7 +287,27 @@ func (r *SearchReconciler) createOrUpdateMergedCollectorConfig(
 	}
 	if err == nil {
 		var droppedRuleMessages []string
-		for _, rule := range userCC.Spec.CollectionRules {
+		protected := instance.Spec.CollectorConfigPolicy.EffectiveProtectedResources()
+		for i, rule := range userCC.Spec.CollectionRules {
+			if rule.Action == searchv1alpha1.ActionExclude {
+				// The Search CR's collectorConfigPolicy protects these resources.
+				if rejected := protectionMessages(rule, protected, searchv1alpha1.ProtectionSeverityReject); len(rejected) > 0 {
+					msg := fmt.Sprintf("exclude rule for kinds %v (apiGroups %v) was not applied — %s",
+						rule.ResourceSelector.Kinds, rule.ResourceSelector.APIGroups, strings.Join(rejected, "; "))
+					log.Info("Skipping user exclude rule — resource is protected by the collectorConfigPolicy",
+						"kinds", rule.ResourceSelector.Kinds,
+						"apiGroups", rule.ResourceSelector.APIGroups)
+					droppedRuleMessages = append(droppedRuleMessages, msg)
+					provenance.droppedRules++
+					continue
+				}
+				if warned := protectionMessages(rule, protected, searchv1alpha1.ProtectionSeverityWarn); len(warned) > 0 {
+					log.Info("Applying user exclude rule for a resource the collectorConfigPolicy warns about",
+						"kinds", rule.ResourceSelector.Kinds,
+						"apiGroups", rule.ResourceSelector.APIGroups,
+						"warnings", warned)
+				}
+			}
 			// Integration team wins: drop user exclude rules that overlap with any
 			// integration team include so they cannot suppress integration-required collection.
 			if rule.Action == searchv1alpha1.ActionExclude &&
@@ -227,13 +320,21 @@ func (r *SearchReconciler) createOrUpdateMergedCollectorConfig(
 					"kinds", rule.ResourceSelector.Kinds,
 					"apiGroups", rule.ResourceSelector.APIGroups)
 				droppedRuleMessages = append(droppedRuleMessages, msg)
+				provenance.droppedRules++
 				continue
 			}
-			mergedSpec.CollectionRules = append(mergedSpec.CollectionRules, rule)
-		}
-		if userCC.Spec.CollectNamespaces != nil {
-			mergedSpec.CollectNamespaces = userCC.Spec.CollectNamespaces.DeepCopy()
+			mergedSpec.CollectionRules = append(mergedSpec.CollectionRules, scopedRule(userCC, rule))
+			provenance.ruleSources = append(provenance.ruleSources,
+				searchv1alpha1.RuleSource{Source: userCollectorConfigName, Index: i})
 		}
+		var skipped []string
+		mergedSpec.CollectNamespaces, skipped = mergeCollectNamespaces(teamConfigs.Items, userCC)
+		droppedRuleMessages = append(droppedRuleMessages, skipped...)
+		var redactionCollisions []ruleCollision
+		mergedSpec.Redact, redactionCollisions = appendRedactionRules(mergedSpec.Redact, userCC, redactionOwners)
+		droppedRuleMessages = append(droppedRuleMessages, collisionMessages(redactionCollisions, "")...)
+		mergedSpec.Relationships, skipped = appendRelationships(mergedSpec.Relationships, userCC, relationshipOwners)
+		droppedRuleMessages = append(droppedRuleMessages, skipped...)
 		if err := r.addBackupLabel(ctx, userCC); err != nil {
 			return &reconcile.Result{}, err
 		}
@@ -241,7 +342,12 @@ func (r *SearchReconciler) createOrUpdateMergedCollectorConfig(
 		if err := r.updateUserCCStatus(ctx, userCC, droppedRuleMessages); err != nil {
 			log.Error(err, "Could not update user-collector-config status after dropping rules")
 		}
+		provenance.addSource(userCC)
+	} else {
+		mergedSpec.CollectNamespaces, _ = mergeCollectNamespaces(teamConfigs.Items, nil)
 	}
+	provenance.edgeTypes = edgeTypes(mergedSpec.Relationships, relationshipOwners)
+	provenanceValue := provenanceAnnotation(provenance.ruleSources)
 
 	// Ensure non-nil slice so DeepEqual works consistently.
 	if mergedSpec.CollectionRules == nil {
@@ -261,8 +367,9 @@ func (r *SearchReconciler) createOrUpdateMergedCollectorConfig(
 				APIVersion: searchv1alpha1.GroupVersion.String(),
 			},
 			ObjectMeta: metav1.ObjectMeta{
-				Name:      mergedCollectorConfigName,
-				Namespace: namespace,
+				Name:        mergedCollectorConfigName,
+				Namespace:   namespace,
+				Annotations: map[string]string{searchv1alpha1.AnnotationMergeProvenance: provenanceValue},
 			},
 			Spec: mergedSpec,
 		}
@@ -276,20 +383,38 @@ func (r *SearchReconciler) createOrUpdateMergedCollectorConfig(
 			return &reconcile.Result{}, err
 		}
 		log.V(2).Info("Created merged-collector-config", "ruleCount", len(mergedSpec.CollectionRules))
-		return nil, nil
+		return r.commitMergedCCStatus(ctx, merged, provenance)
 	} else if err != nil {
 		return &reconcile.Result{}, err
 	}
 
-	// Update only if the spec has changed.
-	if !equality.Semantic.DeepEqual(found.Spec, mergedSpec) {
+	// Update only if the spec or the provenance annotation has changed.
+	if !equality.Semantic.DeepEqual(found.Spec, mergedSpec) ||
+		found.Annotations[searchv1alpha1.AnnotationMergeProvenance] != provenanceValue {
 		found.Spec = mergedSpec
+		if found.Annotations == nil {
+			found.Annotations = map[string]string{}
+		}
+		found.Annotations[searchv1alpha1.AnnotationMergeProvenance] = provenanceValue
 		if err := r.Update(ctx, found); err != nil {
 			log.Error(err, "Could not update merged-collector-config")
 			return &reconcile.Result{}, err
 		}
 		log.V(2).Info("Updated merged-collector-config", "ruleCount", len(mergedSpec.CollectionRules))
 	}
+	return r.commitMergedCCStatus(ctx, found, provenance)
+}
 
+// commitMergedCCStatus updates the merged-collector-config status, and requeues when the config
+// changed since it was read.
+func (r *SearchReconciler) commitMergedCCStatus(ctx context.Context, merged *searchv1alpha1.CollectorConfig,
+	provenance *mergeProvenance) (*reconcile.Result, error) {
+	if err := r.updateMergedCCStatus(ctx, merged, provenance); err != nil {
+		if errors.IsConflict(err) {
+			log.V(2).Info("merged-collector-config was modified, will retry its status update")
+			return &reconcile.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+		log.Error(err, "Could not update merged-collector-config status")
+	}
 	return nil, nil
 }
@@ -8,6 +8,7 @@ import (
 	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -210,7 +211,7 @@ func TestMerge_OnlyLabeledConfigsIncluded(t *testing.T) {
 		CollectionRules: []searchv1alpha1.CollectionRule{
 			{
 				Action:           searchv1alpha1.ActionExclude,
-				ResourceSelector: searchv1alpha1.ResourceSelector{APIGroups: []string{""}, Kinds: []string{"Secret"}},
+				ResourceSelector: searchv1alpha1.ResourceSelector{APIGroups: []string{"coordination.k8s.io"}, Kinds: []string{"Lease"}},
 			},
 		},
 	})
@@ -300,7 +301,7 @@ func TestMerge_BothExist(t *testing.T) {
 		CollectionRules: []searchv1alpha1.CollectionRule{
 			{
 				Action:           searchv1alpha1.ActionExclude,
-				ResourceSelector: searchv1alpha1.ResourceSelector{APIGroups: []string{""}, Kinds: []string{"Secret"}},
+				ResourceSelector: searchv1alpha1.ResourceSelector{APIGroups: []string{"coordination.k8s.io"}, Kinds: []string{"Lease"}},
 			},
 		},
 	})
@@ -399,7 +400,7 @@ func TestMerge_UserDeleted(t *testing.T) {
 		CollectionRules: []searchv1alpha1.CollectionRule{
 			{
 				Action:           searchv1alpha1.ActionExclude,
-				ResourceSelector: searchv1alpha1.ResourceSelector{APIGroups: []string{""}, Kinds: []string{"Secret"}},
+				ResourceSelector: searchv1alpha1.ResourceSelector{APIGroups: []string{"coordination.k8s.io"}, Kinds: []string{"Lease"}},
 			},
 		},
 		CollectNamespaces: &searchv1alpha1.CollectNamespaces{
@@ -452,7 +453,7 @@ func TestMerge_CollectAdditionalPrinterColumnsPropagated(t *testing.T) {
 		CollectionRules: []searchv1alpha1.CollectionRule{
 			{
 				Action:           searchv1alpha1.ActionExclude,
-				ResourceSelector: searchv1alpha1.ResourceSelector{APIGroups: []string{""}, Kinds: []string{"Secret"}},
+				ResourceSelector: searchv1alpha1.ResourceSelector{APIGroups: []string{"coordination.k8s.io"}, Kinds: []string{"Lease"}},
 			},
 		},
 	})
@@ -731,6 +732,55 @@ func TestMerge_UserExcludeKeptWhenNoIntegrationOverlap(t *testing.T) {
 	assert.Equal(t, searchv1alpha1.ActionExclude, merged.Spec.CollectionRules[1].Action)
 }
 
+// A user exclude narrowed by a label selector that cannot match the integration include is kept,
+// and the merged config carries both selectors.
+func TestMerge_UserExcludeKeptWhenLabelSelectorsDisjoint(t *testing.T) {
+	instance := newSearchInstance()
+	teamCC := newIntegrationTeamConfig("tekton", searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{
+				Action: searchv1alpha1.ActionInclude,
+				ResourceSelector: searchv1alpha1.ResourceSelector{
+					APIGroups:     []string{""},
+					Kinds:         []string{"ConfigMap"},
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"owner": "tekton"}},
+				},
+			},
+		},
+	})
+	userCC := newCollectorConfig(userCollectorConfigName, searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{
+				Action: searchv1alpha1.ActionExclude,
+				ResourceSelector: searchv1alpha1.ResourceSelector{
+					APIGroups:     []string{""},
+					Kinds:         []string{"ConfigMap"},
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"owner": "argo"}},
+				},
+			},
+			{
+				Action: searchv1alpha1.ActionExclude,
+				ResourceSelector: searchv1alpha1.ResourceSelector{
+					APIGroups:          []string{""},
+					Kinds:              []string{"ConfigMap"},
+					AnnotationSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"search/hidden": "true"}},
+				},
+			},
+		},
+	})
+	r := setupReconciler(instance, teamCC, userCC)
+
+	result, err := r.createOrUpdateMergedCollectorConfig(context.TODO(), instance)
+	assert.Nil(t, err)
+	assert.Nil(t, result)
+
+	merged, err := getMergedConfig(r)
+	assert.Nil(t, err)
+	assert.Len(t, merged.Spec.CollectionRules, 2, "the owner=argo exclude is kept, the annotation exclude may overlap")
+	assert.Equal(t, map[string]string{"owner": "tekton"}, merged.Spec.CollectionRules[0].ResourceSelector.LabelSelector.MatchLabels)
+	assert.Equal(t, map[string]string{"owner": "argo"}, merged.Spec.CollectionRules[1].ResourceSelector.LabelSelector.MatchLabels)
+}
+
 // User exclude with wildcard kind is dropped when integration team includes any kind in same group.
 func TestMerge_UserWildcardExcludeDroppedWhenIntegrationIncludesInGroup(t *testing.T) {
 	instance := newSearchInstance()
@@ -826,3 +876,48 @@ func TestMerge_UserIncludeNotAffectedByExcludeProtection(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Len(t, merged.Spec.CollectionRules, 2, "Both include rules should be in merged — include is never filtered")
 }
+
+// User exclude rules are checked against the Search CR's collectorConfigPolicy: a Reject entry
+// drops the rule and reports it, a Warn entry keeps it.
+func TestMerge_UserExcludeCheckedAgainstCollectorConfigPolicy(t *testing.T) {
+	instance := newSearchInstance()
+	instance.Spec.CollectorConfigPolicy.ProtectedResources = []searchv1alpha1.ProtectedResource{
+		{APIGroup: "", Severity: searchv1alpha1.ProtectionSeverityWarn},
+		{APIGroup: "rbac.example.com", Kind: "AccessGrant", Severity: searchv1alpha1.ProtectionSeverityReject},
+	}
+	userCC := newCollectorConfig(userCollectorConfigName, searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{
+				Action:           searchv1alpha1.ActionExclude,
+				ResourceSelector: searchv1alpha1.ResourceSelector{APIGroups: []string{""}, Kinds: []string{"Secret"}},
+			},
+			{
+				Action:           searchv1alpha1.ActionExclude,
+				ResourceSelector: searchv1alpha1.ResourceSelector{APIGroups: []string{"rbac.example.com"}, Kinds: []string{"*"}},
+			},
+			{
+				Action:           searchv1alpha1.ActionExclude,
+				ResourceSelector: searchv1alpha1.ResourceSelector{APIGroups: []string{"config.openshift.io"}, Kinds: []string{"*"}},
+			},
+		},
+	})
+	r := setupReconciler(instance, userCC)
+
+	result, err := r.createOrUpdateMergedCollectorConfig(context.TODO(), instance)
+	assert.Nil(t, err)
+	assert.Nil(t, result)
+
+	merged, err := getMergedConfig(r)
+	assert.Nil(t, err)
+	require.Len(t, merged.Spec.CollectionRules, 1, "only the core group exclude, loosened to Warn, is kept")
+	assert.Equal(t, []string{"Secret"}, merged.Spec.CollectionRules[0].ResourceSelector.Kinds)
+
+	updated := &searchv1alpha1.CollectorConfig{}
+	nn := types.NamespacedName{Name: userCollectorConfigName, Namespace: testNamespace}
+	assert.Nil(t, r.Get(context.TODO(), nn, updated))
+	cond := apimeta.FindStatusCondition(updated.Status.Conditions, searchv1alpha1.CollectorConfigConditionApplied)
+	require.NotNil(t, cond)
+	assert.Equal(t, searchv1alpha1.CollectorConfigReasonRulesSkipped, cond.Reason)
+	assert.Contains(t, cond.Message, "AccessGrant")
+	assert.Contains(t, cond.Message, "config.openshift.io")
+}
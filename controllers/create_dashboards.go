@@ -0,0 +1,111 @@
+// Copyright Contributors to the Open Cluster Management project
+package controllers
+
+import (
+	"context"
+	"io/fs"
+	"path"
+	"strings"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	integrationconfigs "github.com/stolostron/search-v2-operator/config"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// dashboardPrefix is prepended to the dashboard file name to build the ConfigMap name.
+	dashboardPrefix = "search-dashboard-"
+	// grafanaDashboardLabel is the label the Grafana dashboard sidecar watches for.
+	grafanaDashboardLabel = "grafana_dashboard"
+)
+
+// dashboardFiles returns the embedded dashboard file names, keyed by ConfigMap name.
+func dashboardFiles(fsys fs.FS, dir string) (map[string]string, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]string{}
+	for _, e := range entries {
+		if e.IsDir() || path.Ext(e.Name()) != ".json" {
+			continue
+		}
+		files[dashboardPrefix+strings.TrimSuffix(e.Name(), ".json")] = e.Name()
+	}
+	return files, nil
+}
+
+// DashboardConfigmap returns the ConfigMap for one dashboard. The Grafana sidecar loads every
+// ConfigMap with the grafana_dashboard label, so no Grafana configuration is needed here.
+func (r *SearchReconciler) DashboardConfigmap(instance *searchv1alpha1.Search, name, file,
+	content string) *corev1.ConfigMap {
+	labels := generateLabels("name", name)
+	labels[grafanaDashboardLabel] = "1"
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: instance.GetNamespace(),
+			Labels:    labels,
+		},
+		Data: map[string]string{
+			file: content,
+		},
+	}
+	if err := controllerutil.SetControllerReference(instance, cm, r.Scheme); err != nil {
+		log.V(2).Info("Could not set control for dashboard configmap", "name", name)
+	}
+	return cm
+}
+
+// reconcileDashboards creates the dashboard ConfigMaps when spec.monitoring.dashboards.enabled
+// is set, and removes them when it is not.
+func (r *SearchReconciler) reconcileDashboards(ctx context.Context,
+	instance *searchv1alpha1.Search) (*reconcile.Result, error) {
+	return r.reconcileDashboardsFromFS(ctx, instance, integrationconfigs.DashboardsFS,
+		integrationconfigs.DashboardsDir)
+}
+
+// reconcileDashboardsFromFS is reconcileDashboards with the dashboard source injected for tests.
+func (r *SearchReconciler) reconcileDashboardsFromFS(ctx context.Context, instance *searchv1alpha1.Search,
+	fsys fs.FS, dir string) (*reconcile.Result, error) {
+	files, err := dashboardFiles(fsys, dir)
+	if err != nil {
+		log.Error(err, "Could not read embedded dashboards")
+		return &reconcile.Result{}, err
+	}
+
+	for name, file := range files {
+		if !instance.Spec.Monitoring.Dashboards.Enabled {
+			// Read from the cache first, so a disabled dashboard costs no API call per reconcile.
+			cm := &corev1.ConfigMap{}
+			err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: instance.GetNamespace()}, cm)
+			if errors.IsNotFound(err) {
+				continue
+			} else if err != nil {
+				log.Error(err, "Could not get configmap "+name)
+				return &reconcile.Result{}, err
+			}
+			if err := r.Delete(ctx, cm); err != nil && !errors.IsNotFound(err) {
+				log.Error(err, "Could not delete configmap "+name)
+				return &reconcile.Result{}, err
+			}
+			log.Info("Deleted dashboard configmap " + name)
+			continue
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, file))
+		if err != nil {
+			log.Error(err, "Could not read embedded dashboard", "file", file)
+			return &reconcile.Result{}, err
+		}
+		result, err := r.createOrUpdateConfigMap(ctx, r.DashboardConfigmap(instance, name, file, string(content)))
+		if result != nil {
+			return result, err
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,76 @@
+// Copyright Contributors to the Open Cluster Management project
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"testing/fstest"
+
+	integrationconfigs "github.com/stolostron/search-v2-operator/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestEmbeddedDashboards(t *testing.T) {
+	files, err := dashboardFiles(integrationconfigs.DashboardsFS, integrationconfigs.DashboardsDir)
+	require.NoError(t, err)
+	assert.Contains(t, files, "search-dashboard-search-indexer")
+	assert.Contains(t, files, "search-dashboard-search-postgres")
+
+	for name, file := range files {
+		data, err := integrationconfigs.DashboardsFS.ReadFile(integrationconfigs.DashboardsDir + "/" + file)
+		require.NoError(t, err)
+		dashboard := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(data, &dashboard), "dashboard %s is not valid JSON", file)
+		assert.NotEmpty(t, dashboard["uid"], "dashboard %s needs a stable uid", file)
+		assert.LessOrEqual(t, len(name), 63)
+	}
+}
+
+func TestReconcileDashboards(t *testing.T) {
+	ctx := context.TODO()
+	instance := newSearchInstance()
+	instance.Spec.Monitoring.Dashboards.Enabled = true
+	r := setupExporterReconciler(t, instance)
+	fsys := fstest.MapFS{
+		"dashboards/search-api.json": {Data: []byte(`{"uid":"search-api"}`)},
+		"dashboards/README.md":       {Data: []byte("ignored")},
+	}
+
+	result, err := r.reconcileDashboardsFromFS(ctx, instance, fsys, "dashboards")
+	require.NoError(t, err)
+	assert.Nil(t, result)
+
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, r.Get(ctx, types.NamespacedName{Name: "search-dashboard-search-api", Namespace: testNamespace}, cm))
+	assert.Equal(t, "1", cm.Labels[grafanaDashboardLabel])
+	assert.Equal(t, `{"uid":"search-api"}`, cm.Data["search-api.json"])
+	assert.Len(t, cm.OwnerReferences, 1)
+
+	// Disabling the dashboards removes the ConfigMaps.
+	instance.Spec.Monitoring.Dashboards.Enabled = false
+	result, err = r.reconcileDashboardsFromFS(ctx, instance, fsys, "dashboards")
+	require.NoError(t, err)
+	assert.Nil(t, result)
+	err = r.Get(ctx, types.NamespacedName{Name: "search-dashboard-search-api", Namespace: testNamespace}, cm)
+	assert.True(t, errors.IsNotFound(err))
+
+	// Nothing to delete is not an error, and makes no delete calls.
+	deletes := 0
+	r.Client = interceptor.NewClient(r.Client.(client.WithWatch), interceptor.Funcs{
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			deletes++
+			return c.Delete(ctx, obj, opts...)
+		},
+	})
+	result, err = r.reconcileDashboardsFromFS(ctx, instance, fsys, "dashboards")
+	require.NoError(t, err)
+	assert.Nil(t, result)
+	assert.Zero(t, deletes)
+}
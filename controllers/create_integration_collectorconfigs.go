@@ -4,8 +4,8 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"io/fs"
-	"sort"
 
 	integrationconfigs "github.com/stolostron/search-v2-operator/config"
 
@@ -20,19 +20,18 @@ import (
 	"sigs.k8s.io/yaml"
 )
 
-// applyIntegrationCollectorConfigs creates or overwrites the initial integration team
+// applyIntegrationCollectorConfigs creates or updates the initial integration team
 // CollectorConfig CRs (CNV, OLM, GRC, Kyverno, Gatekeeper, Argo, ACM app lifecycle, etc.) from
 // the manifests embedded in config/integration_collector_configs/.
 //
 // Integration teams contribute a plain CollectorConfig YAML file to that directory instead of
 // writing Go code. This runs exactly once per operator process, at startup (see
-// IntegrationCollectorConfigSeeder) — not on every reconcile. That is what lets a team edit their
-// config directly and have it persist for the life of that pod: the canonical, fixed name always
-// gets reset to whatever's currently embedded on the next pod start, but is left alone in
-// between. A team that wants a change to survive across restarts before it ships officially
-// creates a differently-named CollectorConfig (still carrying the integration label) instead of
-// editing the canonical one — the merge step already discovers integration configs by label, not
-// name, so it picks up any number of them automatically.
+// IntegrationCollectorConfigSeeder) — not on every reconcile. Existing configs are updated with a
+// three-way merge against the default last applied, so upstream fixes flow in on the next pod
+// start while local edits to other fields survive (see applyOneIntegrationCollectorConfig). A
+// team can also create a differently-named CollectorConfig (still carrying the integration label)
+// — the merge step already discovers integration configs by label, not name, so it picks up any
+// number of them automatically.
 //
 // Reads from the real embedded config/integration_collector_configs/ directory; see
 // applyIntegrationCollectorConfigsFrom for the testable, FS-injectable version.
@@ -45,63 +44,106 @@ func applyIntegrationCollectorConfigs(
 // applyIntegrationCollectorConfigsFrom is applyIntegrationCollectorConfigs with the filesystem and
 // directory injected, so tests can exercise malformed-manifest and read-error paths with a
 // fstest.MapFS instead of editing the real embedded YAMLs.
+//
+// Besides the embedded manifests it loads the external sources (see
+// readExternalIntegrationManifests), records each config's source in AnnotationIntegrationSource,
+// and deletes the configs whose source is gone.
 func applyIntegrationCollectorConfigsFrom(
 	ctx context.Context, c client.Client, scheme *runtime.Scheme, owner *searchv1alpha1.Search,
 	fsys fs.FS, dir string,
 ) error {
-	entries, err := fs.ReadDir(fsys, dir)
+	manifests, err := readIntegrationManifests(fsys, dir, integrationSourceEmbedded)
 	if err != nil {
 		log.Error(err, "Could not read embedded integration_collector_configs directory")
 		return err
 	}
-
-	// Sort for deterministic, readable logs (fs.ReadDir is already sorted, but be explicit).
-	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
-
+	for i := range manifests {
+		manifests[i].shipped = true
+	}
 	namespace := owner.Namespace
+	external, complete := readExternalIntegrationManifests(ctx, c, namespace)
+	manifests = append(manifests, external...)
+
 	var firstErr error
-	for _, entry := range entries {
-		if entry.IsDir() {
+	// sources maps each source to the name of the config it defines, or "" when it defines none.
+	sources := map[string]string{}
+	// claimed maps each config name to the first source that defines it.
+	claimed := map[string]string{}
+	for _, m := range manifests {
+		sources[m.source] = ""
+		desired, err := parseIntegrationManifest(m)
+		if err == nil && desired != nil && claimed[desired.Name] != "" {
+			err = fmt.Errorf("CollectorConfig %s is already defined by %s", desired.Name, claimed[desired.Name])
+		}
+		if err != nil {
+			log.Error(err, "Could not load integration CollectorConfig", "source", m.source)
+			// A broken manifest shipped with the operator is returned so the seeder retries.
+			// Retrying does not fix external manifests, those are only logged.
+			if m.shipped && firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if desired == nil {
+			log.Info("Skipping integration CollectorConfig with no metadata.name", "source", m.source)
 			continue
 		}
-		if err := applyOneIntegrationCollectorConfig(ctx, c, scheme, owner, namespace, fsys, dir, entry.Name()); err != nil {
+		sources[m.source] = desired.Name
+		claimed[desired.Name] = m.source
+		if err := applyOneIntegrationCollectorConfig(ctx, c, scheme, owner, namespace, desired, m.source); err != nil {
 			// Log and continue — one bad or temporarily broken config must not prevent the
 			// remaining integrations from being applied. The seeder retries the whole batch on
 			// the next interval, so a transient failure here is self-healing.
-			log.Error(err, "Failed to apply integration CollectorConfig, continuing with the rest", "file", entry.Name())
+			log.Error(err, "Failed to apply integration CollectorConfig, continuing with the rest", "source", m.source)
 			if firstErr == nil {
 				firstErr = err
 			}
 		}
 	}
+
+	// Only prune when every source could be read, so an unreadable volume or a failed list
+	// doesn't delete the configs it would have defined.
+	if complete {
+		if err := pruneIntegrationCollectorConfigs(ctx, c, namespace, sources); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
 	return firstErr
 }
 
-// applyOneIntegrationCollectorConfig unconditionally creates or overwrites a single embedded
-// integration CollectorConfig manifest — no diffing, no hash comparison. See
-// applyIntegrationCollectorConfigsFrom for why an unconditional overwrite is safe here.
-func applyOneIntegrationCollectorConfig(
-	ctx context.Context, c client.Client, scheme *runtime.Scheme, owner *searchv1alpha1.Search,
-	namespace string, fsys fs.FS, dir, filename string,
-) error {
-	raw, err := fs.ReadFile(fsys, dir+"/"+filename)
-	if err != nil {
-		log.Error(err, "Could not read embedded integration CollectorConfig", "file", filename)
-		return err
+// parseIntegrationManifest parses and validates an integration CollectorConfig manifest. It
+// returns nil when the manifest has no metadata.name.
+func parseIntegrationManifest(m integrationManifest) (*searchv1alpha1.CollectorConfig, error) {
+	if m.err != nil {
+		return nil, m.err
 	}
-
 	desired := &searchv1alpha1.CollectorConfig{}
-	if err := yaml.Unmarshal(raw, desired); err != nil {
-		log.Error(err, "Could not parse embedded integration CollectorConfig", "file", filename)
-		return err
+	if err := yaml.Unmarshal(m.raw, desired); err != nil {
+		return nil, err
 	}
 	if desired.Name == "" {
-		log.Info("Skipping embedded integration CollectorConfig with no metadata.name", "file", filename)
-		return nil
+		return nil, nil
 	}
+	if desired.Name == userCollectorConfigName || desired.Name == mergedCollectorConfigName {
+		return nil, fmt.Errorf("%s is reserved and cannot be an integration CollectorConfig", desired.Name)
+	}
+	if err := desired.Validate(); err != nil {
+		return nil, err
+	}
+	return desired, nil
+}
 
+// applyOneIntegrationCollectorConfig creates a single integration CollectorConfig, or merges the
+// manifest into the live config: changes to the shipped default flow in, edits to other fields are
+// kept, and conflicts are reported in the DefaultConflicts condition (see mergeIntegrationSpec).
+// The AnnotationReset annotation restores the default, and AnnotationManualOverride leaves the
+// config alone entirely.
+func applyOneIntegrationCollectorConfig(
+	ctx context.Context, c client.Client, scheme *runtime.Scheme, owner *searchv1alpha1.Search,
+	namespace string, desired *searchv1alpha1.CollectorConfig, source string,
+) error {
 	found := &searchv1alpha1.CollectorConfig{}
-	err = c.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: namespace}, found)
+	err := c.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: namespace}, found)
 	if errors.IsNotFound(err) {
 		cc := &searchv1alpha1.CollectorConfig{
 			TypeMeta: metav1.TypeMeta{
@@ -119,6 +161,10 @@ func applyOneIntegrationCollectorConfig(
 			cc.Labels = map[string]string{}
 		}
 		cc.Labels[searchv1alpha1.IntegrationTeamLabel] = searchv1alpha1.IntegrationTeamLabelValue
+		if err := setLastAppliedDefault(cc, desired.Spec); err != nil {
+			return err
+		}
+		cc.Annotations[searchv1alpha1.AnnotationIntegrationSource] = source
 		// Set the Search CR as controller-owner so this config is garbage-collected when
 		// Search is torn down, consistent with other operator-managed resources.
 		if scheme != nil && owner != nil && owner.UID != "" {
@@ -145,36 +191,67 @@ func applyOneIntegrationCollectorConfig(
 		return nil
 	}
 
-	hasAllLabels := hasDesiredLabels(found, desired)
-	hasOwnerRef := hasControllerOwnerRef(found, owner)
-	if hasAllLabels && hasOwnerRef && equality.Semantic.DeepEqual(found.Spec, desired.Spec) {
-		// Already matches the currently shipped default, correctly labeled, and owned — skip.
-		return nil
+	// Three-way merge between the last applied default, the shipped default and the live spec,
+	// unless the user asked for the default back.
+	spec := desired.Spec
+	var conflicts []string
+	if _, reset := found.Annotations[searchv1alpha1.AnnotationReset]; !reset {
+		base := found.Spec
+		if lastApplied, ok := lastAppliedDefault(found); ok {
+			base = *lastApplied
+		}
+		if spec, conflicts, err = mergeIntegrationSpec(base, desired.Spec, found.Spec); err != nil {
+			log.Error(err, "Could not merge integration CollectorConfig with the shipped default", "name", found.Name)
+			return err
+		}
+	}
+
+	updated := found.DeepCopy()
+	updated.Spec = spec
+	if updated.Annotations == nil {
+		updated.Annotations = map[string]string{}
+	}
+	updated.Annotations[searchv1alpha1.AnnotationIntegrationSource] = source
+	delete(updated.Annotations, searchv1alpha1.AnnotationReset)
+	// The base only moves on once the merge is clean, so a conflict is reported again on every
+	// run until it is resolved.
+	if len(conflicts) == 0 {
+		if err := setLastAppliedDefault(updated, desired.Spec); err != nil {
+			return err
+		}
 	}
-	found.Spec = desired.Spec
-	if found.Labels == nil {
-		found.Labels = map[string]string{}
+	if updated.Labels == nil {
+		updated.Labels = map[string]string{}
 	}
 	// Merge all labels from the shipped YAML, then enforce the integration label on top.
 	// This makes Create and Update symmetric: the shipped YAML's labels are always the
 	// source of truth — if someone removes the backup label from a live config, the seeder
 	// restores it on the next restart.
 	for k, v := range desired.Labels {
-		found.Labels[k] = v
+		updated.Labels[k] = v
 	}
-	found.Labels[searchv1alpha1.IntegrationTeamLabel] = searchv1alpha1.IntegrationTeamLabelValue
+	updated.Labels[searchv1alpha1.IntegrationTeamLabel] = searchv1alpha1.IntegrationTeamLabelValue
 	// Ensure ownerReference is set for GC when Search is torn down.
-	if scheme != nil && owner != nil && owner.UID != "" && !hasOwnerRef {
-		if err := controllerutil.SetControllerReference(owner, found, scheme); err != nil {
+	if scheme != nil && owner != nil && owner.UID != "" && !hasControllerOwnerRef(found, owner) {
+		if err := controllerutil.SetControllerReference(owner, updated, scheme); err != nil {
 			log.Error(err, "Could not set ownerReference on integration CollectorConfig", "name", desired.Name)
 			return err
 		}
 	}
-	if err := c.Update(ctx, found); err != nil {
-		log.Error(err, "Could not overwrite integration CollectorConfig", "name", desired.Name)
+
+	if !equality.Semantic.DeepEqual(updated.ObjectMeta, found.ObjectMeta) ||
+		!equality.Semantic.DeepEqual(updated.Spec, found.Spec) {
+		if err := c.Update(ctx, updated); err != nil {
+			log.Error(err, "Could not update integration CollectorConfig", "name", desired.Name)
+			return err
+		}
+		log.Info("Merged the shipped default into integration CollectorConfig", "name", desired.Name,
+			"conflicts", len(conflicts))
+	}
+	if err := setDefaultConflictsCondition(ctx, c, updated, conflicts); err != nil {
+		log.Error(err, "Could not update integration CollectorConfig status", "name", desired.Name)
 		return err
 	}
-	log.Info("Overwrote integration CollectorConfig with the currently shipped default", "name", desired.Name)
 	return nil
 }
 
@@ -191,17 +268,3 @@ func hasControllerOwnerRef(obj metav1.Object, owner *searchv1alpha1.Search) bool
 	}
 	return false
 }
-
-// hasDesiredLabels returns true if found already has every label from desired (including the
-// integration team label). Used to avoid redundant updates when labels already match.
-func hasDesiredLabels(found, desired *searchv1alpha1.CollectorConfig) bool {
-	if found.Labels == nil {
-		return len(desired.Labels) == 0
-	}
-	for k, v := range desired.Labels {
-		if found.Labels[k] != v {
-			return false
-		}
-	}
-	return found.Labels[searchv1alpha1.IntegrationTeamLabel] == searchv1alpha1.IntegrationTeamLabelValue
-}
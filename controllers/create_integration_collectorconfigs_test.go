@@ -41,6 +41,7 @@ var expectedIntegrationConfigNames = []string{
 	"gatekeeper-integration",
 	"argo-integration",
 	"app-lifecycle-integration",
+	"redaction-integration",
 }
 
 func TestApplyIntegrationCollectorConfigs_CreatesAllEmbeddedConfigs(t *testing.T) {
@@ -54,7 +55,8 @@ func TestApplyIntegrationCollectorConfigs_CreatesAllEmbeddedConfigs(t *testing.T
 		err := r.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: testNamespace}, cc)
 		require.NoError(t, err, "expected %s to be created", name)
 		assert.Equal(t, searchv1alpha1.IntegrationTeamLabelValue, cc.Labels[searchv1alpha1.IntegrationTeamLabel])
-		assert.NotEmpty(t, cc.Spec.CollectionRules, "%s should have collection rules", name)
+		assert.True(t, len(cc.Spec.CollectionRules) > 0 || len(cc.Spec.Redact) > 0,
+			"%s should have collection or redaction rules", name)
 	}
 }
 
@@ -79,12 +81,9 @@ func TestApplyIntegrationCollectorConfigs_NoOpWhenAlreadyUpToDate(t *testing.T)
 	assert.Equal(t, before.ResourceVersion, after.ResourceVersion, "unchanged config should not be updated")
 }
 
-// This is the deliberate tradeoff: applyIntegrationCollectorConfigs always resets the canonical
-// name to the currently shipped default, even if it was customized. It's only ever called once
-// per operator process at startup (see IntegrationCollectorConfigSeeder), not on every reconcile —
-// so a customization survives for the life of the pod, but is reset on the next restart/upgrade.
-// A team that wants a change to persist across restarts before it's officially shipped should use
-// a different name for their CollectorConfig instead of editing the canonical one.
+// A canonical config written before the last-applied-default annotation existed has no merge base,
+// so it is reset to the currently shipped default once, like the old overwrite did. From then on
+// the three-way merge keeps local edits (see integration_collectorconfig_merge_test.go).
 func TestApplyIntegrationCollectorConfigs_OverwritesCustomizedCanonicalConfig(t *testing.T) {
 	customized := newIntegrationTeamConfig("cnv-integration", searchv1alpha1.CollectorConfigSpec{
 		CollectionRules: []searchv1alpha1.CollectionRule{
@@ -105,7 +104,7 @@ func TestApplyIntegrationCollectorConfigs_OverwritesCustomizedCanonicalConfig(t
 		Name: "cnv-integration", Namespace: testNamespace,
 	}, after))
 	assert.NotEqual(t, customized.Spec, after.Spec,
-		"canonical config is reset to the shipped default on every seeder run, regardless of customization")
+		"canonical config without a merge base is reset to the shipped default")
 }
 
 // If a user annotates an integration CollectorConfig with the manual-override annotation, the
@@ -28,6 +28,7 @@ const (
 // and the operator's own metrics/webhook ports in config/manager/manager.yaml.
 const (
 	postgresPort        = 5432
+	postgresMetricsPort = 9187
 	indexerPort         = 3010
 	apiPort             = 4010
 	collectorPort       = 5010
@@ -118,6 +119,9 @@ func setNPControllerRef(r *SearchReconciler, instance *searchv1alpha1.Search, np
 //     read-only GraphQL queries) need direct DB access. search-mcp-server is granted a
 //     read-only DB role (see create_pgsecret.go) and connects directly, so it also needs
 //     ingress access when deployed in the same namespace.
+//     The search-postgres-field-indexes Job connects to create indexes for custom fields.
+//   - Ingress from openshift-monitoring to the postgres_exporter sidecar, only when
+//     spec.monitoring.databaseExporter is enabled.
 //   - Egress: PostgreSQL never initiates outbound connections, so no egress is required.
 func (r *SearchReconciler) PostgresNetworkPolicy(instance *searchv1alpha1.Search) *networkingv1.NetworkPolicy {
 	podLabels := generateLabels("name", postgresDeploymentName)
@@ -128,10 +132,14 @@ func (r *SearchReconciler) PostgresNetworkPolicy(instance *searchv1alpha1.Search
 				podSelectorPeer(generateLabels("name", indexerDeploymentName)),
 				podSelectorPeer(generateLabels("name", apiDeploymentName)),
 				podSelectorPeer(map[string]string{"app.kubernetes.io/name": "acm-mcp-server"}),
+				podSelectorPeer(generateLabels("name", fieldIndexesName)),
 			},
 			Ports: tcpPort(postgresPort),
 		},
 	}
+	if isPostgresExporterEnabled(instance) {
+		np.Spec.Ingress = append(np.Spec.Ingress, monitoringIngressRule(postgresMetricsPort))
+	}
 	// Egress: PostgreSQL never initiates outbound connections so all egress is denied.
 	// withEgress() adds Egress to policyTypes; the empty egress list means deny-all.
 	withEgress(np)
@@ -137,6 +137,8 @@ func TestPostgresNetworkPolicy(t *testing.T) {
 	assert.True(t, containsPodSelectorLabel(ingress.From, "name", apiDeploymentName), "api must reach postgres")
 	assert.True(t, containsPodSelectorLabel(ingress.From, "app.kubernetes.io/name", "acm-mcp-server"),
 		"mcp-server must reach postgres for read-only queries")
+	assert.True(t, containsPodSelectorLabel(ingress.From, "name", fieldIndexesName),
+		"field index job must reach postgres")
 }
 
 func TestIndexerNetworkPolicy(t *testing.T) {
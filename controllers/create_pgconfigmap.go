@@ -81,12 +81,16 @@ psql -d search -U searchuser -c "CREATE INDEX IF NOT EXISTS edges_sourceid_idx O
 psql -d search -U searchuser -c "CREATE INDEX IF NOT EXISTS edges_destid_idx ON search.edges USING btree (destid)"
 psql -d search -U searchuser -c "CREATE INDEX IF NOT EXISTS edges_cluster_idx ON search.edges USING btree (cluster)"
 psql -d search -U searchuser -f /opt/app-root/src/postgresql-start/postgresql.sql
+if [ -f ` + fieldIndexesMountPath + "/" + fieldIndexesScriptKey + ` ]; then
+  psql -d search -U searchuser -f ` + fieldIndexesMountPath + "/" + fieldIndexesScriptKey + `
+fi
 `
 
 	work_memquery := "psql -d search -U searchuser -c \"ALTER ROLE searchuser set work_mem='" + work_mem + "'\""
 	data[startScript] = data[startScript] + work_memquery
-	// Provision read-only roles for search-v2-api and search-mcp-server.
-	// Passwords are supplied via env vars mounted from the readonly Secrets.
+	// Provision read-only roles for search-v2-api and search-mcp-server, and the search_monitor
+	// role (pg_monitor) used by the optional postgres_exporter sidecar.
+	// Passwords are supplied via env vars mounted from the readonly and monitor Secrets.
 	// The psql -v flag passes them as psql variables (:'name') to avoid shell injection.
 	// Runs as the postgres superuser (peer auth) since CREATE ROLE requires elevated privilege.
 	// psql variable substitution (:'varname') works in plain SQL statements but NOT inside
@@ -96,7 +100,8 @@ psql -d search -U searchuser -f /opt/app-root/src/postgresql-start/postgresql.sq
 	data[startScript] = data[startScript] + `
 psql -d search -U postgres \
   -v "READONLY_API_PASSWORD=$READONLY_API_PASSWORD" \
-  -v "READONLY_MCP_PASSWORD=$READONLY_MCP_PASSWORD" << 'EOSQL'
+  -v "READONLY_MCP_PASSWORD=$READONLY_MCP_PASSWORD" \
+  -v "MONITOR_PASSWORD=$MONITOR_PASSWORD" << 'EOSQL'
 SELECT NOT EXISTS (SELECT FROM pg_roles WHERE rolname = 'search_api_ro') AS create_api_role \gset
 \if :create_api_role
   CREATE ROLE search_api_ro WITH LOGIN PASSWORD :'READONLY_API_PASSWORD';
@@ -121,6 +126,13 @@ BEGIN
   END IF;
 END $$;
 ALTER DEFAULT PRIVILEGES IN SCHEMA search GRANT SELECT ON TABLES TO search_api_ro, search_mcp_ro;
+SELECT NOT EXISTS (SELECT FROM pg_roles WHERE rolname = 'search_monitor') AS create_monitor_role \gset
+\if :create_monitor_role
+  CREATE ROLE search_monitor WITH LOGIN PASSWORD :'MONITOR_PASSWORD';
+\else
+  ALTER ROLE search_monitor WITH PASSWORD :'MONITOR_PASSWORD';
+\endif
+GRANT pg_monitor TO search_monitor;
 EOSQL
 `
 	data["postgresql.sql"] = `CREATE OR REPLACE FUNCTION search.intercluster_edges()
@@ -5,6 +5,7 @@ import (
 	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
@@ -33,6 +34,7 @@ func (r *SearchReconciler) PGDeployment(instance *searchv1alpha1.Search, configH
 			newSecretEnvVar("POSTGRESQL_DATABASE", "database-name", "search-postgres"),
 			newSecretEnvVar("READONLY_API_PASSWORD", "database-password", apiReadonlySecretName),
 			newSecretEnvVar("READONLY_MCP_PASSWORD", "database-password", mcpReadonlySecretName),
+			newSecretEnvVar("MONITOR_PASSWORD", "database-password", monitorSecretName),
 		},
 		VolumeMounts: []corev1.VolumeMount{
 			{
@@ -56,6 +58,10 @@ func (r *SearchReconciler) PGDeployment(instance *searchv1alpha1.Search, configH
 				Name:      "search-postgres-certs",
 				MountPath: "/sslcert",
 			},
+			{
+				Name:      fieldIndexesName,
+				MountPath: fieldIndexesMountPath,
+			},
 		},
 		ReadinessProbe: &corev1.Probe{
 			InitialDelaySeconds: 5,
@@ -157,6 +163,18 @@ func (r *SearchReconciler) PGDeployment(instance *searchv1alpha1.Search, configH
 				},
 			},
 		},
+		{
+			// Generated by reconcileFieldIndexes; optional so postgres can start before it exists.
+			Name: fieldIndexesName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: fieldIndexesName,
+					},
+					Optional: ptr.To(true),
+				},
+			},
+		},
 	}
 	postgresVolume := getPostgresVolume(instance)
 	volumes = append(volumes, postgresVolume,
@@ -189,6 +207,10 @@ func (r *SearchReconciler) PGDeployment(instance *searchv1alpha1.Search, configH
 
 	deployment.Spec.Template.Spec.SecurityContext = getPodSecurityContext()
 	deployment.Spec.Template.Spec.Containers = []corev1.Container{postgresContainer}
+	if isPostgresExporterEnabled(instance) {
+		deployment.Spec.Template.Spec.Containers = append(deployment.Spec.Template.Spec.Containers,
+			PostgresExporterContainer(instance))
+	}
 	deployment.Spec.Template.Spec.Volumes = volumes
 	deployment.Spec.Template.Spec.ServiceAccountName = getPostgresServiceAccountName()
 
@@ -0,0 +1,145 @@
+// Copyright Contributors to the Open Cluster Management project
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	monitorv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// postgresExporterName is the name of the postgres_exporter sidecar container.
+	postgresExporterName = "search-postgres-exporter"
+	// postgresMetricsName names the Service, its port, and the ServiceMonitor job for the exporter.
+	postgresMetricsName = "search-postgres-metrics"
+)
+
+// isPostgresExporterEnabled returns true when the exporter is requested on the Search CR and an
+// image is available for it. It runs several times per reconcile, so a missing image is logged
+// at V(2).
+func isPostgresExporterEnabled(instance *searchv1alpha1.Search) bool {
+	if !instance.Spec.Monitoring.DatabaseExporter.Enabled {
+		return false
+	}
+	if getImageSha(postgresExporterName, instance) == "" {
+		log.V(2).Info("Postgres exporter is enabled but no image is configured. Set POSTGRES_EXPORTER_IMAGE on the operator.")
+		return false
+	}
+	return true
+}
+
+// PostgresExporterContainer returns the postgres_exporter sidecar for the search-postgres pod.
+// It connects over localhost with the search_monitor role, which the start script creates with
+// the pg_monitor privileges, so it never needs the searchuser credentials.
+func PostgresExporterContainer(instance *searchv1alpha1.Search) corev1.Container {
+	resources := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("10m"),
+			corev1.ResourceMemory: resource.MustParse("32Mi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("128Mi"),
+		},
+	}
+	if instance.Spec.Monitoring.DatabaseExporter.Resources != nil {
+		resources = *instance.Spec.Monitoring.DatabaseExporter.Resources
+	}
+	return corev1.Container{
+		Name:            postgresExporterName,
+		Image:           getImageSha(postgresExporterName, instance),
+		ImagePullPolicy: getImagePullPolicy(postgresExporterName, instance),
+		Args:            []string{fmt.Sprintf("--web.listen-address=:%d", postgresMetricsPort)},
+		Ports: []corev1.ContainerPort{
+			{
+				Name:          postgresMetricsName,
+				ContainerPort: postgresMetricsPort,
+				Protocol:      corev1.ProtocolTCP,
+			},
+		},
+		Env: []corev1.EnvVar{
+			newEnvVar("DATA_SOURCE_URI", fmt.Sprintf("localhost:%d/%s?sslmode=require", postgresPort, DBNAME)),
+			newSecretEnvVar("DATA_SOURCE_USER", "database-user", monitorSecretName),
+			newSecretEnvVar("DATA_SOURCE_PASS", "database-password", monitorSecretName),
+		},
+		ReadinessProbe: &corev1.Probe{
+			InitialDelaySeconds: 5,
+			TimeoutSeconds:      1,
+			ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{
+					Path: "/",
+					Port: intstr.FromInt32(postgresMetricsPort),
+				},
+			},
+		},
+		Resources:       resources,
+		SecurityContext: getContainerSecurityContext(),
+	}
+}
+
+// PostgresMetricsService exposes the postgres_exporter port for the ServiceMonitor. The
+// search-postgres Service is left unchanged so database clients never see the metrics port.
+func (r *SearchReconciler) PostgresMetricsService(instance *searchv1alpha1.Search) *corev1.Service {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      postgresMetricsName,
+			Namespace: instance.GetNamespace(),
+			Labels:    map[string]string{"search-monitor": postgresMetricsName},
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{
+					Name:       postgresMetricsName,
+					Port:       postgresMetricsPort,
+					TargetPort: intstr.FromInt32(postgresMetricsPort),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+			Selector: map[string]string{"name": postgresDeploymentName},
+		},
+	}
+	err := controllerutil.SetControllerReference(instance, svc, r.Scheme)
+	if err != nil {
+		log.V(2).Info("Could not set control for search-postgres-metrics service")
+	}
+	return svc
+}
+
+// reconcilePostgresExporter creates the Service and ServiceMonitor for the postgres_exporter
+// sidecar when it is enabled, and removes them when it is disabled.
+func (r *SearchReconciler) reconcilePostgresExporter(ctx context.Context,
+	instance *searchv1alpha1.Search) (*reconcile.Result, error) {
+	if isPostgresExporterEnabled(instance) {
+		result, err := r.createService(ctx, r.PostgresMetricsService(instance))
+		if result != nil {
+			return result, err
+		}
+		// postgres_exporter serves plain HTTP, same as the collector metrics endpoint.
+		return r.createServiceMonitor(ctx, r.CollectorServiceMonitor(instance, postgresMetricsName, instance.Namespace))
+	}
+
+	sm := &monitorv1.ServiceMonitor{}
+	sm.Name = postgresMetricsName + "-monitor"
+	sm.Namespace = instance.GetNamespace()
+	if err := r.Delete(ctx, sm); err != nil && !errors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+		log.Error(err, "Could not delete servicemonitor "+sm.Name)
+		return &reconcile.Result{}, err
+	}
+	svc := &corev1.Service{}
+	svc.Name = postgresMetricsName
+	svc.Namespace = instance.GetNamespace()
+	if err := r.Delete(ctx, svc); err != nil && !errors.IsNotFound(err) {
+		log.Error(err, "Could not delete service "+svc.Name)
+		return &reconcile.Result{}, err
+	}
+	return nil, nil
+}
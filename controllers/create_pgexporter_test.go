@@ -0,0 +1,110 @@
+// Copyright Contributors to the Open Cluster Management project
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	monitorv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func setupExporterReconciler(t *testing.T, instance *searchv1alpha1.Search) *SearchReconciler {
+	t.Helper()
+	s := scheme.Scheme
+	require.NoError(t, searchv1alpha1.SchemeBuilder.AddToScheme(s))
+	require.NoError(t, monitorv1.AddToScheme(s))
+	cl := fake.NewClientBuilder().WithRuntimeObjects(instance).Build()
+	return &SearchReconciler{Client: cl, Scheme: s}
+}
+
+func TestPostgresExporter_Disabled(t *testing.T) {
+	t.Setenv("POSTGRES_EXPORTER_IMAGE", "quay.io/stolostron/postgres-exporter:env")
+	instance := newSearchInstance()
+	r := setupExporterReconciler(t, instance)
+
+	dep := r.PGDeployment(instance, "hash")
+	assert.Len(t, dep.Spec.Template.Spec.Containers, 1)
+	np := r.PostgresNetworkPolicy(instance)
+	assert.Len(t, np.Spec.Ingress, 1)
+}
+
+func TestPostgresExporter_EnabledWithoutImage(t *testing.T) {
+	t.Setenv("POSTGRES_EXPORTER_IMAGE", "")
+	instance := newSearchInstance()
+	instance.Spec.Monitoring.DatabaseExporter.Enabled = true
+
+	assert.False(t, isPostgresExporterEnabled(instance), "exporter needs an image")
+}
+
+func TestPostgresExporter_Enabled(t *testing.T) {
+	t.Setenv("POSTGRES_EXPORTER_IMAGE", "quay.io/stolostron/postgres-exporter:env")
+	instance := newSearchInstance()
+	instance.Spec.Monitoring.DatabaseExporter.Enabled = true
+	r := setupExporterReconciler(t, instance)
+
+	dep := r.PGDeployment(instance, "hash")
+	require.Len(t, dep.Spec.Template.Spec.Containers, 2)
+	sidecar := dep.Spec.Template.Spec.Containers[1]
+	assert.Equal(t, postgresExporterName, sidecar.Name)
+	assert.Equal(t, "quay.io/stolostron/postgres-exporter:env", sidecar.Image)
+	assert.Equal(t, int32(postgresMetricsPort), sidecar.Ports[0].ContainerPort)
+	for _, env := range sidecar.Env {
+		if env.ValueFrom != nil {
+			assert.Equal(t, monitorSecretName, env.ValueFrom.SecretKeyRef.Name,
+				"exporter must use the monitoring role, not searchuser")
+		}
+	}
+
+	np := r.PostgresNetworkPolicy(instance)
+	require.Len(t, np.Spec.Ingress, 2)
+	assert.True(t, containsTCPPort(np.Spec.Ingress[1].Ports, postgresMetricsPort))
+	assert.True(t, containsNamespaceSelector(np.Spec.Ingress[1].From, openshiftMonitoring))
+}
+
+func TestPostgresExporter_ImageOverride(t *testing.T) {
+	t.Setenv("POSTGRES_EXPORTER_IMAGE", "quay.io/stolostron/postgres-exporter:env")
+	instance := newSearchInstance()
+	instance.Spec.Monitoring.DatabaseExporter.ImageOverride = "quay.io/stolostron/postgres-exporter:override"
+	assert.Equal(t, "quay.io/stolostron/postgres-exporter:override", getImageSha(postgresExporterName, instance))
+
+	instance.Spec.Monitoring.DatabaseExporter.ImageOverride = "docker.io/untrusted/postgres-exporter:latest"
+	assert.Equal(t, "quay.io/stolostron/postgres-exporter:env", getImageSha(postgresExporterName, instance))
+}
+
+func TestReconcilePostgresExporter(t *testing.T) {
+	t.Setenv("POSTGRES_EXPORTER_IMAGE", "quay.io/stolostron/postgres-exporter:env")
+	ctx := context.TODO()
+	instance := newSearchInstance()
+	instance.Spec.Monitoring.DatabaseExporter.Enabled = true
+	r := setupExporterReconciler(t, instance)
+
+	result, err := r.reconcilePostgresExporter(ctx, instance)
+	require.NoError(t, err)
+	assert.Nil(t, result)
+
+	svc := &corev1.Service{}
+	require.NoError(t, r.Get(ctx, types.NamespacedName{Name: postgresMetricsName, Namespace: testNamespace}, svc))
+	assert.Equal(t, postgresMetricsName, svc.Labels["search-monitor"])
+	sm := &monitorv1.ServiceMonitor{}
+	require.NoError(t, r.Get(ctx, types.NamespacedName{Name: postgresMetricsName + "-monitor", Namespace: testNamespace}, sm))
+	assert.Equal(t, "http", sm.Spec.Endpoints[0].Scheme)
+	assert.Equal(t, postgresMetricsName, sm.Spec.Endpoints[0].Port)
+
+	// Disabling the exporter removes the Service and ServiceMonitor.
+	instance.Spec.Monitoring.DatabaseExporter.Enabled = false
+	result, err = r.reconcilePostgresExporter(ctx, instance)
+	require.NoError(t, err)
+	assert.Nil(t, result)
+	err = r.Get(ctx, types.NamespacedName{Name: postgresMetricsName, Namespace: testNamespace}, svc)
+	assert.True(t, errors.IsNotFound(err))
+	err = r.Get(ctx, types.NamespacedName{Name: postgresMetricsName + "-monitor", Namespace: testNamespace}, sm)
+	assert.True(t, errors.IsNotFound(err))
+}
@@ -0,0 +1,305 @@
+// Copyright Contributors to the Open Cluster Management project
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	fieldIndexesName           = "search-postgres-field-indexes"
+	fieldIndexesScriptKey      = "field-indexes.sql"
+	fieldIndexesMountPath      = "/opt/app-root/src/postgresql-field-indexes"
+	fieldIndexesHashAnnotation = "search.open-cluster-management.io/field-indexes-hash"
+
+	// fieldIndexPrefix marks the indexes owned by the operator. Indexes with this prefix that are
+	// no longer derived from merged-collector-config are dropped, so it must never collide with
+	// the fixed indexes created by postgresql-start.sh.
+	fieldIndexPrefix = "data_field_"
+
+	fieldIndexMethodGIN   = "gin"
+	fieldIndexMethodBTree = "btree"
+)
+
+// CONDITION_FIELD_INDEXES is set on the Search status while the field indexes Job has failed, and
+// removed once a Job applies the indexes.
+const CONDITION_FIELD_INDEXES = "FieldIndexesApplied"
+
+// fieldIndexKeyPattern matches the data keys the collector can produce from a validated field
+// name and fieldSuffix. Keys are interpolated into SQL, so anything else is skipped.
+var fieldIndexKeyPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9\-_.]*$`)
+
+// fieldIndexNameSanitizer replaces characters that are not valid in an unquoted index name.
+var fieldIndexNameSanitizer = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// desiredFieldIndexes derives one expression index per custom field declared in the include rules
+// of the merged CollectorConfig. The key honours the rule's fieldSuffix the same way the collector
+// does ("status" with suffix "grc" is stored as "status.grc").
+//
+// Numeric types (integer, float, bytes) get a btree index so range comparisons can use it; strings
+// and booleans get a GIN index, matching the fixed indexes in postgresql-start.sh. When the same
+// key is declared more than once, the first declaration wins, consistent with the merge order
+// (integration configs first, then user-collector-config).
+func desiredFieldIndexes(spec searchv1alpha1.CollectorConfigSpec) []searchv1alpha1.FieldIndex {
+	seen := map[string]struct{}{}
+	var indexes []searchv1alpha1.FieldIndex
+	for _, rule := range spec.CollectionRules {
+		if rule.Action != searchv1alpha1.ActionInclude {
+			continue
+		}
+		for _, f := range rule.Fields {
+			key := effectiveFieldName(rule, f)
+			if !fieldIndexKeyPattern.MatchString(key) {
+				log.Info("Skipping index for field with an unsupported name", "field", key)
+				continue
+			}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			method := fieldIndexMethodGIN
+			switch f.Type {
+			case searchv1alpha1.DataTypeInteger, searchv1alpha1.DataTypeFloat, searchv1alpha1.DataTypeBytes:
+				method = fieldIndexMethodBTree
+			}
+			indexes = append(indexes, searchv1alpha1.FieldIndex{
+				Name:   fieldIndexName(key, method),
+				Field:  key,
+				Method: method,
+			})
+		}
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i].Name < indexes[j].Name })
+	return indexes
+}
+
+// fieldIndexName builds a stable index name that fits the 63 byte Postgres identifier limit.
+// The hash covers the method so a type change produces a new index and drops the old one.
+func fieldIndexName(key, method string) string {
+	readable := fieldIndexNameSanitizer.ReplaceAllString(strings.ToLower(key), "_")
+	if len(readable) > 38 {
+		readable = readable[:38]
+	}
+	sum := sha256.Sum256([]byte(key + "/" + method))
+	return fmt.Sprintf("%s%s_%x_idx", fieldIndexPrefix, readable, sum[:4])
+}
+
+// fieldIndexesSQL renders the psql script that brings the managed indexes in line with the desired
+// set. Every statement is safe to re-run. CONCURRENTLY cannot run inside a transaction block, so
+// drops are generated with \gexec, which sends each statement separately.
+func fieldIndexesSQL(indexes []searchv1alpha1.FieldIndex) string {
+	var sb strings.Builder
+	sb.WriteString("-- Generated by search-v2-operator from merged-collector-config. Do not edit.\n")
+	// A failed concurrent build leaves an invalid index behind that IF NOT EXISTS would skip.
+	sb.WriteString(`SELECT format('DROP INDEX CONCURRENTLY IF EXISTS search.%I', c.relname)
+  FROM pg_index i JOIN pg_class c ON c.oid = i.indexrelid JOIN pg_namespace n ON n.oid = c.relnamespace
+  WHERE n.nspname = 'search' AND NOT i.indisvalid AND c.relname LIKE '` + fieldIndexPrefix + `%' \gexec
+`)
+	names := make([]string, 0, len(indexes))
+	for _, idx := range indexes {
+		fmt.Fprintf(&sb, "CREATE INDEX CONCURRENTLY IF NOT EXISTS %s ON search.resources USING %s ((data -> '%s'));\n",
+			idx.Name, idx.Method, idx.Field)
+		names = append(names, "'"+idx.Name+"'")
+	}
+	sb.WriteString(`SELECT format('DROP INDEX CONCURRENTLY IF EXISTS search.%I', indexname)
+  FROM pg_indexes WHERE schemaname = 'search' AND tablename = 'resources' AND indexname LIKE '` + fieldIndexPrefix + `%'`)
+	if len(names) > 0 {
+		sb.WriteString(" AND indexname NOT IN (" + strings.Join(names, ", ") + ")")
+	}
+	sb.WriteString(" \\gexec\n")
+	return sb.String()
+}
+
+// FieldIndexesConfigmap holds the generated index script. It is mounted into search-postgres, so
+// the indexes are rebuilt at startup (e.g. after an emptyDir restart), and into the
+// search-postgres-field-indexes Job, which applies changes to the running database.
+func (r *SearchReconciler) FieldIndexesConfigmap(instance *searchv1alpha1.Search,
+	indexes []searchv1alpha1.FieldIndex) *corev1.ConfigMap {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fieldIndexesName,
+			Namespace: instance.GetNamespace(),
+			Labels:    generateLabels("name", fieldIndexesName),
+		},
+		Data: map[string]string{
+			fieldIndexesScriptKey: fieldIndexesSQL(indexes),
+		},
+	}
+	if err := controllerutil.SetControllerReference(instance, cm, r.Scheme); err != nil {
+		log.V(2).Info("Could not set control for search-postgres-field-indexes configmap")
+	}
+	return cm
+}
+
+// FieldIndexesJob runs the index script against the search-postgres Service with psql from the
+// postgres image. The script hash is recorded on the Job so a changed field set replaces it.
+func (r *SearchReconciler) FieldIndexesJob(instance *searchv1alpha1.Search, scriptHash string) *batchv1.Job {
+	podLabels := generateLabels("name", fieldIndexesName)
+	backoffLimit := int32(6)
+	container := corev1.Container{
+		Name:            fieldIndexesName,
+		Image:           getImageSha(postgresDeploymentName, instance),
+		ImagePullPolicy: getImagePullPolicy(postgresDeploymentName, instance),
+		Command: []string{
+			"psql", "-v", "ON_ERROR_STOP=1",
+			"-h", postgresDeploymentName, "-p", fmt.Sprint(postgresPort),
+			"-f", fieldIndexesMountPath + "/" + fieldIndexesScriptKey,
+		},
+		Env: []corev1.EnvVar{
+			newSecretEnvVar("PGUSER", "database-user", "search-postgres"),
+			newSecretEnvVar("PGPASSWORD", "database-password", "search-postgres"),
+			newSecretEnvVar("PGDATABASE", "database-name", "search-postgres"),
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: fieldIndexesName, MountPath: fieldIndexesMountPath},
+		},
+		SecurityContext: getContainerSecurityContext(),
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fieldIndexesName,
+			Namespace:   instance.GetNamespace(),
+			Labels:      podLabels,
+			Annotations: map[string]string{fieldIndexesHashAnnotation: scriptHash},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: podLabels},
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ServiceAccountName: getPostgresServiceAccountName(),
+					SecurityContext:    getPodSecurityContext(),
+					Containers:         []corev1.Container{container},
+					Volumes: []corev1.Volume{{
+						Name: fieldIndexesName,
+						VolumeSource: corev1.VolumeSource{
+							ConfigMap: &corev1.ConfigMapVolumeSource{
+								LocalObjectReference: corev1.LocalObjectReference{Name: fieldIndexesName},
+							},
+						},
+					}},
+					NodeSelector: getNodeSelector(postgresDeploymentName, instance),
+					Tolerations:  getTolerations(postgresDeploymentName, instance),
+				},
+			},
+		},
+	}
+	if err := controllerutil.SetControllerReference(instance, job, r.Scheme); err != nil {
+		log.V(2).Info("Could not set control for search-postgres-field-indexes job")
+	}
+	return job
+}
+
+// reconcileFieldIndexes keeps the expression indexes for custom CollectorConfig fields in sync
+// with merged-collector-config, and lists them in the Search status once the Job has applied them.
+func (r *SearchReconciler) reconcileFieldIndexes(ctx context.Context,
+	instance *searchv1alpha1.Search) (*reconcile.Result, error) {
+	merged := &searchv1alpha1.CollectorConfig{}
+	err := r.Get(ctx, types.NamespacedName{Name: mergedCollectorConfigName, Namespace: instance.GetNamespace()}, merged)
+	if err != nil && !errors.IsNotFound(err) {
+		log.Error(err, "Could not get merged-collector-config to derive field indexes")
+		return &reconcile.Result{}, err
+	}
+	indexes := desiredFieldIndexes(merged.Spec)
+
+	cm := r.FieldIndexesConfigmap(instance, indexes)
+	if result, err := r.createOrUpdateConfigMap(ctx, cm); result != nil {
+		return result, err
+	}
+	sum := sha256.Sum256([]byte(cm.Data[fieldIndexesScriptKey]))
+	scriptHash := fmt.Sprintf("%x", sum[:8])
+
+	found := &batchv1.Job{}
+	err = r.Get(ctx, types.NamespacedName{Name: fieldIndexesName, Namespace: instance.GetNamespace()}, found)
+	if errors.IsNotFound(err) {
+		if err := r.Create(ctx, r.FieldIndexesJob(instance, scriptHash)); err != nil {
+			if errors.IsAlreadyExists(err) {
+				// The previous Job is still being deleted.
+				return &reconcile.Result{RequeueAfter: 5 * time.Second}, nil
+			}
+			log.Error(err, "Could not create search-postgres-field-indexes job")
+			return &reconcile.Result{}, err
+		}
+		log.Info("Created job to apply field indexes", "indexCount", len(indexes))
+		return nil, nil
+	} else if err != nil {
+		log.Error(err, "Could not get search-postgres-field-indexes job")
+		return &reconcile.Result{}, err
+	}
+
+	if found.Annotations[fieldIndexesHashAnnotation] != scriptHash {
+		// Jobs are immutable once started; replace it so the new script runs.
+		if err := r.Delete(ctx, found, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil &&
+			!errors.IsNotFound(err) {
+			log.Error(err, "Could not delete outdated search-postgres-field-indexes job")
+			return &reconcile.Result{}, err
+		}
+		log.V(2).Info("Replacing search-postgres-field-indexes job for changed fields")
+		return &reconcile.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+
+	if failed, message := fieldIndexesJobFailed(found); failed {
+		// Report the failure and run the script again in a new Job. The pod backoff of each Job
+		// (backoffLimit) spaces the attempts.
+		r.updateStatusCondition(ctx, instance, metav1.Condition{
+			Type:               CONDITION_FIELD_INDEXES,
+			Status:             metav1.ConditionFalse,
+			Reason:             "JobFailed",
+			Message:            fmt.Sprintf("The %s job failed and is retried: %s", fieldIndexesName, message),
+			LastTransitionTime: metav1.Now(),
+		})
+		if err := r.Delete(ctx, found, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil &&
+			!errors.IsNotFound(err) {
+			log.Error(err, "Could not delete failed search-postgres-field-indexes job")
+			return &reconcile.Result{}, err
+		}
+		log.Info("Retrying failed search-postgres-field-indexes job", "message", message)
+		return &reconcile.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+
+	if found.Status.Succeeded == 0 {
+		return nil, nil
+	}
+	if err := r.removeStatusCondition(ctx, instance, CONDITION_FIELD_INDEXES); err != nil {
+		log.Error(err, "Failed to update status condition.", "type", CONDITION_FIELD_INDEXES)
+		return &reconcile.Result{}, err
+	}
+	if !equality.Semantic.DeepEqual(instance.Status.FieldIndexes, indexes) {
+		instance.Status.FieldIndexes = indexes
+		if err := r.commitSearchCRInstanceState(ctx, instance); err != nil {
+			return &reconcile.Result{}, err
+		}
+		log.Info("Updated field indexes in Search status", "indexCount", len(indexes))
+	}
+	return nil, nil
+}
+
+// fieldIndexesJobFailed reports whether job gave up, with the reason from its JobFailed condition.
+func fieldIndexesJobFailed(job *batchv1.Job) (bool, string) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			return true, condition.Message
+		}
+	}
+	if job.Spec.BackoffLimit != nil && job.Status.Failed > *job.Spec.BackoffLimit {
+		return true, fmt.Sprintf("%d failed pods", job.Status.Failed)
+	}
+	return false, ""
+}
@@ -0,0 +1,216 @@
+// Copyright Contributors to the Open Cluster Management project
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func setupFieldIndexReconciler(objs ...runtime.Object) *SearchReconciler {
+	s := scheme.Scheme
+	_ = searchv1alpha1.SchemeBuilder.AddToScheme(s)
+	cl := fake.NewClientBuilder().
+		WithRuntimeObjects(objs...).
+		WithStatusSubresource(&searchv1alpha1.Search{}).
+		Build()
+	return &SearchReconciler{Client: cl, Scheme: s}
+}
+
+func fieldIndexTestSpec() searchv1alpha1.CollectorConfigSpec {
+	return searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{
+				Action:           searchv1alpha1.ActionInclude,
+				FieldSuffix:      "grc",
+				ResourceSelector: searchv1alpha1.ResourceSelector{APIGroups: []string{"policy.open-cluster-management.io"}, Kinds: []string{"Policy"}},
+				Fields: []searchv1alpha1.Field{
+					{Name: "severity", JSONPath: "{.spec.severity}"},
+					{Name: "violations", JSONPath: "{.status.violations}", Type: searchv1alpha1.DataTypeInteger},
+				},
+			},
+			{
+				Action:           searchv1alpha1.ActionExclude,
+				ResourceSelector: searchv1alpha1.ResourceSelector{APIGroups: []string{""}, Kinds: []string{"Event"}},
+			},
+			{
+				Action:           searchv1alpha1.ActionInclude,
+				ResourceSelector: searchv1alpha1.ResourceSelector{APIGroups: []string{"apps"}, Kinds: []string{"Deployment"}},
+				Fields: []searchv1alpha1.Field{
+					{Name: "strategy", JSONPath: "{.spec.strategy.type}"},
+				},
+			},
+			{
+				Action:           searchv1alpha1.ActionInclude,
+				ResourceSelector: searchv1alpha1.ResourceSelector{APIGroups: []string{"apps"}, Kinds: []string{"StatefulSet"}},
+				Fields: []searchv1alpha1.Field{
+					{Name: "strategy", JSONPath: "{.spec.updateStrategy.type}", Type: searchv1alpha1.DataTypeInteger},
+				},
+			},
+		},
+	}
+}
+
+func TestDesiredFieldIndexes(t *testing.T) {
+	indexes := desiredFieldIndexes(fieldIndexTestSpec())
+	require.Len(t, indexes, 3)
+
+	byField := map[string]searchv1alpha1.FieldIndex{}
+	for _, idx := range indexes {
+		byField[idx.Field] = idx
+		assert.True(t, strings.HasPrefix(idx.Name, fieldIndexPrefix), "index %s must use the managed prefix", idx.Name)
+		assert.LessOrEqual(t, len(idx.Name), 63, "index name must fit the Postgres identifier limit")
+	}
+	assert.Equal(t, fieldIndexMethodGIN, byField["severity.grc"].Method, "string fields use gin")
+	assert.Equal(t, fieldIndexMethodBTree, byField["violations.grc"].Method, "numeric fields use btree")
+	// The first declaration wins when the same key is declared twice.
+	assert.Equal(t, fieldIndexMethodGIN, byField["strategy"].Method)
+}
+
+func TestDesiredFieldIndexes_NoFields(t *testing.T) {
+	assert.Nil(t, desiredFieldIndexes(searchv1alpha1.CollectorConfigSpec{}))
+}
+
+func TestFieldIndexName_ChangesWithMethod(t *testing.T) {
+	assert.NotEqual(t, fieldIndexName("status", fieldIndexMethodGIN), fieldIndexName("status", fieldIndexMethodBTree))
+	assert.Equal(t, fieldIndexName("status", fieldIndexMethodGIN), fieldIndexName("status", fieldIndexMethodGIN))
+	long := fieldIndexName(strings.Repeat("a", 200), fieldIndexMethodGIN)
+	assert.LessOrEqual(t, len(long), 63)
+}
+
+func TestFieldIndexesSQL(t *testing.T) {
+	indexes := desiredFieldIndexes(fieldIndexTestSpec())
+	sql := fieldIndexesSQL(indexes)
+
+	for _, idx := range indexes {
+		assert.Contains(t, sql, "CREATE INDEX CONCURRENTLY IF NOT EXISTS "+idx.Name+
+			" ON search.resources USING "+idx.Method+" ((data -> '"+idx.Field+"'))")
+		assert.Contains(t, sql, "'"+idx.Name+"'", "desired index must be excluded from the drop query")
+	}
+	assert.Contains(t, sql, "NOT i.indisvalid", "invalid indexes from failed builds must be dropped")
+	assert.Contains(t, sql, "indexname LIKE '"+fieldIndexPrefix+"%'")
+
+	// With no fields every managed index is dropped.
+	empty := fieldIndexesSQL(nil)
+	assert.NotContains(t, empty, "CREATE INDEX")
+	assert.NotContains(t, empty, "NOT IN")
+}
+
+func TestReconcileFieldIndexes(t *testing.T) {
+	ctx := context.TODO()
+	instance := newSearchInstance()
+	merged := newCollectorConfig(mergedCollectorConfigName, fieldIndexTestSpec())
+	r := setupFieldIndexReconciler(instance, merged)
+
+	result, err := r.reconcileFieldIndexes(ctx, instance)
+	require.NoError(t, err)
+	assert.Nil(t, result)
+
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, r.Get(ctx, types.NamespacedName{Name: fieldIndexesName, Namespace: testNamespace}, cm))
+	assert.Contains(t, cm.Data[fieldIndexesScriptKey], "severity.grc")
+
+	job := &batchv1.Job{}
+	require.NoError(t, r.Get(ctx, types.NamespacedName{Name: fieldIndexesName, Namespace: testNamespace}, job))
+	assert.NotEmpty(t, job.Annotations[fieldIndexesHashAnnotation])
+	assert.Equal(t, corev1.RestartPolicyNever, job.Spec.Template.Spec.RestartPolicy)
+	require.Len(t, job.OwnerReferences, 1)
+	assert.Equal(t, OperatorName, job.OwnerReferences[0].Name)
+
+	// Status is only updated after the Job has applied the script.
+	assert.Empty(t, instance.Status.FieldIndexes)
+	job.Status.Succeeded = 1
+	require.NoError(t, r.Status().Update(ctx, job))
+
+	result, err = r.reconcileFieldIndexes(ctx, instance)
+	require.NoError(t, err)
+	assert.Nil(t, result)
+	assert.Equal(t, desiredFieldIndexes(fieldIndexTestSpec()), instance.Status.FieldIndexes)
+}
+
+func TestReconcileFieldIndexes_ReplacesJobOnChange(t *testing.T) {
+	ctx := context.TODO()
+	instance := newSearchInstance()
+	merged := newCollectorConfig(mergedCollectorConfigName, fieldIndexTestSpec())
+	r := setupFieldIndexReconciler(instance, merged)
+
+	_, err := r.reconcileFieldIndexes(ctx, instance)
+	require.NoError(t, err)
+
+	// Drop the fields; the script changes, so the old Job must be replaced.
+	require.NoError(t, r.Get(ctx, types.NamespacedName{Name: mergedCollectorConfigName, Namespace: testNamespace}, merged))
+	merged.Spec = searchv1alpha1.CollectorConfigSpec{}
+	require.NoError(t, r.Update(ctx, merged))
+
+	result, err := r.reconcileFieldIndexes(ctx, instance)
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.NotZero(t, result.RequeueAfter)
+
+	job := &batchv1.Job{}
+	err = r.Get(ctx, types.NamespacedName{Name: fieldIndexesName, Namespace: testNamespace}, job)
+	assert.True(t, errors.IsNotFound(err), "outdated job should be deleted")
+
+	result, err = r.reconcileFieldIndexes(ctx, instance)
+	require.NoError(t, err)
+	assert.Nil(t, result)
+	require.NoError(t, r.Get(ctx, types.NamespacedName{Name: fieldIndexesName, Namespace: testNamespace}, job))
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, r.Get(ctx, types.NamespacedName{Name: fieldIndexesName, Namespace: testNamespace}, cm))
+	assert.NotContains(t, cm.Data[fieldIndexesScriptKey], "CREATE INDEX")
+}
+
+func TestReconcileFieldIndexes_RetriesFailedJob(t *testing.T) {
+	ctx := context.TODO()
+	instance := newSearchInstance()
+	merged := newCollectorConfig(mergedCollectorConfigName, fieldIndexTestSpec())
+	r := setupFieldIndexReconciler(instance, merged)
+
+	_, err := r.reconcileFieldIndexes(ctx, instance)
+	require.NoError(t, err)
+	job := &batchv1.Job{}
+	require.NoError(t, r.Get(ctx, types.NamespacedName{Name: fieldIndexesName, Namespace: testNamespace}, job))
+	job.Status.Failed = 4
+	job.Status.Conditions = []batchv1.JobCondition{{
+		Type: batchv1.JobFailed, Status: corev1.ConditionTrue,
+		Reason: "BackoffLimitExceeded", Message: "Job has reached the specified backoff limit",
+	}}
+	require.NoError(t, r.Status().Update(ctx, job))
+
+	// The failure is reported and the Job is replaced.
+	result, err := r.reconcileFieldIndexes(ctx, instance)
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.NotZero(t, result.RequeueAfter)
+	err = r.Get(ctx, types.NamespacedName{Name: fieldIndexesName, Namespace: testNamespace}, job)
+	assert.True(t, errors.IsNotFound(err), "failed job should be deleted")
+	assert.Empty(t, instance.Status.FieldIndexes)
+	require.Len(t, instance.Status.Conditions, 1)
+	assert.Equal(t, CONDITION_FIELD_INDEXES, instance.Status.Conditions[0].Type)
+	assert.Equal(t, metav1.ConditionFalse, instance.Status.Conditions[0].Status)
+	assert.Contains(t, instance.Status.Conditions[0].Message, "backoff limit")
+
+	// The retry succeeds: the condition is removed and the indexes are listed.
+	_, err = r.reconcileFieldIndexes(ctx, instance)
+	require.NoError(t, err)
+	require.NoError(t, r.Get(ctx, types.NamespacedName{Name: fieldIndexesName, Namespace: testNamespace}, job))
+	job.Status.Succeeded = 1
+	require.NoError(t, r.Status().Update(ctx, job))
+	result, err = r.reconcileFieldIndexes(ctx, instance)
+	require.NoError(t, err)
+	assert.Nil(t, result)
+	assert.Empty(t, instance.Status.Conditions)
+	assert.Equal(t, desiredFieldIndexes(fieldIndexTestSpec()), instance.Status.FieldIndexes)
+}
@@ -16,6 +16,7 @@ const DBNAME = "search"
 const (
 	apiReadonlySecretName = "search-postgres-api-readonly" // #nosec G101 - False positive, this is a secret name, not a password
 	mcpReadonlySecretName = "search-postgres-mcp-readonly" // #nosec G101 - False positive, this is a secret name, not a password
+	monitorSecretName     = "search-postgres-monitor"      // #nosec G101 - False positive, this is a secret name, not a password
 )
 
 func (r *SearchReconciler) PGSecret(instance *searchv1alpha1.Search) *corev1.Secret {
@@ -78,6 +79,28 @@ func (r *SearchReconciler) MCPReadonlySecret(instance *searchv1alpha1.Search) *c
 	}
 	return secret
 }
+
+// MonitorSecret holds the credentials of the search_monitor role used by the postgres_exporter sidecar.
+func (r *SearchReconciler) MonitorSecret(instance *searchv1alpha1.Search) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      monitorSecretName,
+			Namespace: instance.GetNamespace(),
+		},
+		Type: corev1.SecretTypeOpaque,
+	}
+	secret.StringData = map[string]string{
+		"database-user":     "search_monitor",
+		"database-password": generatePass(16),
+		"database-name":     DBNAME,
+	}
+	err := controllerutil.SetControllerReference(instance, secret, r.Scheme)
+	if err != nil {
+		log.V(2).Info("Could not set control for search-postgres-monitor secret")
+	}
+	return secret
+}
+
 func generatePass(length int) string {
 	chars := "ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
 		"abcdefghijklmnopqrstuvwxyz" +
@@ -4,6 +4,7 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/api/equality"
 
@@ -20,6 +21,13 @@ import (
 
 const SearchPVCAlertRuleName = "search-pvc-info-alert"
 
+// searchRunbookURL is the base of the runbook_url annotation. Each alert links to its own section.
+const searchRunbookURL = "https://github.com/stolostron/search-v2-operator/blob/main/docs/RUNBOOKS.md#"
+
+func runbookURL(alert string) string {
+	return searchRunbookURL + strings.ToLower(alert)
+}
+
 // SearchPVCPrometheusRule creates a PrometheusRule for PVC info alert
 func (r *SearchReconciler) SearchPVCPrometheusRule(instance *searchv1alpha1.Search) *monitorv1.PrometheusRule {
 	pvcAbsentExpr := fmt.Sprintf(`absent(kube_persistentvolumeclaim_info{namespace="%s", persistentvolumeclaim=~".*-search"}) == 1`, instance.GetNamespace())
@@ -83,6 +91,7 @@ func (r *SearchReconciler) SearchPVCPrometheusRule(instance *searchv1alpha1.Sear
 								"summary":     "Search Persistent Volume Claim is not present",
 								"description": "Search PVC is not present in namespace " + instance.GetNamespace() + ". You should configure persistent storage for RHACM Search in production environments. See docs.redhat.com for more information about RHACM Search with persistent storage.",
 								"message":     "Search is currently running without persistent storage. Consider configuring a PVC by setting spec.dbStorage.storageClassName in the RHACM Search CR for better performance.",
+								"runbook_url": runbookURL("SearchPVCNotPresent"),
 							},
 						},
 					},
@@ -91,7 +100,7 @@ func (r *SearchReconciler) SearchPVCPrometheusRule(instance *searchv1alpha1.Sear
 		},
 	}
 
-	if !isPrometheusAlertSearchPVCCriticalDisabled(instance) {
+	if isPrometheusAlertEnabled(instance, searchv1alpha1.AlertSearchPVCNotPresentCritical) {
 		// Add the Search PVC critical alert - will fire if the PVC is missing and the conditions are met (system load high enough or a core search pod has crashed due to memory limits)
 		rule.Spec.Groups[0].Rules = append(rule.Spec.Groups[0].Rules, monitorv1.Rule{
 			Alert: "SearchPVCNotPresentCritical",
@@ -116,11 +125,20 @@ func (r *SearchReconciler) SearchPVCPrometheusRule(instance *searchv1alpha1.Sear
 					"- The PVC is created/restored: As soon as kube_persistentvolumeclaim_info appears in Prometheus for the " + instance.GetNamespace() + " namespace\n" +
 					"OR\n" +
 					"- The underlying \"Load\" or \"Crash\" clears",
-				"message": "Search is currently running without persistent storage. System usage is high enough that persistent storage is needed to avoid performance issues. Consider configuring a PVC by setting spec.dbStorage.storageClassName in the RHACM Search CR for better performance.",
+				"message":     "Search is currently running without persistent storage. System usage is high enough that persistent storage is needed to avoid performance issues. Consider configuring a PVC by setting spec.dbStorage.storageClassName in the RHACM Search CR for better performance.",
+				"runbook_url": runbookURL("SearchPVCNotPresentCritical"),
 			},
 		})
 	}
 
+	rule.Spec.Groups = append(rule.Spec.Groups,
+		searchDatabaseAlerts(instance),
+		searchIndexerAlerts(instance),
+		searchAPIAlerts(instance),
+		searchCollectorAlerts(instance),
+	)
+	applyAlertConfigs(instance, rule)
+
 	err := controllerutil.SetControllerReference(instance, rule, r.Scheme)
 	if err != nil {
 		log.Info("Could not set controller reference for PrometheusRule", "name", SearchPVCAlertRuleName)
@@ -128,6 +146,162 @@ func (r *SearchReconciler) SearchPVCPrometheusRule(instance *searchv1alpha1.Sear
 	return rule
 }
 
+// applyAlertConfigs applies spec.monitoring.alerts to the generated rules: disabled alerts are
+// removed, and the configured for duration and extra labels are set. Groups left without rules
+// are dropped.
+func applyAlertConfigs(instance *searchv1alpha1.Search, rule *monitorv1.PrometheusRule) {
+	groups := []monitorv1.RuleGroup{}
+	for _, group := range rule.Spec.Groups {
+		rules := []monitorv1.Rule{}
+		for _, r := range group.Rules {
+			alert := searchv1alpha1.SearchAlertName(r.Alert)
+			if !isPrometheusAlertEnabled(instance, alert) {
+				continue
+			}
+			if cfg, ok := getAlertConfig(instance, alert); ok {
+				if cfg.For != "" {
+					r.For = ptr.To(monitorv1.Duration(cfg.For))
+				}
+				for k, v := range cfg.Labels {
+					r.Labels[k] = v
+				}
+			}
+			rules = append(rules, r)
+		}
+		if len(rules) > 0 {
+			group.Rules = rules
+			groups = append(groups, group)
+		}
+	}
+	rule.Spec.Groups = groups
+}
+
+// searchAlert builds a rule with the labels and annotations shared by all search alerts.
+func searchAlert(alert, expr, forDuration, severity, summary, description string) monitorv1.Rule {
+	return monitorv1.Rule{
+		Alert: alert,
+		Expr:  intstr.FromString(expr),
+		For:   ptr.To(monitorv1.Duration(forDuration)),
+		Labels: map[string]string{
+			"severity":  severity,
+			"component": "search",
+		},
+		Annotations: map[string]string{
+			"summary":     summary,
+			"description": description,
+			"runbook_url": runbookURL(alert),
+		},
+	}
+}
+
+// searchDatabaseAlerts covers PVC usage, postgres restarts and, when the postgres_exporter sidecar
+// is enabled, long-running queries and connection saturation.
+func searchDatabaseAlerts(instance *searchv1alpha1.Search) monitorv1.RuleGroup {
+	ns := instance.GetNamespace()
+	pvcUsageExpr := fmt.Sprintf(`100 * max by (persistentvolumeclaim) (
+			kubelet_volume_stats_used_bytes{namespace="%s", persistentvolumeclaim=~".*-search"}
+			/ kubelet_volume_stats_capacity_bytes{namespace="%s", persistentvolumeclaim=~".*-search"}
+		) > %%s`, ns, ns)
+	warningPct := getPrometheusAlertThreshold(instance, searchv1alpha1.AlertSearchPVCUsageHigh,
+		alertThresholdPercent, defaultPrometheusAlertPVCUsageWarningPercent)
+	criticalPct := getPrometheusAlertThreshold(instance, searchv1alpha1.AlertSearchPVCUsageCritical,
+		alertThresholdPercent, defaultPrometheusAlertPVCUsageCriticalPercent)
+	maxRestarts := getPrometheusAlertThreshold(instance, searchv1alpha1.AlertSearchPostgresRestarting,
+		alertThresholdRestarts, defaultPrometheusAlertMaxPostgresRestarts1h)
+
+	rules := []monitorv1.Rule{
+		searchAlert("SearchPVCUsageHigh", fmt.Sprintf(pvcUsageExpr, warningPct), "15m", "warning",
+			"Search database volume is filling up",
+			"The search-postgres volume {{ $labels.persistentvolumeclaim }} in namespace "+ns+
+				" is more than "+warningPct+"% full."),
+		searchAlert("SearchPVCUsageCritical", fmt.Sprintf(pvcUsageExpr, criticalPct), "5m", "critical",
+			"Search database volume is almost full",
+			"The search-postgres volume {{ $labels.persistentvolumeclaim }} in namespace "+ns+
+				" is more than "+criticalPct+"% full. Postgres stops accepting writes when the volume is full."),
+		searchAlert("SearchPostgresRestarting", fmt.Sprintf(`increase(kube_pod_container_status_restarts_total{
+			namespace="%s", pod=~"search-postgres.*", container="search-postgres"}[1h]) > %s`, ns, maxRestarts),
+			"5m", "warning",
+			"Search database is restarting",
+			"The search-postgres container restarted more than "+maxRestarts+" times in the last hour."),
+	}
+
+	if isPostgresExporterEnabled(instance) {
+		maxQuerySeconds := getPrometheusAlertThreshold(instance, searchv1alpha1.AlertSearchPostgresLongRunningQuery,
+			alertThresholdSeconds, defaultPrometheusAlertMaxQueryDurationSeconds)
+		maxConnectionsPct := getPrometheusAlertThreshold(instance, searchv1alpha1.AlertSearchPostgresConnectionsHigh,
+			alertThresholdPercent, defaultPrometheusAlertMaxConnectionsPercent)
+		rules = append(rules,
+			searchAlert("SearchPostgresLongRunningQuery", fmt.Sprintf(
+				`max(pg_stat_activity_max_tx_duration{namespace="%s", datname="%s"}) > %s`, ns, DBNAME, maxQuerySeconds),
+				"5m", "warning",
+				"Search database has long-running queries",
+				"A transaction in the search database has been running for more than "+maxQuerySeconds+" seconds."),
+			searchAlert("SearchPostgresConnectionsHigh", fmt.Sprintf(
+				`100 * sum(pg_stat_activity_count{namespace="%s"}) / max(pg_settings_max_connections{namespace="%s"}) > %s`,
+				ns, ns, maxConnectionsPct),
+				"10m", "warning",
+				"Search database is running out of connections",
+				"More than "+maxConnectionsPct+"% of the search-postgres max_connections are in use."),
+		)
+	}
+	return monitorv1.RuleGroup{Name: "search-database-alerts", Rules: rules}
+}
+
+// searchIndexerAlerts covers search-indexer request latency.
+func searchIndexerAlerts(instance *searchv1alpha1.Search) monitorv1.RuleGroup {
+	maxLatency := getPrometheusAlertThreshold(instance, searchv1alpha1.AlertSearchIndexerLatencyHigh,
+		alertThresholdSeconds, defaultPrometheusAlertMaxIndexerLatencySecs)
+	return monitorv1.RuleGroup{
+		Name: "search-indexer-alerts",
+		Rules: []monitorv1.Rule{
+			searchAlert("SearchIndexerLatencyHigh", fmt.Sprintf(`histogram_quantile(0.95,
+				sum by (le) (rate(search_indexer_request_duration_bucket{namespace="%s"}[10m]))) > %s`,
+				instance.GetNamespace(), maxLatency),
+				"15m", "warning",
+				"Search indexer requests are slow",
+				"The 95th percentile of search-indexer request duration is above "+maxLatency+" seconds."),
+		},
+	}
+}
+
+// searchAPIAlerts covers the search-api error rate.
+func searchAPIAlerts(instance *searchv1alpha1.Search) monitorv1.RuleGroup {
+	ns := instance.GetNamespace()
+	maxErrorPct := getPrometheusAlertThreshold(instance, searchv1alpha1.AlertSearchAPIErrorRateHigh,
+		alertThresholdPercent, defaultPrometheusAlertMaxAPIErrorPercent)
+	return monitorv1.RuleGroup{
+		Name: "search-api-alerts",
+		Rules: []monitorv1.Rule{
+			searchAlert("SearchAPIErrorRateHigh", fmt.Sprintf(`100 *
+				sum(rate(search_api_request_duration_count{namespace="%s", code=~"5.."}[10m]))
+				/ sum(rate(search_api_request_duration_count{namespace="%s"}[10m])) > %s`, ns, ns, maxErrorPct),
+				"10m", "warning",
+				"Search API is returning errors",
+				"More than "+maxErrorPct+"% of search-api requests failed with a server error in the last 10 minutes."),
+		},
+	}
+}
+
+// searchCollectorAlerts fires for managed clusters whose collector sent data to the indexer in the
+// last 6 hours but has been silent for the configured duration.
+func searchCollectorAlerts(instance *searchv1alpha1.Search) monitorv1.RuleGroup {
+	ns := instance.GetNamespace()
+	silence := getPrometheusAlertCollectorSilence(instance)
+	return monitorv1.RuleGroup{
+		Name: "search-collector-alerts",
+		Rules: []monitorv1.Rule{
+			searchAlert("SearchCollectorNotReporting", fmt.Sprintf(`(
+				sum by (managed_cluster_name) (increase(search_indexer_request_duration_count{namespace="%s"}[6h])) > 0
+			) unless (
+				sum by (managed_cluster_name) (increase(search_indexer_request_duration_count{namespace="%s"}[%s])) > 0
+			)`, ns, ns, silence),
+				"5m", "warning",
+				"Search collector stopped reporting",
+				"The search-collector on managed cluster {{ $labels.managed_cluster_name }} has not sent data to the indexer in the last "+silence+"."),
+		},
+	}
+}
+
 // createOrUpdatePrometheusRule creates or updates a PrometheusRule
 func (r *SearchReconciler) createOrUpdatePrometheusRule(ctx context.Context,
 	rule *monitorv1.PrometheusRule,
@@ -0,0 +1,117 @@
+// Copyright Contributors to the Open Cluster Management project
+package controllers
+
+import (
+	"testing"
+
+	monitorv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func findAlert(rule *monitorv1.PrometheusRule, alert string) *monitorv1.Rule {
+	for _, group := range rule.Spec.Groups {
+		for i := range group.Rules {
+			if group.Rules[i].Alert == alert {
+				return &group.Rules[i]
+			}
+		}
+	}
+	return nil
+}
+
+func TestSearchPVCPrometheusRule_Groups(t *testing.T) {
+	t.Setenv("POSTGRES_EXPORTER_IMAGE", "")
+	instance := newSearchInstance()
+	r := setupExporterReconciler(t, instance)
+
+	rule := r.SearchPVCPrometheusRule(instance)
+	groups := []string{}
+	for _, g := range rule.Spec.Groups {
+		groups = append(groups, g.Name)
+	}
+	assert.Equal(t, []string{"search-pvc-alerts", "search-database-alerts", "search-indexer-alerts",
+		"search-api-alerts", "search-collector-alerts"}, groups)
+
+	for _, alert := range []string{"SearchPVCNotPresent", "SearchPVCNotPresentCritical", "SearchPVCUsageHigh",
+		"SearchPVCUsageCritical", "SearchPostgresRestarting", "SearchIndexerLatencyHigh", "SearchAPIErrorRateHigh",
+		"SearchCollectorNotReporting"} {
+		a := findAlert(rule, alert)
+		require.NotNil(t, a, "missing alert %s", alert)
+		assert.Equal(t, runbookURL(alert), a.Annotations["runbook_url"])
+		assert.Equal(t, "search", a.Labels["component"])
+	}
+
+	// Queries that need postgres_exporter metrics are only added when the exporter is running.
+	assert.Nil(t, findAlert(rule, "SearchPostgresLongRunningQuery"))
+	assert.Nil(t, findAlert(rule, "SearchPostgresConnectionsHigh"))
+}
+
+func TestSearchPVCPrometheusRule_ExporterAlerts(t *testing.T) {
+	t.Setenv("POSTGRES_EXPORTER_IMAGE", "quay.io/stolostron/postgres-exporter:env")
+	instance := newSearchInstance()
+	instance.Spec.Monitoring.DatabaseExporter.Enabled = true
+	r := setupExporterReconciler(t, instance)
+
+	rule := r.SearchPVCPrometheusRule(instance)
+	assert.NotNil(t, findAlert(rule, "SearchPostgresLongRunningQuery"))
+	assert.NotNil(t, findAlert(rule, "SearchPostgresConnectionsHigh"))
+}
+
+func TestSearchPVCPrometheusRule_Thresholds(t *testing.T) {
+	instance := newSearchInstance()
+	instance.Spec.Monitoring.Alerts = []searchv1alpha1.AlertConfig{
+		{
+			Name:       searchv1alpha1.AlertSearchPVCUsageHigh,
+			Thresholds: map[string]searchv1alpha1.AlertThreshold{alertThresholdPercent: "65"},
+		},
+		{
+			Name:       searchv1alpha1.AlertSearchAPIErrorRateHigh,
+			Thresholds: map[string]searchv1alpha1.AlertThreshold{alertThresholdPercent: "12"},
+		},
+		{
+			Name:       searchv1alpha1.AlertSearchCollectorNotReporting,
+			Thresholds: map[string]searchv1alpha1.AlertThreshold{alertThresholdMinutes: "120"},
+		},
+	}
+	r := setupExporterReconciler(t, instance)
+
+	rule := r.SearchPVCPrometheusRule(instance)
+	assert.Contains(t, findAlert(rule, "SearchPVCUsageHigh").Expr.String(), "> 65")
+	assert.Contains(t, findAlert(rule, "SearchPVCUsageCritical").Expr.String(), "> "+defaultPrometheusAlertPVCUsageCriticalPercent)
+	assert.Contains(t, findAlert(rule, "SearchAPIErrorRateHigh").Expr.String(), "> 12")
+	assert.Contains(t, findAlert(rule, "SearchCollectorNotReporting").Expr.String(), "[120m]")
+}
+
+func TestSearchPVCPrometheusRule_AlertConfigs(t *testing.T) {
+	instance := newSearchInstance()
+	instance.Spec.Monitoring.Alerts = []searchv1alpha1.AlertConfig{
+		{
+			Name:   searchv1alpha1.AlertSearchAPIErrorRateHigh,
+			For:    "30m",
+			Labels: map[string]string{"receiver": "search-team", "severity": "critical"},
+			Thresholds: map[string]searchv1alpha1.AlertThreshold{
+				alertThresholdPercent: "20",
+			},
+		},
+		{Name: searchv1alpha1.AlertSearchIndexerLatencyHigh, Enabled: ptr.To(false)},
+	}
+	r := setupExporterReconciler(t, instance)
+
+	rule := r.SearchPVCPrometheusRule(instance)
+	api := findAlert(rule, "SearchAPIErrorRateHigh")
+	require.NotNil(t, api)
+	assert.Equal(t, monitorv1.Duration("30m"), *api.For)
+	assert.Equal(t, "search-team", api.Labels["receiver"])
+	assert.Equal(t, "critical", api.Labels["severity"])
+	assert.Equal(t, "search", api.Labels["component"])
+	assert.Contains(t, api.Expr.String(), "> 20")
+
+	// Disabling the only alert in a group removes the group.
+	assert.Nil(t, findAlert(rule, "SearchIndexerLatencyHigh"))
+	for _, g := range rule.Spec.Groups {
+		assert.NotEqual(t, "search-indexer-alerts", g.Name)
+	}
+}
@@ -250,6 +250,18 @@ func getAddonRules() []rbacv1.PolicyRule {
 			Resources: []string{"leases"},
 			Verbs:     []string{"create", "get", "list", "watch", "patch", "update"},
 		},
+		{
+			// The per-cluster merged-collector-config in the cluster namespace, and its
+			// status for collector acknowledgements.
+			APIGroups: []string{"search.open-cluster-management.io"},
+			Resources: []string{"collectorconfigs"},
+			Verbs:     []string{"get", "list", "watch"},
+		},
+		{
+			APIGroups: []string{"search.open-cluster-management.io"},
+			Resources: []string{"collectorconfigs/status"},
+			Verbs:     []string{"patch", "update"},
+		},
 	}
 }
 
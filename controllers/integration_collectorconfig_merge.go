@@ -0,0 +1,223 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// mergeIntegrationSpec is a three-way merge of a built-in integration CollectorConfig's spec. The
+// base is the shipped default last applied (AnnotationLastAppliedDefault), desired is the default
+// shipped now, and live is the spec on the cluster. Fields the default did not change keep their
+// live value and fields the user did not change take the new default. A field both changed, to
+// different values, is a conflict: it keeps the live value and is returned in conflicts.
+//
+// Objects are merged key by key. collectionRules are matched by action and resourceSelector, so
+// the default can add, change or remove rules while rules the user added or removed stay that way.
+// Other lists are replaced as a whole. Without a base (configs written before the annotation
+// existed) the live spec is the base, so the default replaces it like the old overwrite did.
+func mergeIntegrationSpec(base, desired, live searchv1alpha1.CollectorConfigSpec) (
+	merged searchv1alpha1.CollectorConfigSpec, conflicts []string, err error) {
+	values := make([]map[string]interface{}, 3)
+	for i, spec := range []searchv1alpha1.CollectorConfigSpec{base, desired, live} {
+		if values[i], err = runtime.DefaultUnstructuredConverter.ToUnstructured(&spec); err != nil {
+			return merged, nil, err
+		}
+	}
+	result, _ := mergeValue("spec", values[0], values[1], values[2], &conflicts).(map[string]interface{})
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(result, &merged); err != nil {
+		return merged, nil, err
+	}
+	return merged, conflicts, nil
+}
+
+// mergeValue merges one JSON value. A nil value means the field is not set.
+func mergeValue(path string, base, desired, live interface{}, conflicts *[]string) interface{} {
+	switch {
+	case equality.Semantic.DeepEqual(desired, base):
+		return live
+	case equality.Semantic.DeepEqual(live, base), equality.Semantic.DeepEqual(live, desired):
+		return desired
+	}
+	if path == "spec.collectionRules" {
+		b, _ := base.([]interface{})
+		d, dok := desired.([]interface{})
+		l, lok := live.([]interface{})
+		if dok && lok {
+			return mergeRules(b, d, l, conflicts)
+		}
+	}
+	b, _ := base.(map[string]interface{})
+	d, dok := desired.(map[string]interface{})
+	l, lok := live.(map[string]interface{})
+	if !dok || !lok {
+		*conflicts = append(*conflicts, path)
+		return live
+	}
+	keys := map[string]bool{}
+	for _, m := range []map[string]interface{}{b, d, l} {
+		for k := range m {
+			keys[k] = true
+		}
+	}
+	result := map[string]interface{}{}
+	for k := range keys {
+		if v := mergeValue(path+"."+k, b[k], d[k], l[k], conflicts); v != nil {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// mergeRules merges collectionRules matched by action and resourceSelector. The result keeps the
+// live order, followed by the rules the default added.
+func mergeRules(base, desired, live []interface{}, conflicts *[]string) []interface{} {
+	baseRules, desiredRules := rulesByKey(base), rulesByKey(desired)
+	seen := map[string]bool{}
+	var result []interface{}
+	for i, l := range live {
+		key := ruleKey(l)
+		b, d := baseRules[key], desiredRules[key]
+		if seen[key] {
+			// A duplicate live rule has nothing to merge with.
+			result = append(result, l)
+			continue
+		}
+		seen[key] = true
+		switch {
+		case b == nil && d == nil:
+			// Added by the user.
+			result = append(result, l)
+		case d == nil:
+			// Removed from the default; a rule the user changed is kept.
+			if !equality.Semantic.DeepEqual(l, b) {
+				*conflicts = append(*conflicts, fmt.Sprintf("spec.collectionRules[%d]", i))
+				result = append(result, l)
+			}
+		default:
+			path := fmt.Sprintf("spec.collectionRules[%d]", i)
+			if v := mergeValue(path, b, d, l, conflicts); v != nil {
+				result = append(result, v)
+			}
+		}
+	}
+	for i, d := range desired {
+		key := ruleKey(d)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		b := baseRules[key]
+		switch {
+		case b == nil:
+			// Added to the default.
+			result = append(result, d)
+		case !equality.Semantic.DeepEqual(d, b):
+			// Removed by the user but changed in the default; it stays removed.
+			*conflicts = append(*conflicts, fmt.Sprintf("rule %d of the default, which was removed", i))
+		}
+	}
+	return result
+}
+
+func rulesByKey(rules []interface{}) map[string]interface{} {
+	byKey := map[string]interface{}{}
+	for _, r := range rules {
+		if key := ruleKey(r); byKey[key] == nil {
+			byKey[key] = r
+		}
+	}
+	return byKey
+}
+
+// ruleKey identifies a collection rule by its action and resourceSelector.
+func ruleKey(rule interface{}) string {
+	m, _ := rule.(map[string]interface{})
+	key, _ := json.Marshal([]interface{}{m["action"], m["resourceSelector"]})
+	return string(key)
+}
+
+// resetRequested reports whether any of the configs carries AnnotationReset.
+func resetRequested(configs []searchv1alpha1.CollectorConfig) bool {
+	for i := range configs {
+		if _, ok := configs[i].Annotations[searchv1alpha1.AnnotationReset]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// lastAppliedDefault returns the spec recorded in AnnotationLastAppliedDefault, if any.
+func lastAppliedDefault(cc *searchv1alpha1.CollectorConfig) (*searchv1alpha1.CollectorConfigSpec, bool) {
+	raw, ok := cc.Annotations[searchv1alpha1.AnnotationLastAppliedDefault]
+	if !ok {
+		return nil, false
+	}
+	spec := &searchv1alpha1.CollectorConfigSpec{}
+	if err := json.Unmarshal([]byte(raw), spec); err != nil {
+		log.Info("Ignoring unreadable last-applied-default annotation", "name", cc.Name, "error", err.Error())
+		return nil, false
+	}
+	return spec, true
+}
+
+// setLastAppliedDefault records spec in AnnotationLastAppliedDefault.
+func setLastAppliedDefault(cc *searchv1alpha1.CollectorConfig, spec searchv1alpha1.CollectorConfigSpec) error {
+	raw, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	if cc.Annotations == nil {
+		cc.Annotations = map[string]string{}
+	}
+	cc.Annotations[searchv1alpha1.AnnotationLastAppliedDefault] = string(raw)
+	return nil
+}
+
+// defaultConflictsCondition returns the DefaultConflicts condition for the conflicting fields.
+func defaultConflictsCondition(conflicts []string) metav1.Condition {
+	condition := metav1.Condition{
+		Type:    searchv1alpha1.CollectorConfigConditionDefaultConflicts,
+		Status:  metav1.ConditionFalse,
+		Reason:  searchv1alpha1.CollectorConfigReasonNoConflicts,
+		Message: "The shipped default merged without conflicts.",
+	}
+	if len(conflicts) > 0 {
+		sorted := append([]string(nil), conflicts...)
+		sort.Strings(sorted)
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = searchv1alpha1.CollectorConfigReasonLiveValuesKept
+		condition.Message = fmt.Sprintf("The shipped default and the live config both changed %s; the live values "+
+			"were kept. Add the %s annotation to restore the default.",
+			strings.Join(sorted, ", "), searchv1alpha1.AnnotationReset)
+	}
+	return condition
+}
+
+// setDefaultConflictsCondition writes the DefaultConflicts condition when it changed. Configs that
+// never had a conflict don't get the condition.
+func setDefaultConflictsCondition(ctx context.Context, c client.Client, cc *searchv1alpha1.CollectorConfig,
+	conflicts []string) error {
+	condition := defaultConflictsCondition(conflicts)
+	existing := apimeta.FindStatusCondition(cc.Status.Conditions, condition.Type)
+	if existing == nil && len(conflicts) == 0 {
+		return nil
+	}
+	if existing != nil && existing.Status == condition.Status &&
+		existing.Reason == condition.Reason && existing.Message == condition.Message {
+		return nil
+	}
+	apimeta.SetStatusCondition(&cc.Status.Conditions, condition)
+	return c.Status().Update(ctx, cc)
+}
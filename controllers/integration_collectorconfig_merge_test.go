@@ -0,0 +1,207 @@
+// Copyright Contributors to the Open Cluster Management project
+package controllers
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+)
+
+func includeRule(group string, fields ...string) searchv1alpha1.CollectionRule {
+	rule := searchv1alpha1.CollectionRule{
+		Action:           searchv1alpha1.ActionInclude,
+		ResourceSelector: searchv1alpha1.ResourceSelector{APIGroups: []string{group}, Kinds: []string{"Widget"}},
+	}
+	for _, f := range fields {
+		rule.Fields = append(rule.Fields, searchv1alpha1.Field{Name: f, JSONPath: "{.spec." + f + "}"})
+	}
+	return rule
+}
+
+// defaultFS returns a filesystem with one shipped integration config named team-integration.
+func defaultFS(t *testing.T, spec searchv1alpha1.CollectorConfigSpec) fstest.MapFS {
+	t.Helper()
+	cc := newIntegrationTeamConfig("team-integration", spec)
+	data, err := yaml.Marshal(cc)
+	require.NoError(t, err)
+	return fstest.MapFS{"configs/team.yaml": &fstest.MapFile{Data: data}}
+}
+
+// liveTeamConfig returns the live team-integration config, last applied from base.
+func liveTeamConfig(t *testing.T, base, live searchv1alpha1.CollectorConfigSpec) *searchv1alpha1.CollectorConfig {
+	t.Helper()
+	cc := newIntegrationTeamConfig("team-integration", live)
+	require.NoError(t, setLastAppliedDefault(cc, base))
+	return cc
+}
+
+func applyTeamConfig(t *testing.T, r *SearchReconciler, fsys fstest.MapFS) *searchv1alpha1.CollectorConfig {
+	t.Helper()
+	require.NoError(t, applyIntegrationCollectorConfigsFrom(context.TODO(), r.Client, testScheme(), testSearchOwner(),
+		fsys, "configs"))
+	cc := &searchv1alpha1.CollectorConfig{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: "team-integration", Namespace: testNamespace}, cc))
+	return cc
+}
+
+func TestMergeIntegrationSpec(t *testing.T) {
+	tests := []struct {
+		name                string
+		base, desired, live []searchv1alpha1.CollectionRule
+		want                []searchv1alpha1.CollectionRule
+		conflicts           []string
+	}{
+		{
+			name:    "default adds a rule and the user's rule is kept",
+			base:    []searchv1alpha1.CollectionRule{includeRule("a")},
+			desired: []searchv1alpha1.CollectionRule{includeRule("a"), includeRule("b")},
+			live:    []searchv1alpha1.CollectionRule{includeRule("a"), includeRule("user")},
+			want:    []searchv1alpha1.CollectionRule{includeRule("a"), includeRule("user"), includeRule("b")},
+		},
+		{
+			name:    "default changes a rule the user did not edit",
+			base:    []searchv1alpha1.CollectionRule{includeRule("a", "x")},
+			desired: []searchv1alpha1.CollectionRule{includeRule("a", "y")},
+			live:    []searchv1alpha1.CollectionRule{includeRule("a", "x")},
+			want:    []searchv1alpha1.CollectionRule{includeRule("a", "y")},
+		},
+		{
+			name:    "default removes a rule",
+			base:    []searchv1alpha1.CollectionRule{includeRule("a"), includeRule("b")},
+			desired: []searchv1alpha1.CollectionRule{includeRule("a")},
+			live:    []searchv1alpha1.CollectionRule{includeRule("a"), includeRule("b")},
+			want:    []searchv1alpha1.CollectionRule{includeRule("a")},
+		},
+		{
+			name:    "a rule the user removed stays removed",
+			base:    []searchv1alpha1.CollectionRule{includeRule("a"), includeRule("b")},
+			desired: []searchv1alpha1.CollectionRule{includeRule("a"), includeRule("b"), includeRule("c")},
+			live:    []searchv1alpha1.CollectionRule{includeRule("a")},
+			want:    []searchv1alpha1.CollectionRule{includeRule("a"), includeRule("c")},
+		},
+		{
+			name:      "both change the same rule",
+			base:      []searchv1alpha1.CollectionRule{includeRule("a", "x")},
+			desired:   []searchv1alpha1.CollectionRule{includeRule("a", "y")},
+			live:      []searchv1alpha1.CollectionRule{includeRule("a", "z")},
+			want:      []searchv1alpha1.CollectionRule{includeRule("a", "z")},
+			conflicts: []string{"spec.collectionRules[0].fields"},
+		},
+		{
+			name:      "default changes a rule the user removed",
+			base:      []searchv1alpha1.CollectionRule{includeRule("a"), includeRule("b", "x")},
+			desired:   []searchv1alpha1.CollectionRule{includeRule("a"), includeRule("b", "y")},
+			live:      []searchv1alpha1.CollectionRule{includeRule("a")},
+			want:      []searchv1alpha1.CollectionRule{includeRule("a")},
+			conflicts: []string{"rule 1 of the default, which was removed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, conflicts, err := mergeIntegrationSpec(
+				searchv1alpha1.CollectorConfigSpec{CollectionRules: tt.base},
+				searchv1alpha1.CollectorConfigSpec{CollectionRules: tt.desired},
+				searchv1alpha1.CollectorConfigSpec{CollectionRules: tt.live})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, merged.CollectionRules)
+			assert.Equal(t, tt.conflicts, conflicts)
+		})
+	}
+}
+
+func TestMergeIntegrationSpec_KeepsOtherUserFields(t *testing.T) {
+	base := searchv1alpha1.CollectorConfigSpec{CollectionRules: []searchv1alpha1.CollectionRule{includeRule("a")}}
+	desired := searchv1alpha1.CollectorConfigSpec{CollectionRules: []searchv1alpha1.CollectionRule{includeRule("a", "x")}}
+	live := *base.DeepCopy()
+	live.CollectNamespaces = &searchv1alpha1.CollectNamespaces{
+		NamespaceSelector: &searchv1alpha1.NamespaceSelector{Exclude: []string{"scratch"}}}
+
+	merged, conflicts, err := mergeIntegrationSpec(base, desired, live)
+	require.NoError(t, err)
+	assert.Empty(t, conflicts)
+	assert.Equal(t, live.CollectNamespaces, merged.CollectNamespaces)
+	assert.Equal(t, desired.CollectionRules, merged.CollectionRules)
+}
+
+func TestApplyIntegrationCollectorConfig_ThreeWayMerge(t *testing.T) {
+	base := searchv1alpha1.CollectorConfigSpec{CollectionRules: []searchv1alpha1.CollectionRule{includeRule("a")}}
+	desired := searchv1alpha1.CollectorConfigSpec{CollectionRules: []searchv1alpha1.CollectionRule{
+		includeRule("a"), includeRule("b")}}
+	r := setupReconciler(liveTeamConfig(t, base, searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{includeRule("a"), includeRule("user")}}))
+
+	cc := applyTeamConfig(t, r, defaultFS(t, desired))
+
+	assert.Equal(t, []searchv1alpha1.CollectionRule{includeRule("a"), includeRule("user"), includeRule("b")},
+		cc.Spec.CollectionRules, "the upstream rule is added and the user's rule is kept")
+	lastApplied, ok := lastAppliedDefault(cc)
+	require.True(t, ok)
+	assert.Equal(t, desired, *lastApplied)
+	assert.Nil(t, apimeta.FindStatusCondition(cc.Status.Conditions, searchv1alpha1.CollectorConfigConditionDefaultConflicts))
+}
+
+func TestApplyIntegrationCollectorConfig_ReportsConflicts(t *testing.T) {
+	base := searchv1alpha1.CollectorConfigSpec{CollectionRules: []searchv1alpha1.CollectionRule{includeRule("a", "x")}}
+	desired := searchv1alpha1.CollectorConfigSpec{CollectionRules: []searchv1alpha1.CollectionRule{includeRule("a", "y")}}
+	live := searchv1alpha1.CollectorConfigSpec{CollectionRules: []searchv1alpha1.CollectionRule{includeRule("a", "z")}}
+	r := setupReconciler(liveTeamConfig(t, base, live))
+
+	cc := applyTeamConfig(t, r, defaultFS(t, desired))
+
+	assert.Equal(t, live, cc.Spec, "the live value is kept on conflict")
+	condition := apimeta.FindStatusCondition(cc.Status.Conditions, searchv1alpha1.CollectorConfigConditionDefaultConflicts)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Contains(t, condition.Message, "spec.collectionRules[0].fields")
+	lastApplied, _ := lastAppliedDefault(cc)
+	assert.Equal(t, base, *lastApplied, "the base does not move while there are conflicts")
+
+	// Resolving the conflict by hand clears the condition on the next run.
+	cc.Spec = desired
+	require.NoError(t, r.Update(context.TODO(), cc))
+	cc = applyTeamConfig(t, r, defaultFS(t, desired))
+	condition = apimeta.FindStatusCondition(cc.Status.Conditions, searchv1alpha1.CollectorConfigConditionDefaultConflicts)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	lastApplied, _ = lastAppliedDefault(cc)
+	assert.Equal(t, desired, *lastApplied)
+}
+
+func TestApplyIntegrationCollectorConfig_Reset(t *testing.T) {
+	base := searchv1alpha1.CollectorConfigSpec{CollectionRules: []searchv1alpha1.CollectionRule{includeRule("a")}}
+	live := liveTeamConfig(t, base, searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{includeRule("a"), includeRule("user")}})
+	live.Annotations[searchv1alpha1.AnnotationReset] = "true"
+	r := setupReconciler(live)
+
+	cc := applyTeamConfig(t, r, defaultFS(t, base))
+
+	assert.Equal(t, base, cc.Spec)
+	assert.NotContains(t, cc.Annotations, searchv1alpha1.AnnotationReset)
+}
+
+// A reset annotation is handled by the reconcile, without waiting for the next operator start.
+func TestCreateOrUpdateMergedCollectorConfig_ResetsIntegrationConfig(t *testing.T) {
+	instance := newSearchInstance()
+	customized := newIntegrationTeamConfig("cnv-integration", searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{includeRule("custom.example.io")},
+	})
+	customized.Annotations = map[string]string{searchv1alpha1.AnnotationReset: ""}
+	r := setupReconciler(instance, customized)
+
+	_, err := r.createOrUpdateMergedCollectorConfig(context.TODO(), instance)
+	require.NoError(t, err)
+
+	cc := &searchv1alpha1.CollectorConfig{}
+	require.NoError(t, r.Get(context.TODO(), types.NamespacedName{Name: "cnv-integration", Namespace: testNamespace}, cc))
+	assert.NotEqual(t, customized.Spec, cc.Spec)
+	assert.NotContains(t, cc.Annotations, searchv1alpha1.AnnotationReset)
+}
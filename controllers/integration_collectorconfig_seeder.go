@@ -21,8 +21,7 @@ const integrationSeedRetryInterval = 10 * time.Second
 // IntegrationCollectorConfigSeeder is a controller-runtime manager.Runnable (see main.go's
 // mgr.Add call) that applies the built-in integration CollectorConfigs exactly once per operator
 // process, at manager startup — deliberately not on every reconcile. See
-// applyIntegrationCollectorConfigs for why running this only at startup (and unconditionally
-// overwriting, no diffing) is the intended behavior, not a limitation.
+// applyIntegrationCollectorConfigs for how the shipped defaults are merged with live edits.
 //
 // This intentionally isn't a sync.Once: a plain sync.Once marks itself "done" the moment its
 // function is called, even if that call failed (e.g. the CollectorConfig webhook's CA bundle
@@ -89,27 +88,34 @@ func (s *IntegrationCollectorConfigSeeder) NeedLeaderElection() bool {
 	return true
 }
 
-// resolveSearch returns the live Search CR and its pause state. There is always supposed to be
+// resolveSearch returns the live Search CR and its pause state, see findSearch.
+func (s *IntegrationCollectorConfigSeeder) resolveSearch(
+	ctx context.Context,
+) (searchCR *searchv1alpha1.Search, paused bool, err error) {
+	return findSearch(ctx, s.Client, s.Namespace)
+}
+
+// findSearch returns the live Search CR and its pause state. There is always supposed to be
 // exactly one, named OperatorName (see search_controller.go) — this requires exactly one match
-// rather than returning the first one found, so a duplicate CR (a bug elsewhere) causes this to
-// keep retrying with an error instead of silently seeding an arbitrary namespace. If s.Namespace
-// is explicitly set (used by tests), a minimal placeholder Search is returned since the caller
-// only needs its namespace and UID for setting ownerReferences.
+// rather than returning the first one found, so a duplicate CR (a bug elsewhere) causes callers to
+// keep retrying with an error instead of silently writing to an arbitrary namespace. If namespace
+// is explicitly set (used by tests), a minimal placeholder Search is returned since the callers
+// only need its namespace and UID for setting ownerReferences.
 //
 // The rest of the reconciler already gets its namespace this way (from the reconciled object
 // itself, via a cluster-wide watch), not from an env var — WATCH_NAMESPACE/POD_NAMESPACE are not
 // reliably set in real deployments.
-func (s *IntegrationCollectorConfigSeeder) resolveSearch(
-	ctx context.Context,
+func findSearch(
+	ctx context.Context, c client.Client, namespace string,
 ) (searchCR *searchv1alpha1.Search, paused bool, err error) {
-	if s.Namespace != "" {
+	if namespace != "" {
 		// Tests set Namespace directly — return a minimal placeholder with the namespace filled.
 		placeholder := &searchv1alpha1.Search{}
-		placeholder.Namespace = s.Namespace
+		placeholder.Namespace = namespace
 		return placeholder, false, nil
 	}
 	list := &searchv1alpha1.SearchList{}
-	if err := s.Client.List(ctx, list); err != nil {
+	if err := c.List(ctx, list); err != nil {
 		return nil, false, err
 	}
 	var match *searchv1alpha1.Search
@@ -0,0 +1,168 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// integrationConfigsDirEnv names a directory of integration CollectorConfig manifests, such as a
+// mounted volume or image, that the operator loads along with the embedded ones.
+const integrationConfigsDirEnv = "INTEGRATION_COLLECTOR_CONFIGS_DIR"
+
+// Prefixes of the AnnotationIntegrationSource values.
+const (
+	integrationSourceEmbedded  = searchv1alpha1.IntegrationSourceEmbedded
+	integrationSourceDirectory = "directory:"
+	integrationSourceConfigMap = "configmap:"
+)
+
+// integrationManifest is an integration CollectorConfig manifest and where it was read from.
+type integrationManifest struct {
+	// source is recorded in AnnotationIntegrationSource.
+	source string
+	raw    []byte
+	// err is set when the manifest could not be read.
+	err error
+	// shipped is set for the manifests embedded in the operator.
+	shipped bool
+}
+
+// readIntegrationManifests reads the YAML and JSON files in dir. Hidden files are skipped, which
+// leaves out the ..data links of a mounted ConfigMap volume.
+func readIntegrationManifests(fsys fs.FS, dir, prefix string) ([]integrationManifest, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	// Sort for deterministic, readable logs (fs.ReadDir is already sorted, but be explicit).
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	var manifests []integrationManifest
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !isManifestFile(name) {
+			continue
+		}
+		m := integrationManifest{source: prefix + name}
+		m.raw, m.err = fs.ReadFile(fsys, path.Join(dir, name))
+		manifests = append(manifests, m)
+	}
+	return manifests, nil
+}
+
+func isManifestFile(name string) bool {
+	if strings.HasPrefix(name, ".") {
+		return false
+	}
+	switch path.Ext(name) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// readExternalIntegrationManifests reads the integration manifests that don't ship with the
+// operator: the files in the INTEGRATION_COLLECTOR_CONFIGS_DIR directory, and each data key of the
+// ConfigMaps in the namespace labeled IntegrationConfigMapLabel=true. complete is false when a
+// source could not be read.
+func readExternalIntegrationManifests(ctx context.Context, c client.Client, namespace string) (
+	manifests []integrationManifest, complete bool) {
+	complete = true
+	if dir := os.Getenv(integrationConfigsDirEnv); dir != "" {
+		dirManifests, err := readIntegrationManifests(os.DirFS(dir), ".", integrationSourceDirectory)
+		if err != nil {
+			log.Error(err, "Could not read integration CollectorConfig directory", "dir", dir)
+			complete = false
+		}
+		manifests = append(manifests, dirManifests...)
+	}
+
+	configMaps, err := listIntegrationConfigMaps(ctx, c, namespace)
+	if err != nil {
+		log.Error(err, "Could not list integration CollectorConfig ConfigMaps")
+		return manifests, false
+	}
+	for _, cm := range configMaps {
+		keys := make([]string, 0, len(cm.Data))
+		for key := range cm.Data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			manifests = append(manifests, integrationManifest{
+				source: integrationSourceConfigMap + cm.Name + "/" + key,
+				raw:    []byte(cm.Data[key]),
+			})
+		}
+	}
+	return manifests, complete
+}
+
+// listIntegrationConfigMaps returns the ConfigMaps labeled IntegrationConfigMapLabel=true, sorted
+// by name.
+func listIntegrationConfigMaps(ctx context.Context, c client.Client, namespace string) ([]corev1.ConfigMap, error) {
+	list := &corev1.ConfigMapList{}
+	if err := c.List(ctx, list, client.InNamespace(namespace),
+		client.MatchingLabels{searchv1alpha1.IntegrationConfigMapLabel: "true"}); err != nil {
+		return nil, err
+	}
+	sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Name < list.Items[j].Name })
+	return list.Items, nil
+}
+
+// integrationConfigMapsVersion identifies the content of the integration ConfigMaps, so the
+// reconcile loads them again only when one is added, changed or deleted.
+func integrationConfigMapsVersion(ctx context.Context, c client.Client, namespace string) (string, error) {
+	configMaps, err := listIntegrationConfigMaps(ctx, c, namespace)
+	if err != nil {
+		return "", err
+	}
+	versions := make([]string, 0, len(configMaps))
+	for _, cm := range configMaps {
+		versions = append(versions, cm.Name+"@"+cm.ResourceVersion)
+	}
+	return strings.Join(versions, ","), nil
+}
+
+// pruneIntegrationCollectorConfigs deletes the integration CollectorConfigs loaded from a source
+// that no longer exists or now defines a config with another name. sources maps each source read
+// to the config it defines, or "" when its manifest is invalid; those configs are kept. Configs
+// without AnnotationIntegrationSource, or with AnnotationManualOverride, are left alone.
+func pruneIntegrationCollectorConfigs(ctx context.Context, c client.Client, namespace string,
+	sources map[string]string) error {
+	list := &searchv1alpha1.CollectorConfigList{}
+	if err := c.List(ctx, list, client.InNamespace(namespace),
+		client.MatchingLabels{searchv1alpha1.IntegrationTeamLabel: searchv1alpha1.IntegrationTeamLabelValue}); err != nil {
+		return err
+	}
+	for i := range list.Items {
+		cc := &list.Items[i]
+		source, ok := cc.Annotations[searchv1alpha1.AnnotationIntegrationSource]
+		if !ok {
+			continue
+		}
+		if _, overridden := cc.Annotations[searchv1alpha1.AnnotationManualOverride]; overridden {
+			continue
+		}
+		if name, found := sources[source]; found && (name == "" || name == cc.Name) {
+			continue
+		}
+		if err := c.Delete(ctx, cc); err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Could not delete integration CollectorConfig whose source is gone", "name", cc.Name)
+			return err
+		}
+		log.Info("Deleted integration CollectorConfig whose source is gone", "name", cc.Name, "source", source)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+// Copyright Contributors to the Open Cluster Management project
+package controllers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func newIntegrationConfigMap(name string, data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+			Labels:    map[string]string{searchv1alpha1.IntegrationConfigMapLabel: "true"},
+		},
+		Data: data,
+	}
+}
+
+func getIntegrationConfig(r *SearchReconciler, name string) (*searchv1alpha1.CollectorConfig, error) {
+	cc := &searchv1alpha1.CollectorConfig{}
+	err := r.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: testNamespace}, cc)
+	return cc, err
+}
+
+var embeddedTestFS = fstest.MapFS{"configs/team.yaml": &fstest.MapFile{Data: validCollectorConfigYAML("team-integration")}}
+
+func TestApplyIntegrationCollectorConfigs_LoadsConfigMaps(t *testing.T) {
+	cm := newIntegrationConfigMap("tekton", map[string]string{"config.yaml": string(validCollectorConfigYAML("tekton-integration"))})
+	r := setupReconciler(cm)
+
+	require.NoError(t, applyIntegrationCollectorConfigsFrom(context.TODO(), r.Client, testScheme(), testSearchOwner(),
+		embeddedTestFS, "configs"))
+
+	cc, err := getIntegrationConfig(r, "tekton-integration")
+	require.NoError(t, err)
+	assert.Equal(t, "configmap:tekton/config.yaml", cc.Annotations[searchv1alpha1.AnnotationIntegrationSource])
+	assert.Equal(t, searchv1alpha1.IntegrationTeamLabelValue, cc.Labels[searchv1alpha1.IntegrationTeamLabel])
+	cc, err = getIntegrationConfig(r, "team-integration")
+	require.NoError(t, err)
+	assert.Equal(t, "embedded:team.yaml", cc.Annotations[searchv1alpha1.AnnotationIntegrationSource])
+
+	// The config is deleted with its ConfigMap.
+	require.NoError(t, r.Delete(context.TODO(), cm))
+	require.NoError(t, applyIntegrationCollectorConfigsFrom(context.TODO(), r.Client, testScheme(), testSearchOwner(),
+		embeddedTestFS, "configs"))
+	_, err = getIntegrationConfig(r, "tekton-integration")
+	assert.True(t, apierrors.IsNotFound(err))
+	_, err = getIntegrationConfig(r, "team-integration")
+	assert.NoError(t, err)
+}
+
+func TestApplyIntegrationCollectorConfigs_LoadsDirectory(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "crossplane.yaml"), validCollectorConfigYAML("crossplane-integration"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a manifest"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".hidden.yaml"), []byte("not: valid: ["), 0o600))
+	t.Setenv(integrationConfigsDirEnv, dir)
+	r := setupReconciler()
+
+	require.NoError(t, applyIntegrationCollectorConfigsFrom(context.TODO(), r.Client, testScheme(), testSearchOwner(),
+		embeddedTestFS, "configs"))
+
+	cc, err := getIntegrationConfig(r, "crossplane-integration")
+	require.NoError(t, err)
+	assert.Equal(t, "directory:crossplane.yaml", cc.Annotations[searchv1alpha1.AnnotationIntegrationSource])
+
+	// A directory that can't be read doesn't delete the configs it defined.
+	t.Setenv(integrationConfigsDirEnv, filepath.Join(dir, "missing"))
+	require.NoError(t, applyIntegrationCollectorConfigsFrom(context.TODO(), r.Client, testScheme(), testSearchOwner(),
+		embeddedTestFS, "configs"))
+	_, err = getIntegrationConfig(r, "crossplane-integration")
+	assert.NoError(t, err)
+}
+
+func TestApplyIntegrationCollectorConfigs_InvalidExternalManifests(t *testing.T) {
+	invalid := `
+apiVersion: search.open-cluster-management.io/v1alpha1
+kind: CollectorConfig
+metadata:
+  name: broken-integration
+spec:
+  collectionRules:
+    - action: include
+      resourceSelector:
+        apiGroups: ["example.io"]
+        kinds: []
+`
+	cm := newIntegrationConfigMap("external", map[string]string{
+		"a-invalid.yaml":   invalid,
+		"b-duplicate.yaml": string(validCollectorConfigYAML("team-integration")),
+		"c-reserved.yaml":  string(validCollectorConfigYAML(userCollectorConfigName)),
+		"d-unparsable":     "not: valid: yaml: [",
+	})
+	// A config loaded earlier from a key that is now invalid is kept.
+	previous := newIntegrationTeamConfig("broken-integration", searchv1alpha1.CollectorConfigSpec{})
+	previous.Annotations = map[string]string{searchv1alpha1.AnnotationIntegrationSource: "configmap:external/a-invalid.yaml"}
+	r := setupReconciler(cm, previous)
+
+	err := applyIntegrationCollectorConfigsFrom(context.TODO(), r.Client, testScheme(), testSearchOwner(),
+		embeddedTestFS, "configs")
+	assert.NoError(t, err, "invalid external manifests are logged, not retried")
+
+	_, err = getIntegrationConfig(r, "broken-integration")
+	assert.NoError(t, err)
+	cc, err := getIntegrationConfig(r, "team-integration")
+	require.NoError(t, err)
+	assert.Equal(t, "embedded:team.yaml", cc.Annotations[searchv1alpha1.AnnotationIntegrationSource],
+		"the embedded config keeps its name")
+	_, err = getIntegrationConfig(r, userCollectorConfigName)
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestCreateOrUpdateMergedCollectorConfig_LoadsChangedConfigMaps(t *testing.T) {
+	instance := newSearchInstance()
+	r := setupReconciler(instance)
+	_, err := r.createOrUpdateMergedCollectorConfig(context.TODO(), instance)
+	require.NoError(t, err)
+
+	require.NoError(t, r.Create(context.TODO(), newIntegrationConfigMap("tekton",
+		map[string]string{"config.yaml": string(validCollectorConfigYAML("tekton-integration"))})))
+	_, err = r.createOrUpdateMergedCollectorConfig(context.TODO(), instance)
+	require.NoError(t, err)
+
+	_, err = getIntegrationConfig(r, "tekton-integration")
+	require.NoError(t, err)
+	merged, err := getMergedConfig(r)
+	require.NoError(t, err)
+	var sources []string
+	for _, source := range merged.Status.Sources {
+		sources = append(sources, source.Name)
+	}
+	assert.Contains(t, sources, "tekton-integration", "the ConfigMap's config is merged")
+}
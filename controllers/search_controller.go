@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
@@ -32,8 +33,10 @@ import (
 	"github.com/stolostron/search-v2-operator/addon"
 	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -41,7 +44,9 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -54,6 +59,10 @@ type SearchReconciler struct {
 	Scheme        *runtime.Scheme
 	context       context.Context
 	DynamicClient dynamic.Interface
+
+	// integrationConfigMapsVersion is the version of the integration ConfigMaps last loaded, see
+	// integrationConfigMapsVersion().
+	integrationConfigMapsVersion string
 }
 
 const searchFinalizer = "search.open-cluster-management.io/finalizer"
@@ -67,6 +76,7 @@ var cleanOnce sync.Once
 //+kubebuilder:rbac:groups="",resources=secrets;serviceaccounts;services,verbs=create;get;list;watch;patch;update;delete
 //+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;update
 //+kubebuilder:rbac:groups=apps,resources=deployments,verbs=create;delete;get;list;patch;update;watch
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=create;delete;get;list;watch
 // 'bind' on the pre-provisioned search-api and search-collector ClusterRoles lets the
 // operator create their ClusterRoleBindings without holding 'impersonate' or wildcard read.
 // Both ClusterRoles are static manifests; the operator never creates or updates them.
@@ -85,11 +95,12 @@ var cleanOnce sync.Once
 //+kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=get;create
 //+kubebuilder:rbac:groups=certificates.k8s.io,resources=certificatesigningrequests;certificatesigningrequests/approval,verbs=get;list;watch;create;update
 //+kubebuilder:rbac:groups=certificates.k8s.io,resources=signers,verbs=approve
-//+kubebuilder:rbac:groups=addon.open-cluster-management.io,resources=addondeploymentconfigs;clustermanagementaddons;managedclusteraddons,verbs=create;get;list;delete;update
+//+kubebuilder:rbac:groups=addon.open-cluster-management.io,resources=addondeploymentconfigs;clustermanagementaddons;managedclusteraddons,verbs=create;get;list;watch;delete;update;patch
 //+kubebuilder:rbac:groups=addon.open-cluster-management.io,resources=managedclusteraddons/finalizers;clustermanagementaddons/finalizers;managedclusteraddons/finalizers,verbs=update
 //+kubebuilder:rbac:groups=addon.open-cluster-management.io,resources=managedclusteraddons/status;clustermanagementaddons/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=authentication.open-cluster-management.io,resources=managedserviceaccounts,verbs=create;get;delete
 //+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=managedclusters,verbs=get;list;watch
+//+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=placementdecisions,verbs=get;list;watch
 //+kubebuilder:rbac:groups=operator.open-cluster-management.io,resources=multiclusterglobalhubs;multiclusterhubs,verbs=get;list
 //+kubebuilder:rbac:groups=proxy.open-cluster-management.io,resources=clusterstatuses/aggregator,verbs=create
 //+kubebuilder:rbac:groups=rbac.open-cluster-management.io,resources=clusterpermissions,verbs=create;get;delete
@@ -122,7 +133,7 @@ func (r *SearchReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	// Start the addon framework part of search controller.
 	// This is in charge of approving CertificateSigningRequest for managed clusters.
 	once.Do(func() {
-		addon.CreateAddonOnce(ctx, instance)
+		addon.CreateAddonOnce(ctx, r.searchGetter(instance.GetNamespace()))
 	})
 
 	// Update status
@@ -234,6 +245,11 @@ func (r *SearchReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		log.Error(err, "Merged CollectorConfig setup failed")
 		return *result, err
 	}
+	result, err = r.reconcileClusterCollectorConfigs(ctx, instance)
+	if result != nil {
+		log.Error(err, "Per-cluster CollectorConfig setup failed")
+		return *result, err
+	}
 	result, err = r.createSecret(ctx, r.PGSecret(instance))
 	if result != nil {
 		log.Error(err, "Postgres Secret setup failed")
@@ -249,6 +265,11 @@ func (r *SearchReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		log.Error(err, "Postgres MCP readonly Secret setup failed")
 		return *result, err
 	}
+	result, err = r.createSecret(ctx, r.MonitorSecret(instance))
+	if result != nil {
+		log.Error(err, "Postgres monitor Secret setup failed")
+		return *result, err
+	}
 	result, err = r.createService(ctx, r.PGService(instance))
 	if result != nil {
 		log.Error(err, "Postgres Service setup failed")
@@ -302,6 +323,11 @@ func (r *SearchReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		log.Error(err, "ServiceMonitor setup failed for search-collector")
 		return *result, err
 	}
+	result, err = r.reconcilePostgresExporter(ctx, instance)
+	if result != nil {
+		log.Error(err, "Postgres exporter ServiceMonitor setup failed")
+		return *result, err
+	}
 
 	tlsEnvVars := r.getTLSEnvVars(ctx)
 	result, err = r.createOrUpdateDeployment(ctx, r.CollectorDeployment(ctx, instance, tlsEnvVars))
@@ -364,6 +390,12 @@ func (r *SearchReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return *result, err
 	}
 
+	result, err = r.reconcileDashboards(ctx, instance)
+	if result != nil {
+		log.Error(err, "Search dashboards setup failed")
+		return *result, err
+	}
+
 	cleanOnce.Do(func() {
 		// delete legacy servicemonitor setup
 		// Starting with ACM 2.9, ServiceMonitors are created in the open-cluster-management namespace.
@@ -406,9 +438,55 @@ func (r *SearchReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return ctrl.Result{}, err
 	}
 
+	result, err = r.reconcileFieldIndexes(ctx, instance)
+	if result != nil {
+		log.Error(err, "Field index setup failed")
+		return *result, err
+	}
+
+	result, err = r.reconcileCollectorHealth(ctx, instance)
+	if result != nil {
+		log.Error(err, "Collector health status update failed")
+		return *result, err
+	}
+
+	result, err = r.reconcileCollectorImage(ctx, instance)
+	if result != nil {
+		log.Error(err, "Collector image update failed")
+		return *result, err
+	}
+
+	result, err = r.reconcileCollectorRollout(ctx, instance)
+	if result != nil {
+		log.Error(err, "Collector rollout failed")
+		return *result, err
+	}
+
 	return ctrl.Result{}, nil
 }
 
+// CacheOptions returns the manager cache options. When watchNamespace is set, only that namespace
+// is cached, except for the types the operator reads in other namespaces:
+//   - CollectorConfig, for the per-cluster configs in the managed cluster namespaces
+//   - PlacementDecision, for rules whose clusterSelector references a Placement in any namespace
+//   - ManagedClusterAddOn, for the search-collector addons in the managed cluster namespaces
+func CacheOptions(watchNamespace string) cache.Options {
+	if watchNamespace == "" {
+		return cache.Options{}
+	}
+	allNamespaces := func() cache.ByObject {
+		return cache.ByObject{Namespaces: map[string]cache.Config{cache.AllNamespaces: {}}}
+	}
+	return cache.Options{
+		DefaultNamespaces: map[string]cache.Config{watchNamespace: {}},
+		ByObject: map[client.Object]cache.ByObject{
+			&searchv1alpha1.CollectorConfig{}:       allNamespaces(),
+			&clusterv1beta1.PlacementDecision{}:     allNamespaces(),
+			&addonapiv1alpha1.ManagedClusterAddOn{}: allNamespaces(),
+		},
+	}
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *SearchReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	pred := predicate.Funcs{
@@ -430,6 +508,16 @@ func (r *SearchReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			return true
 		},
 	}
+	// Trigger reconcile when the field index Job we own finishes, so the applied indexes are
+	// reported in the Search status.
+	jobPred := predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return true
+		},
+	}
 	// Trigger on create and update for ConfigMaps
 	configMapPred := predicate.Funcs{
 		CreateFunc: func(e event.CreateEvent) bool {
@@ -439,7 +527,22 @@ func (r *SearchReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			return true
 		},
 		DeleteFunc: func(e event.DeleteEvent) bool {
-			return false
+			// A deleted integration ConfigMap removes the CollectorConfigs it defined.
+			return e.Object.GetLabels()[searchv1alpha1.IntegrationConfigMapLabel] == "true"
+		},
+	}
+	// Trigger when a ManagedCluster is added, removed, or relabeled.
+	managedClusterLabelsPred := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return !equality.Semantic.DeepEqual(e.ObjectOld.GetLabels(), e.ObjectNew.GetLabels())
+		},
+	}
+	// Trigger when the clusters selected by a Placement change.
+	placementDecisionPred := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldPD, okOld := e.ObjectOld.(*clusterv1beta1.PlacementDecision)
+			newPD, okNew := e.ObjectNew.(*clusterv1beta1.PlacementDecision)
+			return !okOld || !okNew || !equality.Semantic.DeepEqual(oldPD.Status.Decisions, newPD.Status.Decisions)
 		},
 	}
 	return ctrl.NewControllerManagedBy(mgr).
@@ -450,6 +553,8 @@ func (r *SearchReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			&searchv1alpha1.Search{}, handler.OnlyControllerOwner()), builder.WithPredicates(pred)).
 		Watches(&networkingv1.NetworkPolicy{}, handler.EnqueueRequestForOwner(mgr.GetScheme(), mgr.GetRESTMapper(),
 			&searchv1alpha1.Search{}, handler.OnlyControllerOwner()), builder.WithPredicates(networkPolicyPred)).
+		Watches(&batchv1.Job{}, handler.EnqueueRequestForOwner(mgr.GetScheme(), mgr.GetRESTMapper(),
+			&searchv1alpha1.Search{}, handler.OnlyControllerOwner()), builder.WithPredicates(jobPred)).
 		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(
 			func(ctx context.Context, a client.Object) []reconcile.Request {
 				// Trigger reconcile if SEARCH_GLOBAL_CONFIG configmap
@@ -463,6 +568,17 @@ func (r *SearchReconciler) SetupWithManager(mgr ctrl.Manager) error {
 						},
 					}
 				}
+				// Trigger reconcile for integration CollectorConfig ConfigMaps
+				if a.GetLabels()[searchv1alpha1.IntegrationConfigMapLabel] == "true" {
+					return []reconcile.Request{
+						{
+							NamespacedName: types.NamespacedName{
+								Name:      OperatorName,
+								Namespace: a.GetNamespace(),
+							},
+						},
+					}
+				}
 				// Trigger reconcile for owned ConfigMaps
 				for _, ref := range a.GetOwnerReferences() {
 					if ref.APIVersion == searchv1alpha1.GroupVersion.String() &&
@@ -518,12 +634,31 @@ func (r *SearchReconciler) SetupWithManager(mgr ctrl.Manager) error {
 				return nil
 			}),
 		).
+		// ManagedCluster labels and Placement decisions select the clusters for rules with a
+		// clusterSelector, see reconcileClusterCollectorConfigs.
+		Watches(&clusterv1.ManagedCluster{}, handler.EnqueueRequestsFromMapFunc(enqueueSearchInstance),
+			builder.WithPredicates(managedClusterLabelsPred)).
+		Watches(&clusterv1beta1.PlacementDecision{}, handler.EnqueueRequestsFromMapFunc(enqueueSearchInstance),
+			builder.WithPredicates(placementDecisionPred)).
 		Watches(&searchv1alpha1.CollectorConfig{}, handler.EnqueueRequestsFromMapFunc(
 			func(ctx context.Context, a client.Object) []reconcile.Request {
 				name := a.GetName()
-				// Skip operator-managed output to prevent reconcile loops.
+				// Skip operator-managed output to prevent reconcile loops, unless a collector
+				// acknowledgement left the ObservedByCollectors condition out of date.
 				if name == mergedCollectorConfigName {
-					return nil
+					cc, ok := a.(*searchv1alpha1.CollectorConfig)
+					if !ok || cc.Labels[searchv1alpha1.IntegrationTeamLabel] == searchv1alpha1.ClusterConfigLabelValue ||
+						!observedByCollectorsChanged(cc) {
+						return nil
+					}
+					return []reconcile.Request{
+						{
+							NamespacedName: types.NamespacedName{
+								Name:      OperatorName,
+								Namespace: a.GetNamespace(),
+							},
+						},
+					}
 				}
 				// Trigger on user config (by name) or any integration team config (by label).
 				if name == userCollectorConfigName ||
@@ -540,6 +675,15 @@ func (r *SearchReconciler) SetupWithManager(mgr ctrl.Manager) error {
 				return nil
 			}),
 		).
+		// The Available condition of the search-collector addons is rolled up into the Search status.
+		Watches(&addonapiv1alpha1.ManagedClusterAddOn{}, handler.EnqueueRequestsFromMapFunc(enqueueSearchInstance),
+			builder.WithPredicates(collectorAddonHealthPred)).
+		// spec.collectorRollout sets the ClusterManagementAddOn install strategy and reports its
+		// progress; a change to the collector settings config is rolled out again.
+		Watches(&addonapiv1alpha1.ClusterManagementAddOn{}, handler.EnqueueRequestsFromMapFunc(enqueueSearchInstance),
+			builder.WithPredicates(collectorAddonRolloutPred)).
+		Watches(&addonapiv1alpha1.AddOnDeploymentConfig{}, handler.EnqueueRequestsFromMapFunc(enqueueSearchInstance),
+			builder.WithPredicates(collectorRolloutConfigPred)).
 		Watches(apiServerUnstructured(), handler.EnqueueRequestsFromMapFunc(
 			func(ctx context.Context, a client.Object) []reconcile.Request {
 				// Trigger reconcile when the cluster APIServer TLS profile changes.
@@ -653,6 +797,19 @@ func (r *SearchReconciler) finalizeSearch(instance *searchv1alpha1.Search) error
 	return nil
 }
 
+// enqueueSearchInstance maps an event on a cluster-scoped or other-namespace object to the Search CR
+// in the operator namespace.
+func enqueueSearchInstance(ctx context.Context, a client.Object) []reconcile.Request {
+	return []reconcile.Request{
+		{
+			NamespacedName: types.NamespacedName{
+				Name:      OperatorName,
+				Namespace: os.Getenv("POD_NAMESPACE"),
+			},
+		},
+	}
+}
+
 // isManagedHub checks if a ManagedCluster is a managedHub by inspecting its clusterClaims
 func isManagedHub(mc *clusterv1.ManagedCluster) bool {
 	if mc == nil {
@@ -29,14 +29,16 @@ import (
 	monitorv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
 	"github.com/stolostron/search-v2-operator/controllers"
+	"github.com/stolostron/search-v2-operator/pkg/impact"
+	"github.com/stolostron/search-v2-operator/pkg/schemacheck"
 	admissionv1 "k8s.io/api/admission/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
@@ -55,6 +57,7 @@ func init() {
 	utilruntime.Must(searchv1alpha1.AddToScheme(scheme))
 	utilruntime.Must(monitorv1.AddToScheme(scheme))
 	utilruntime.Must(clusterv1.AddToScheme(scheme))
+	utilruntime.Must(clusterv1beta1.AddToScheme(scheme))
 	utilruntime.Must(admissionv1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
@@ -76,19 +79,13 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
-	watchNs := os.Getenv("WATCH_NAMESPACE")
-	var cacheOpts cache.Options
-	if watchNs != "" {
-		cacheOpts = cache.Options{DefaultNamespaces: map[string]cache.Config{watchNs: {}}}
-	}
-
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
 		Metrics:                server.Options{BindAddress: metricsAddr},
 		WebhookServer:          webhook.NewServer(webhook.Options{Port: 9443}),
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
-		Cache:                  cacheOpts,
+		Cache:                  controllers.CacheOptions(os.Getenv("WATCH_NAMESPACE")),
 		LeaderElectionID:       "b648e39a.open-cluster-management.io",
 	})
 
@@ -130,6 +127,27 @@ func main() {
 		os.Exit(1)
 	}
 
+	estimator, err := impact.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		setupLog.Error(err, "unable to create CollectorConfig impact estimator")
+		os.Exit(1)
+	}
+	if err := mgr.Add(&controllers.CollectorConfigImpactEstimator{
+		Client:    mgr.GetClient(),
+		Estimator: estimator,
+	}); err != nil {
+		setupLog.Error(err, "unable to add CollectorConfig impact estimator")
+		os.Exit(1)
+	}
+
+	if err := mgr.Add(&controllers.CollectorConfigDiscoveryChecker{
+		Client:  mgr.GetClient(),
+		Checker: &schemacheck.Checker{Discovery: estimator.Discovery, Dynamic: estimator.Dynamic},
+	}); err != nil {
+		setupLog.Error(err, "unable to add CollectorConfig discovery checker")
+		os.Exit(1)
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -0,0 +1,349 @@
+// Copyright Contributors to the Open Cluster Management project
+
+// Package fieldtransform computes the value of a CollectorConfig custom field: it reads the
+// field's jsonPath from a resource, runs the field's transforms in order and converts the result
+// to the field's data type. The search collector and preview tooling use it so that a field has
+// the same value wherever it is computed.
+package fieldtransform
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// defaultJoinSeparator is used by join when no separator is set.
+const defaultJoinSeparator = ","
+
+// step is a compiled transform. When it leaves no value it returns why.
+type step func(value interface{}, obj interface{}) (result interface{}, reason string, err error)
+
+// Result is a field value with an explanation of how it was computed.
+type Result struct {
+	// Value is the converted value, nil when the field has no value.
+	Value interface{}
+	// Type is the data type the value was converted to.
+	Type searchv1alpha1.DataType
+	// EmptyReason says why Value is nil.
+	EmptyReason string
+	// Err is set when a transform or the conversion failed.
+	Err error
+}
+
+// Pipeline is a compiled Field. Like the jsonpath parser it holds, it must not be used by
+// several goroutines at once.
+type Pipeline struct {
+	jsonPath string
+	path     *jsonpath.JSONPath
+	steps    []step
+	dataType searchv1alpha1.DataType
+}
+
+// New compiles the jsonPath, transforms and data type of a field.
+func New(f searchv1alpha1.Field) (*Pipeline, error) {
+	path, err := compilePath(f.JSONPath)
+	if err != nil {
+		return nil, fmt.Errorf("field %s: %w", f.Name, err)
+	}
+	p := &Pipeline{jsonPath: f.JSONPath, path: path, dataType: f.Type}
+	for i, t := range f.Transforms {
+		s, err := compileStep(t)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: transforms[%d]: %w", f.Name, i, err)
+		}
+		p.steps = append(p.steps, s)
+	}
+	return p, nil
+}
+
+// Evaluate computes the field for a resource, given as the unstructured content of the object.
+// It returns nil when the field has no value for the resource.
+func (p *Pipeline) Evaluate(obj map[string]interface{}) (interface{}, error) {
+	r := p.Explain(obj)
+	return r.Value, r.Err
+}
+
+// Explain computes the field for a resource like Evaluate and records why there is no value.
+func (p *Pipeline) Explain(obj map[string]interface{}) Result {
+	result := Result{Type: p.dataType}
+	if result.Type == "" {
+		result.Type = searchv1alpha1.DataTypeString
+	}
+	value, err := extract(p.path, obj)
+	if err != nil {
+		result.Err = fmt.Errorf("jsonPath %s: %w", p.jsonPath, err)
+		return result
+	}
+	if value == nil {
+		result.EmptyReason = fmt.Sprintf("jsonPath %s does not resolve on the object", p.jsonPath)
+	}
+	for i, s := range p.steps {
+		next, reason, err := s(value, obj)
+		if err != nil {
+			result.Err = fmt.Errorf("transforms[%d]: %w", i, err)
+			return result
+		}
+		if next == nil && value != nil {
+			result.EmptyReason = fmt.Sprintf("transforms[%d]: %s", i, reason)
+		} else if next != nil {
+			result.EmptyReason = ""
+		}
+		value = next
+	}
+	result.Value, result.Err = Convert(value, p.dataType)
+	return result
+}
+
+// Evaluate compiles the field and computes it for a resource. Use New to evaluate the same
+// field for many resources.
+func Evaluate(f searchv1alpha1.Field, obj map[string]interface{}) (interface{}, error) {
+	p, err := New(f)
+	if err != nil {
+		return nil, err
+	}
+	return p.Evaluate(obj)
+}
+
+// compilePath parses a jsonPath in braced ("{.spec.x}") or unbraced (".spec.x") form.
+func compilePath(path string) (*jsonpath.JSONPath, error) {
+	normalized := "{" + strings.TrimSuffix(strings.TrimPrefix(path, "{"), "}") + "}"
+	jp := jsonpath.New("field").AllowMissingKeys(true)
+	if err := jp.Parse(normalized); err != nil {
+		return nil, fmt.Errorf("invalid jsonPath %q: %w", path, err)
+	}
+	return jp, nil
+}
+
+// extract returns the value at a compiled path: nil when there is none, the value when there is
+// one, and a list when the path selects several.
+func extract(path *jsonpath.JSONPath, obj interface{}) (interface{}, error) {
+	results, err := path.FindResults(obj)
+	if err != nil {
+		return nil, err
+	}
+	var values []interface{}
+	for _, r := range results {
+		for _, v := range r {
+			if v.IsValid() && v.CanInterface() {
+				values = append(values, v.Interface())
+			}
+		}
+	}
+	switch len(values) {
+	case 0:
+		return nil, nil
+	case 1:
+		return values[0], nil
+	default:
+		return values, nil
+	}
+}
+
+// compileStep returns the operation set in a transform.
+func compileStep(t searchv1alpha1.FieldTransform) (step, error) {
+	switch {
+	case t.Regex != nil:
+		return compileRegex(t.Regex)
+	case t.Map != nil:
+		return mapStep(t.Map), nil
+	case t.Join != nil:
+		return joinStep(t.Join), nil
+	case t.Default != nil:
+		return defaultStep(*t.Default), nil
+	case t.When != nil:
+		return compileWhen(t.When)
+	default:
+		return nil, fmt.Errorf("no operation set")
+	}
+}
+
+func compileRegex(t *searchv1alpha1.RegexTransform) (step, error) {
+	re, err := regexp.Compile(t.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex %q: %w", t.Pattern, err)
+	}
+	group := 1
+	if t.Group != nil {
+		group = *t.Group
+	}
+	if group < 0 || group > re.NumSubexp() {
+		return nil, fmt.Errorf("regex %q has no capture group %d", t.Pattern, group)
+	}
+	return func(value interface{}, _ interface{}) (interface{}, string, error) {
+		if value == nil {
+			return nil, "", nil
+		}
+		match := re.FindStringSubmatch(String(value))
+		if match == nil {
+			return nil, fmt.Sprintf("regex %q does not match %q", t.Pattern, String(value)), nil
+		}
+		return match[group], "", nil
+	}, nil
+}
+
+func mapStep(t *searchv1alpha1.MapTransform) step {
+	return func(value interface{}, _ interface{}) (interface{}, string, error) {
+		if value == nil {
+			return nil, "", nil
+		}
+		if mapped, ok := t.Values[String(value)]; ok {
+			return mapped, "", nil
+		}
+		if t.Default != nil {
+			return *t.Default, "", nil
+		}
+		return value, "", nil
+	}
+}
+
+func joinStep(t *searchv1alpha1.JoinTransform) step {
+	sep := defaultJoinSeparator
+	if t.Separator != nil {
+		sep = *t.Separator
+	}
+	return func(value interface{}, _ interface{}) (interface{}, string, error) {
+		items, ok := value.([]interface{})
+		if !ok {
+			return value, "", nil
+		}
+		parts := make([]string, 0, len(items))
+		for _, item := range items {
+			parts = append(parts, String(item))
+		}
+		return strings.Join(parts, sep), "", nil
+	}
+}
+
+func defaultStep(def string) step {
+	return func(value interface{}, _ interface{}) (interface{}, string, error) {
+		if value == nil || value == "" {
+			return def, "", nil
+		}
+		return value, "", nil
+	}
+}
+
+func compileWhen(t *searchv1alpha1.WhenTransform) (step, error) {
+	path, err := compilePath(t.JSONPath)
+	if err != nil {
+		return nil, err
+	}
+	return func(value interface{}, obj interface{}) (interface{}, string, error) {
+		tested, err := extract(path, obj)
+		if err != nil {
+			return nil, "", err
+		}
+		if t.Equals != nil {
+			if tested == nil || String(tested) != *t.Equals {
+				return nil, fmt.Sprintf("when: %s is %q, not %q", t.JSONPath, String(tested), *t.Equals), nil
+			}
+		} else if tested == nil || String(tested) == "" {
+			return nil, fmt.Sprintf("when: %s is empty", t.JSONPath), nil
+		}
+		return value, "", nil
+	}, nil
+}
+
+// String formats a value the way transforms compare and join it: strings as they are, numbers
+// without exponent, and lists and objects as JSON.
+func String(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case int:
+		return strconv.Itoa(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
+
+// Convert converts a value to a field data type. An empty type is a string. Integers and bytes
+// are returned as int64, floats as float64 and booleans as bool; bytes parses Kubernetes
+// quantities, so "1Gi" is 1073741824. nil stays nil.
+func Convert(value interface{}, dataType searchv1alpha1.DataType) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	switch dataType {
+	case "", searchv1alpha1.DataTypeString:
+		return String(value), nil
+	case searchv1alpha1.DataTypeInteger:
+		return toInteger(value)
+	case searchv1alpha1.DataTypeFloat:
+		return toFloat(value)
+	case searchv1alpha1.DataTypeBoolean:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+		b, err := strconv.ParseBool(String(value))
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert %q to boolean", String(value))
+		}
+		return b, nil
+	case searchv1alpha1.DataTypeBytes:
+		if s, ok := value.(string); ok {
+			q, err := resource.ParseQuantity(s)
+			if err != nil {
+				return nil, fmt.Errorf("cannot convert %q to bytes: %w", s, err)
+			}
+			return q.Value(), nil
+		}
+		return toInteger(value)
+	default:
+		return nil, fmt.Errorf("unsupported data type %q", dataType)
+	}
+}
+
+func toInteger(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case float64:
+		if v != math.Trunc(v) {
+			return nil, fmt.Errorf("cannot convert %v to integer", v)
+		}
+		return int64(v), nil
+	}
+	i, err := strconv.ParseInt(String(value), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("cannot convert %q to integer", String(value))
+	}
+	return i, nil
+}
+
+func toFloat(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	}
+	f, err := strconv.ParseFloat(String(value), 64)
+	if err != nil {
+		return nil, fmt.Errorf("cannot convert %q to float", String(value))
+	}
+	return f, nil
+}
@@ -0,0 +1,240 @@
+// Copyright Contributors to the Open Cluster Management project
+package fieldtransform
+
+import (
+	"testing"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func testPod() map[string]interface{} {
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "web-1"},
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "web", "image": "quay.io/acme/web:1.4.2"},
+				map[string]interface{}{"name": "proxy", "image": "quay.io/acme/proxy:0.9"},
+			},
+			"priority": float64(3),
+		},
+		"status": map[string]interface{}{
+			"phase": "Running",
+			"qos":   "",
+		},
+		"resources": map[string]interface{}{"memory": "1Gi"},
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name  string
+		field searchv1alpha1.Field
+		want  interface{}
+	}{
+		{
+			name:  "no transforms",
+			field: searchv1alpha1.Field{Name: "phase", JSONPath: "{.status.phase}"},
+			want:  "Running",
+		},
+		{
+			name: "regex capture of an image tag",
+			field: searchv1alpha1.Field{Name: "tag", JSONPath: ".spec.containers[0].image", Transforms: []searchv1alpha1.FieldTransform{
+				{Regex: &searchv1alpha1.RegexTransform{Pattern: `:([^:]+)$`}},
+			}},
+			want: "1.4.2",
+		},
+		{
+			name: "regex whole match",
+			field: searchv1alpha1.Field{Name: "registry", JSONPath: ".spec.containers[0].image", Transforms: []searchv1alpha1.FieldTransform{
+				{Regex: &searchv1alpha1.RegexTransform{Pattern: `^[^/]+`, Group: ptr(0)}},
+			}},
+			want: "quay.io",
+		},
+		{
+			name: "regex without match then default",
+			field: searchv1alpha1.Field{Name: "digest", JSONPath: ".spec.containers[0].image", Transforms: []searchv1alpha1.FieldTransform{
+				{Regex: &searchv1alpha1.RegexTransform{Pattern: `@(sha256:.+)$`}},
+				{Default: ptr("none")},
+			}},
+			want: "none",
+		},
+		{
+			name: "map enum value",
+			field: searchv1alpha1.Field{Name: "state", JSONPath: ".status.phase", Transforms: []searchv1alpha1.FieldTransform{
+				{Map: &searchv1alpha1.MapTransform{Values: map[string]string{"Running": "up", "Failed": "down"}}},
+			}},
+			want: "up",
+		},
+		{
+			name: "map unmatched uses default",
+			field: searchv1alpha1.Field{Name: "state", JSONPath: ".status.phase", Transforms: []searchv1alpha1.FieldTransform{
+				{Map: &searchv1alpha1.MapTransform{Values: map[string]string{"Failed": "down"}, Default: ptr("other")}},
+			}},
+			want: "other",
+		},
+		{
+			name: "join array",
+			field: searchv1alpha1.Field{Name: "images", JSONPath: ".spec.containers[*].name", Transforms: []searchv1alpha1.FieldTransform{
+				{Join: &searchv1alpha1.JoinTransform{Separator: ptr(";")}},
+			}},
+			want: "web;proxy",
+		},
+		{
+			name:  "bytes type normalizes quantities",
+			field: searchv1alpha1.Field{Name: "memory", JSONPath: ".resources.memory", Type: searchv1alpha1.DataTypeBytes},
+			want:  int64(1 << 30),
+		},
+		{
+			name:  "integer from number",
+			field: searchv1alpha1.Field{Name: "priority", JSONPath: ".spec.priority", Type: searchv1alpha1.DataTypeInteger},
+			want:  int64(3),
+		},
+		{
+			name: "integer from regex capture",
+			field: searchv1alpha1.Field{Name: "major", JSONPath: ".spec.containers[0].image", Type: searchv1alpha1.DataTypeInteger,
+				Transforms: []searchv1alpha1.FieldTransform{{Regex: &searchv1alpha1.RegexTransform{Pattern: `:(\d+)\.`}}}},
+			want: int64(1),
+		},
+		{
+			name: "default replaces empty string",
+			field: searchv1alpha1.Field{Name: "qos", JSONPath: ".status.qos", Transforms: []searchv1alpha1.FieldTransform{
+				{Default: ptr("BestEffort")},
+			}},
+			want: "BestEffort",
+		},
+		{
+			name: "when condition holds",
+			field: searchv1alpha1.Field{Name: "tag", JSONPath: ".spec.containers[0].image", Transforms: []searchv1alpha1.FieldTransform{
+				{When: &searchv1alpha1.WhenTransform{JSONPath: ".status.phase", Equals: ptr("Running")}},
+				{Regex: &searchv1alpha1.RegexTransform{Pattern: `:([^:]+)$`}},
+			}},
+			want: "1.4.2",
+		},
+		{
+			name: "when condition fails",
+			field: searchv1alpha1.Field{Name: "tag", JSONPath: ".spec.containers[0].image", Transforms: []searchv1alpha1.FieldTransform{
+				{When: &searchv1alpha1.WhenTransform{JSONPath: ".status.phase", Equals: ptr("Failed")}},
+			}},
+			want: nil,
+		},
+		{
+			name: "when path must not be empty",
+			field: searchv1alpha1.Field{Name: "tag", JSONPath: ".metadata.name", Transforms: []searchv1alpha1.FieldTransform{
+				{When: &searchv1alpha1.WhenTransform{JSONPath: ".status.qos"}},
+			}},
+			want: nil,
+		},
+		{
+			name:  "missing path",
+			field: searchv1alpha1.Field{Name: "missing", JSONPath: ".spec.nodeName", Type: searchv1alpha1.DataTypeInteger},
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Evaluate(tt.field, testPod())
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	tests := map[string]searchv1alpha1.Field{
+		"invalid jsonPath": {Name: "f", JSONPath: "{.spec[}"},
+		"invalid regex": {Name: "f", JSONPath: ".spec", Transforms: []searchv1alpha1.FieldTransform{
+			{Regex: &searchv1alpha1.RegexTransform{Pattern: "("}},
+		}},
+		"missing group": {Name: "f", JSONPath: ".spec", Transforms: []searchv1alpha1.FieldTransform{
+			{Regex: &searchv1alpha1.RegexTransform{Pattern: "a(b)", Group: ptr(2)}},
+		}},
+		"no operation": {Name: "f", JSONPath: ".spec", Transforms: []searchv1alpha1.FieldTransform{{}}},
+	}
+	for name, f := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(f)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		dataType searchv1alpha1.DataType
+		want     interface{}
+		wantErr  bool
+	}{
+		{"500m", searchv1alpha1.DataTypeBytes, int64(1), false},
+		{"2Ki", searchv1alpha1.DataTypeBytes, int64(2048), false},
+		{"lots", searchv1alpha1.DataTypeBytes, nil, true},
+		{"1.5", searchv1alpha1.DataTypeFloat, 1.5, false},
+		{float64(2.5), searchv1alpha1.DataTypeInteger, nil, true},
+		{"true", searchv1alpha1.DataTypeBoolean, true, false},
+		{"yes", searchv1alpha1.DataTypeBoolean, nil, true},
+		{float64(1e21), searchv1alpha1.DataTypeString, "1000000000000000000000", false},
+		{[]interface{}{"a", "b"}, "", `["a","b"]`, false},
+	}
+	for _, tt := range tests {
+		got, err := Convert(tt.value, tt.dataType)
+		if tt.wantErr {
+			assert.Error(t, err, "%v to %s", tt.value, tt.dataType)
+			continue
+		}
+		require.NoError(t, err, "%v to %s", tt.value, tt.dataType)
+		assert.Equal(t, tt.want, got, "%v to %s", tt.value, tt.dataType)
+	}
+}
+
+func TestExplain_EmptyReasons(t *testing.T) {
+	tests := []struct {
+		name   string
+		field  searchv1alpha1.Field
+		reason string
+	}{
+		{
+			name:   "path does not resolve",
+			field:  searchv1alpha1.Field{Name: "node", JSONPath: ".spec.nodeName"},
+			reason: "jsonPath .spec.nodeName does not resolve on the object",
+		},
+		{
+			name: "regex does not match",
+			field: searchv1alpha1.Field{Name: "digest", JSONPath: ".spec.containers[0].image", Transforms: []searchv1alpha1.FieldTransform{
+				{Regex: &searchv1alpha1.RegexTransform{Pattern: `@(.+)$`}},
+			}},
+			reason: `transforms[0]: regex "@(.+)$" does not match "quay.io/acme/web:1.4.2"`,
+		},
+		{
+			name: "when condition fails",
+			field: searchv1alpha1.Field{Name: "tag", JSONPath: ".metadata.name", Transforms: []searchv1alpha1.FieldTransform{
+				{Map: &searchv1alpha1.MapTransform{Values: map[string]string{"x": "y"}}},
+				{When: &searchv1alpha1.WhenTransform{JSONPath: ".status.phase", Equals: ptr("Failed")}},
+			}},
+			reason: `transforms[1]: when: .status.phase is "Running", not "Failed"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(tt.field)
+			require.NoError(t, err)
+			r := p.Explain(testPod())
+			assert.NoError(t, r.Err)
+			assert.Nil(t, r.Value)
+			assert.Equal(t, tt.reason, r.EmptyReason)
+			assert.Equal(t, searchv1alpha1.DataTypeString, r.Type)
+		})
+	}
+
+	// A default after an empty step clears the reason.
+	p, err := New(searchv1alpha1.Field{Name: "node", JSONPath: ".spec.nodeName", Transforms: []searchv1alpha1.FieldTransform{
+		{Default: ptr("unscheduled")},
+	}})
+	require.NoError(t, err)
+	r := p.Explain(testPod())
+	assert.Equal(t, "unscheduled", r.Value)
+	assert.Empty(t, r.EmptyReason)
+}
@@ -0,0 +1,224 @@
+// Copyright Contributors to the Open Cluster Management project
+
+// Package impact estimates how many objects, and roughly how many bytes, a collection rule adds
+// to search by counting the matching objects on the cluster with discovery and paged list calls.
+// It does not depend on the API types so that the CollectorConfig webhook can use it.
+package impact
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+// defaultPageSize is the list page size when Estimator.PageSize is not set.
+const defaultPageSize = 500
+
+// Selector selects resources like a CollectionRule's resourceSelector.
+type Selector struct {
+	APIGroups          []string
+	Kinds              []string
+	LabelSelector      *metav1.LabelSelector
+	AnnotationSelector *metav1.LabelSelector
+}
+
+// Resource is a listable resource type found by discovery.
+type Resource struct {
+	GVR  schema.GroupVersionResource
+	Kind string
+}
+
+// Estimate is the number and approximate size of the objects a selector matches.
+type Estimate struct {
+	// Objects is the number of matching objects.
+	Objects int64
+	// Bytes is the JSON size of the matching objects, extrapolated from the first page of each
+	// resource. Search stores less than the whole object, so this is an upper bound.
+	Bytes int64
+	// Resources is the number of resource types the selector matched.
+	Resources int
+	// Partial is set when MaxPages stopped the count before all objects were seen, or when some
+	// resource types could not be listed.
+	Partial bool
+}
+
+// Add sums two estimates.
+func (e Estimate) Add(o Estimate) Estimate {
+	return Estimate{
+		Objects:   e.Objects + o.Objects,
+		Bytes:     e.Bytes + o.Bytes,
+		Resources: e.Resources + o.Resources,
+		Partial:   e.Partial || o.Partial,
+	}
+}
+
+// Estimator counts the objects matched by selectors.
+type Estimator struct {
+	Discovery discovery.DiscoveryInterface
+	Dynamic   dynamic.Interface
+
+	// PageSize is the list page size. Defaults to 500.
+	PageSize int64
+	// MaxPages limits the pages listed per resource type; 0 lists all. Counts use the
+	// remainingItemCount of the first page when the API server returns it, so one page is
+	// usually enough unless an annotation selector has to be applied to every object.
+	MaxPages int
+
+	// cache holds the estimates of one resource type and selector, see WithCache.
+	cache map[string]Estimate
+}
+
+// NewForConfig returns an estimator that lists all pages.
+func NewForConfig(cfg *rest.Config) (*Estimator, error) {
+	dc, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	dyn, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Estimator{Discovery: dc, Dynamic: dyn}, nil
+}
+
+// WithCache returns a copy of the estimator that remembers the count of each resource type and
+// selector, so the same rule in several CollectorConfigs is only counted once.
+func (e *Estimator) WithCache() *Estimator {
+	c := *e
+	c.cache = map[string]Estimate{}
+	return &c
+}
+
+// Resources returns the listable resource types in their preferred version.
+func (e *Estimator) Resources() ([]Resource, error) {
+	lists, err := discovery.ServerPreferredResources(e.Discovery)
+	if err != nil && len(lists) == 0 {
+		// Discovery returns the groups it could read along with an error for the others.
+		return nil, err
+	}
+	var resources []Resource
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, r := range list.APIResources {
+			if strings.Contains(r.Name, "/") || !slices.Contains(r.Verbs, "list") {
+				continue
+			}
+			resources = append(resources, Resource{GVR: gv.WithResource(r.Name), Kind: r.Kind})
+		}
+	}
+	return resources, nil
+}
+
+// Matches reports whether the selector's apiGroups and kinds select the resource type.
+func (s Selector) Matches(r Resource) bool {
+	return (slices.Contains(s.APIGroups, "*") || slices.Contains(s.APIGroups, r.GVR.Group)) &&
+		(slices.Contains(s.Kinds, "*") || slices.Contains(s.Kinds, r.Kind))
+}
+
+// Estimate counts the objects of the given resource types that the selector matches. When some
+// resource types cannot be listed, it returns the estimate of the others, marked Partial, along
+// with the errors.
+func (e *Estimator) Estimate(ctx context.Context, resources []Resource, sel Selector) (Estimate, error) {
+	var labelSelector string
+	if sel.LabelSelector != nil {
+		s, err := metav1.LabelSelectorAsSelector(sel.LabelSelector)
+		if err != nil {
+			return Estimate{}, err
+		}
+		labelSelector = s.String()
+	}
+	var annotationSelector labels.Selector
+	if sel.AnnotationSelector != nil {
+		s, err := metav1.LabelSelectorAsSelector(sel.AnnotationSelector)
+		if err != nil {
+			return Estimate{}, err
+		}
+		annotationSelector = s
+	}
+
+	total := Estimate{}
+	var errs []error
+	for _, r := range resources {
+		if !sel.Matches(r) {
+			continue
+		}
+		key := r.GVR.String() + "|" + labelSelector
+		if annotationSelector != nil {
+			key += "|" + annotationSelector.String()
+		}
+		est, ok := e.cache[key]
+		if !ok {
+			var err error
+			if est, err = e.count(ctx, r, labelSelector, annotationSelector); err != nil {
+				// Count the other resource types, so one unavailable API doesn't hide the rest.
+				errs = append(errs, fmt.Errorf("could not list %s: %w", r.GVR.String(), err))
+				total.Partial = true
+				continue
+			}
+			if e.cache != nil {
+				e.cache[key] = est
+			}
+		}
+		total = total.Add(est)
+	}
+	return total, errors.Join(errs...)
+}
+
+// count lists one resource type page by page.
+func (e *Estimator) count(ctx context.Context, r Resource, labelSelector string,
+	annotationSelector labels.Selector) (Estimate, error) {
+	pageSize := e.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	est := Estimate{Resources: 1}
+	var sampled, sampledBytes int64
+	opts := metav1.ListOptions{Limit: pageSize, LabelSelector: labelSelector}
+	for page := 1; ; page++ {
+		list, err := e.Dynamic.Resource(r.GVR).List(ctx, opts)
+		if err != nil {
+			return est, err
+		}
+		for i := range list.Items {
+			if annotationSelector != nil && !annotationSelector.Matches(labels.Set(list.Items[i].GetAnnotations())) {
+				continue
+			}
+			est.Objects++
+			if page == 1 {
+				if data, err := json.Marshal(list.Items[i].Object); err == nil {
+					sampled++
+					sampledBytes += int64(len(data))
+				}
+			}
+		}
+		if page == 1 && annotationSelector == nil && list.GetRemainingItemCount() != nil {
+			est.Objects += *list.GetRemainingItemCount()
+			break
+		}
+		if list.GetContinue() == "" {
+			break
+		}
+		if e.MaxPages > 0 && page >= e.MaxPages {
+			est.Partial = true
+			break
+		}
+		opts.Continue = list.GetContinue()
+	}
+	if sampled > 0 {
+		est.Bytes = sampledBytes / sampled * est.Objects
+	}
+	return est, nil
+}
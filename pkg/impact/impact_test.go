@@ -0,0 +1,136 @@
+// Copyright Contributors to the Open Cluster Management project
+package impact
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+var (
+	configMaps  = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	deployments = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+)
+
+func object(apiVersion, kind, name string, labels, annotations map[string]string) runtime.Object {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetNamespace("default")
+	u.SetName(name)
+	u.SetLabels(labels)
+	u.SetAnnotations(annotations)
+	return u
+}
+
+func testEstimator(objs ...runtime.Object) *Estimator {
+	dyn := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		configMaps:  "ConfigMapList",
+		deployments: "DeploymentList",
+	}, objs...)
+	disc := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{
+			{Name: "configmaps", Kind: "ConfigMap", Namespaced: true, Verbs: []string{"get", "list", "watch"}},
+			{Name: "pods/log", Kind: "Pod", Namespaced: true, Verbs: []string{"get"}},
+			{Name: "bindings", Kind: "Binding", Namespaced: true, Verbs: []string{"create"}},
+		}},
+		{GroupVersion: "apps/v1", APIResources: []metav1.APIResource{
+			{Name: "deployments", Kind: "Deployment", Namespaced: true, Verbs: []string{"get", "list", "watch"}},
+		}},
+	}}}
+	return &Estimator{Discovery: disc, Dynamic: dyn}
+}
+
+func TestResources(t *testing.T) {
+	resources, err := testEstimator().Resources()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []Resource{{GVR: configMaps, Kind: "ConfigMap"}, {GVR: deployments, Kind: "Deployment"}},
+		resources, "subresources and resources that cannot be listed are left out")
+}
+
+func TestEstimate(t *testing.T) {
+	objs := []runtime.Object{
+		object("apps/v1", "Deployment", "web", map[string]string{"app": "web"}, nil),
+		object("apps/v1", "Deployment", "db", map[string]string{"app": "db"}, map[string]string{"tier": "data"}),
+	}
+	for i := 0; i < 5; i++ {
+		objs = append(objs, object("v1", "ConfigMap", fmt.Sprintf("cm-%d", i), nil, nil))
+	}
+	e := testEstimator(objs...)
+	resources, err := e.Resources()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		sel       Selector
+		objects   int64
+		resources int
+	}{
+		{"all kinds", Selector{APIGroups: []string{"*"}, Kinds: []string{"*"}}, 7, 2},
+		{"one kind", Selector{APIGroups: []string{""}, Kinds: []string{"ConfigMap"}}, 5, 1},
+		{"wrong group", Selector{APIGroups: []string{"apps"}, Kinds: []string{"ConfigMap"}}, 0, 0},
+		{"label selector", Selector{APIGroups: []string{"apps"}, Kinds: []string{"*"},
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}}, 1, 1},
+		{"annotation selector", Selector{APIGroups: []string{"apps"}, Kinds: []string{"*"},
+			AnnotationSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "data"}}}, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			est, err := e.Estimate(context.TODO(), resources, tt.sel)
+			require.NoError(t, err)
+			assert.Equal(t, tt.objects, est.Objects)
+			assert.Equal(t, tt.resources, est.Resources)
+			assert.False(t, est.Partial)
+			if tt.objects > 0 {
+				assert.Positive(t, est.Bytes)
+			} else {
+				assert.Zero(t, est.Bytes)
+			}
+		})
+	}
+}
+
+func TestEstimate_ListErrorsArePartial(t *testing.T) {
+	e := testEstimator(object("v1", "ConfigMap", "cm", nil, nil))
+	e.Dynamic.(*fakedynamic.FakeDynamicClient).PrependReactor("list", "deployments",
+		func(clienttesting.Action) (bool, runtime.Object, error) {
+			return true, nil, fmt.Errorf("service unavailable")
+		})
+	resources, err := e.Resources()
+	require.NoError(t, err)
+
+	est, err := e.Estimate(context.TODO(), resources, Selector{APIGroups: []string{"*"}, Kinds: []string{"*"}})
+	assert.ErrorContains(t, err, "could not list apps/v1, Resource=deployments")
+	assert.Equal(t, int64(1), est.Objects, "the resources that could be listed are counted")
+	assert.True(t, est.Partial)
+}
+
+func TestEstimate_Cache(t *testing.T) {
+	e := testEstimator(object("v1", "ConfigMap", "cm", nil, nil)).WithCache()
+	resources, err := e.Resources()
+	require.NoError(t, err)
+	sel := Selector{APIGroups: []string{""}, Kinds: []string{"ConfigMap"}}
+
+	_, err = e.Estimate(context.TODO(), resources, sel)
+	require.NoError(t, err)
+	_, err = e.Estimate(context.TODO(), resources, sel)
+	require.NoError(t, err)
+
+	lists := 0
+	for _, a := range e.Dynamic.(*fakedynamic.FakeDynamicClient).Actions() {
+		if a.GetVerb() == "list" {
+			lists++
+		}
+	}
+	assert.Equal(t, 1, lists)
+}
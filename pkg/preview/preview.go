@@ -0,0 +1,216 @@
+// Copyright Contributors to the Open Cluster Management project
+
+// Package preview shows what a CollectorConfig does with an object before it is applied: which
+// collection rules match, the resulting action, and the custom field values the collector would
+// index, with the reason a field has no value.
+package preview
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"github.com/stolostron/search-v2-operator/pkg/fieldtransform"
+	"github.com/stolostron/search-v2-operator/pkg/redact"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// ActionNone is the Result action when no rule matched, so the collector's built-in collection
+// applies.
+const ActionNone = "none"
+
+// Result is the outcome of a CollectorConfig for one object.
+type Result struct {
+	// Object identifies the previewed object as apiVersion/kind namespace/name.
+	Object string `json:"object"`
+	// Action is include or exclude when a rule matched, otherwise none. Exclude rules win.
+	Action string `json:"action"`
+	// Rules has one entry per collection rule, in order.
+	Rules []RuleResult `json:"rules"`
+	// Fields are the custom fields of the matched include rules, when the object is included.
+	Fields []FieldResult `json:"fields,omitempty"`
+}
+
+// RuleResult reports whether one collection rule matched the object.
+type RuleResult struct {
+	Index int `json:"index"`
+	// Source is the CollectorConfig the rule was merged from, for merged-collector-config.
+	Source  string                    `json:"source,omitempty"`
+	Action  searchv1alpha1.ActionType `json:"action"`
+	Matched bool                      `json:"matched"`
+	// Reason says why the rule did not match.
+	Reason string `json:"reason,omitempty"`
+	// Note points out what the preview cannot check, such as a clusterSelector.
+	Note string `json:"note,omitempty"`
+}
+
+// FieldResult is the value of one custom field for the object.
+type FieldResult struct {
+	// Rule is the index of the rule that defines the field.
+	Rule int `json:"rule"`
+	// Name includes the rule's fieldSuffix.
+	Name     string                  `json:"name"`
+	JSONPath string                  `json:"jsonPath"`
+	Type     searchv1alpha1.DataType `json:"type"`
+	Value    interface{}             `json:"value"`
+	// EmptyReason says why Value is null.
+	EmptyReason string `json:"emptyReason,omitempty"`
+	// Redacted names the redaction rule that masked or dropped the value.
+	Redacted string `json:"redacted,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Preview evaluates the collection rules of a CollectorConfig for an object. It uses the same
+// field evaluation as the collector (pkg/fieldtransform). Rules with a clusterSelector are
+// evaluated as if the object is on a selected cluster.
+func Preview(cc *searchv1alpha1.CollectorConfig, obj *unstructured.Unstructured) Result {
+	gvk := obj.GroupVersionKind()
+	result := Result{
+		Object: fmt.Sprintf("%s/%s %s", gvk.GroupVersion().String(), gvk.Kind, objectName(obj)),
+		Action: ActionNone,
+		Rules:  []RuleResult{},
+	}
+
+	var included []int
+	for i, rule := range cc.Spec.CollectionRules {
+		rr := RuleResult{Index: i, Action: rule.Action}
+		if len(cc.Status.RuleSources) == len(cc.Spec.CollectionRules) {
+			rr.Source = cc.Status.RuleSources[i].Source
+		}
+		if rule.ClusterSelector != nil {
+			rr.Note = "applies only to the clusters selected by clusterSelector"
+		}
+		rr.Reason = mismatch(rule.ResourceSelector, obj)
+		rr.Matched = rr.Reason == ""
+		result.Rules = append(result.Rules, rr)
+		if !rr.Matched {
+			continue
+		}
+		switch rule.Action {
+		case searchv1alpha1.ActionExclude:
+			result.Action = string(searchv1alpha1.ActionExclude)
+		case searchv1alpha1.ActionInclude:
+			included = append(included, i)
+			if result.Action == ActionNone {
+				result.Action = string(searchv1alpha1.ActionInclude)
+			}
+		}
+	}
+
+	if result.Action != string(searchv1alpha1.ActionInclude) {
+		return result
+	}
+	redactor, redactErr := redact.New(cc.Spec.Redact)
+	for _, i := range included {
+		rule := cc.Spec.CollectionRules[i]
+		for _, f := range rule.Fields {
+			fr := evaluateField(i, rule, f, obj)
+			if redactErr != nil {
+				fr.Value, fr.Error = nil, redactErr.Error()
+			} else if fr.Value != nil {
+				redactField(redactor, f.Name, &fr)
+			}
+			result.Fields = append(result.Fields, fr)
+		}
+	}
+	return result
+}
+
+// redactField applies the redaction rules to a field value, like the collector does before
+// indexing it.
+func redactField(redactor *redact.Redactor, name string, fr *FieldResult) {
+	value, keep, rule := redactor.Field(name, fr.Value)
+	if rule == "" {
+		return
+	}
+	fr.Redacted = rule
+	fr.Value = value
+	if !keep {
+		fr.EmptyReason = "dropped by redaction rule " + rule
+	}
+}
+
+// mismatch returns why a resource selector does not match the object, or "" when it matches.
+func mismatch(sel searchv1alpha1.ResourceSelector, obj *unstructured.Unstructured) string {
+	gvk := obj.GroupVersionKind()
+	if !slices.Contains(sel.APIGroups, "*") && !slices.Contains(sel.APIGroups, gvk.Group) {
+		return fmt.Sprintf("apiGroup %q is not in %q", gvk.Group, sel.APIGroups)
+	}
+	if !slices.Contains(sel.Kinds, "*") && !slices.Contains(sel.Kinds, gvk.Kind) {
+		return fmt.Sprintf("kind %q is not in %q", gvk.Kind, sel.Kinds)
+	}
+	if len(sel.Versions) > 0 && !slices.Contains(sel.Versions, gvk.Version) {
+		return fmt.Sprintf("version %q is not in %q", gvk.Version, sel.Versions)
+	}
+	if sel.LabelSelector != nil {
+		if reason := selectorMismatch("labels", sel.LabelSelector, obj.GetLabels()); reason != "" {
+			return reason
+		}
+	}
+	if sel.AnnotationSelector != nil {
+		if reason := selectorMismatch("annotations", sel.AnnotationSelector, obj.GetAnnotations()); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+func selectorMismatch(what string, ls *metav1.LabelSelector, values map[string]string) string {
+	selector, err := metav1.LabelSelectorAsSelector(ls)
+	if err != nil {
+		return fmt.Sprintf("invalid %s selector: %v", what, err)
+	}
+	if !selector.Matches(labels.Set(values)) {
+		return fmt.Sprintf("%s do not match %s", what, selector.String())
+	}
+	return ""
+}
+
+func evaluateField(index int, rule searchv1alpha1.CollectionRule, f searchv1alpha1.Field,
+	obj *unstructured.Unstructured) FieldResult {
+	fr := FieldResult{Rule: index, Name: f.Name, JSONPath: f.JSONPath, Type: f.Type}
+	if rule.FieldSuffix != "" {
+		fr.Name = f.Name + "." + rule.FieldSuffix
+	}
+	if fr.Type == "" {
+		fr.Type = searchv1alpha1.DataTypeString
+	}
+	p, err := fieldtransform.New(f)
+	if err != nil {
+		fr.Error = err.Error()
+		return fr
+	}
+	r := p.Explain(obj.Object)
+	fr.Value = r.Value
+	fr.EmptyReason = r.EmptyReason
+	if r.Err != nil {
+		fr.Error = r.Err.Error()
+	}
+	return fr
+}
+
+func objectName(obj *unstructured.Unstructured) string {
+	if obj.GetNamespace() == "" {
+		return obj.GetName()
+	}
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
+
+// Fetch reads a live object to preview, resolving its kind with the REST mapper.
+func Fetch(ctx context.Context, client dynamic.Interface, mapper meta.RESTMapper, gvk schema.GroupVersionKind,
+	namespace, name string) (*unstructured.Unstructured, error) {
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, fmt.Errorf("could not find the resource for %s: %w", gvk.String(), err)
+	}
+	if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+		return client.Resource(mapping.Resource).Get(ctx, name, metav1.GetOptions{})
+	}
+	return client.Resource(mapping.Resource).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+}
@@ -0,0 +1,165 @@
+// Copyright Contributors to the Open Cluster Management project
+package preview
+
+import (
+	"context"
+	"testing"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+)
+
+func testDeployment() *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":      "web",
+			"namespace": "default",
+			"labels":    map[string]interface{}{"owner": "tekton"},
+		},
+		"spec": map[string]interface{}{
+			"replicas": float64(3),
+			"template": map[string]interface{}{"spec": map[string]interface{}{
+				"containers": []interface{}{map[string]interface{}{"image": "quay.io/acme/web:1.4.2"}},
+			}},
+		},
+	}}
+}
+
+func deploymentRule(action searchv1alpha1.ActionType, fields ...searchv1alpha1.Field) searchv1alpha1.CollectionRule {
+	return searchv1alpha1.CollectionRule{
+		Action:           action,
+		ResourceSelector: searchv1alpha1.ResourceSelector{APIGroups: []string{"apps"}, Kinds: []string{"Deployment"}},
+		Fields:           fields,
+	}
+}
+
+func TestPreview_IncludeWithFields(t *testing.T) {
+	cc := &searchv1alpha1.CollectorConfig{Spec: searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			{
+				Action:           searchv1alpha1.ActionInclude,
+				ResourceSelector: searchv1alpha1.ResourceSelector{APIGroups: []string{""}, Kinds: []string{"ConfigMap"}},
+			},
+			func() searchv1alpha1.CollectionRule {
+				r := deploymentRule(searchv1alpha1.ActionInclude,
+					searchv1alpha1.Field{Name: "replicas", JSONPath: "{.spec.replicas}", Type: searchv1alpha1.DataTypeInteger},
+					searchv1alpha1.Field{Name: "tag", JSONPath: ".spec.template.spec.containers[0].image",
+						Transforms: []searchv1alpha1.FieldTransform{{Regex: &searchv1alpha1.RegexTransform{Pattern: `:(.+)$`}}}},
+					searchv1alpha1.Field{Name: "paused", JSONPath: ".spec.paused", Type: searchv1alpha1.DataTypeBoolean},
+				)
+				r.FieldSuffix = "app"
+				return r
+			}(),
+		},
+	}}
+	cc.Status.RuleSources = []searchv1alpha1.RuleSource{{Source: "a", Index: 0}, {Source: "b", Index: 3}}
+
+	result := Preview(cc, testDeployment())
+
+	assert.Equal(t, "apps/v1/Deployment default/web", result.Object)
+	assert.Equal(t, "include", result.Action)
+	require.Len(t, result.Rules, 2)
+	assert.False(t, result.Rules[0].Matched)
+	assert.Equal(t, `apiGroup "apps" is not in [""]`, result.Rules[0].Reason)
+	assert.True(t, result.Rules[1].Matched)
+	assert.Equal(t, "b", result.Rules[1].Source)
+
+	require.Len(t, result.Fields, 3)
+	assert.Equal(t, FieldResult{Rule: 1, Name: "replicas.app", JSONPath: "{.spec.replicas}",
+		Type: searchv1alpha1.DataTypeInteger, Value: int64(3)}, result.Fields[0])
+	assert.Equal(t, "1.4.2", result.Fields[1].Value)
+	assert.Equal(t, searchv1alpha1.DataTypeString, result.Fields[1].Type)
+	assert.Nil(t, result.Fields[2].Value)
+	assert.Equal(t, "jsonPath .spec.paused does not resolve on the object", result.Fields[2].EmptyReason)
+}
+
+func TestPreview_ExcludeWins(t *testing.T) {
+	exclude := deploymentRule(searchv1alpha1.ActionExclude)
+	exclude.ResourceSelector.LabelSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"owner": "tekton"}}
+	cc := &searchv1alpha1.CollectorConfig{Spec: searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			deploymentRule(searchv1alpha1.ActionInclude, searchv1alpha1.Field{Name: "replicas", JSONPath: ".spec.replicas"}),
+			exclude,
+		},
+	}}
+
+	result := Preview(cc, testDeployment())
+	assert.Equal(t, "exclude", result.Action)
+	assert.Empty(t, result.Fields, "excluded objects have no fields")
+
+	obj := testDeployment()
+	obj.SetLabels(map[string]string{"owner": "argo"})
+	result = Preview(cc, obj)
+	assert.Equal(t, "include", result.Action)
+	assert.Equal(t, "labels do not match owner=tekton", result.Rules[1].Reason)
+}
+
+func TestPreview_NoMatchAndClusterSelectorNote(t *testing.T) {
+	rule := deploymentRule(searchv1alpha1.ActionInclude)
+	rule.ResourceSelector.Kinds = []string{"StatefulSet"}
+	rule.ClusterSelector = &searchv1alpha1.ClusterSelector{PlacementRef: &searchv1alpha1.PlacementRef{Name: "edge"}}
+	cc := &searchv1alpha1.CollectorConfig{Spec: searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{rule},
+	}}
+
+	result := Preview(cc, testDeployment())
+	assert.Equal(t, ActionNone, result.Action)
+	assert.Equal(t, `kind "Deployment" is not in ["StatefulSet"]`, result.Rules[0].Reason)
+	assert.NotEmpty(t, result.Rules[0].Note)
+}
+
+func TestPreview_PinnedVersion(t *testing.T) {
+	rule := deploymentRule(searchv1alpha1.ActionInclude)
+	rule.ResourceSelector.Versions = []string{"v1beta1"}
+	cc := &searchv1alpha1.CollectorConfig{Spec: searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{rule},
+	}}
+
+	result := Preview(cc, testDeployment())
+	assert.Equal(t, ActionNone, result.Action)
+	assert.Equal(t, `version "v1" is not in ["v1beta1"]`, result.Rules[0].Reason)
+}
+
+func TestPreview_RedactsFields(t *testing.T) {
+	cc := &searchv1alpha1.CollectorConfig{Spec: searchv1alpha1.CollectorConfigSpec{
+		CollectionRules: []searchv1alpha1.CollectionRule{
+			deploymentRule(searchv1alpha1.ActionInclude,
+				searchv1alpha1.Field{Name: "image", JSONPath: ".spec.template.spec.containers[0].image"},
+				searchv1alpha1.Field{Name: "replicas", JSONPath: ".spec.replicas", Type: searchv1alpha1.DataTypeInteger}),
+		},
+		Redact: []searchv1alpha1.RedactionRule{
+			{Name: "images", Target: searchv1alpha1.RedactionTargetFields, ValuePattern: `^quay\.io/`},
+			{Name: "replicas", Target: searchv1alpha1.RedactionTargetFields, Keys: []string{"replicas"}},
+		},
+	}}
+
+	result := Preview(cc, testDeployment())
+	require.Len(t, result.Fields, 2)
+	assert.Equal(t, searchv1alpha1.RedactedValue, result.Fields[0].Value)
+	assert.Equal(t, "images", result.Fields[0].Redacted)
+	assert.Nil(t, result.Fields[1].Value, "masked values that are not strings are dropped")
+	assert.Equal(t, "dropped by redaction rule replicas", result.Fields[1].EmptyReason)
+}
+
+func TestFetch(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(gvk, meta.RESTScopeNamespace)
+	client := fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(), testDeployment())
+
+	obj, err := Fetch(context.TODO(), client, mapper, gvk, "default", "web")
+	require.NoError(t, err)
+	assert.Equal(t, "web", obj.GetName())
+
+	_, err = Fetch(context.TODO(), client, mapper, schema.GroupVersionKind{Group: "x", Version: "v1", Kind: "Y"}, "", "y")
+	assert.Error(t, err)
+}
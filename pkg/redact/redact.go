@@ -0,0 +1,137 @@
+// Copyright Contributors to the Open Cluster Management project
+
+// Package redact applies the redaction rules of a CollectorConfig: it masks or drops the
+// annotations, labels and custom field values that match a rule before they are indexed. The
+// search collector and preview tooling use it so that a value is redacted wherever it is shown.
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+
+	searchv1alpha1 "github.com/stolostron/search-v2-operator/api/v1alpha1"
+)
+
+// rule is a compiled RedactionRule.
+type rule struct {
+	name         string
+	target       searchv1alpha1.RedactionTarget
+	keys         []string
+	keyPattern   *regexp.Regexp
+	valuePattern *regexp.Regexp
+	drop         bool
+}
+
+// Redactor is a compiled list of redaction rules. It is safe for concurrent use.
+type Redactor struct {
+	rules []rule
+}
+
+// New compiles redaction rules.
+func New(rules []searchv1alpha1.RedactionRule) (*Redactor, error) {
+	r := &Redactor{}
+	for _, rr := range rules {
+		c := rule{
+			name:   rr.Name,
+			target: rr.Target,
+			keys:   rr.Keys,
+			drop:   rr.Action == searchv1alpha1.RedactionActionDrop,
+		}
+		var err error
+		if rr.KeyPattern != "" {
+			if c.keyPattern, err = regexp.Compile(rr.KeyPattern); err != nil {
+				return nil, fmt.Errorf("redaction rule %s: keyPattern: %w", rr.Name, err)
+			}
+		}
+		if rr.ValuePattern != "" {
+			if c.valuePattern, err = regexp.Compile(rr.ValuePattern); err != nil {
+				return nil, fmt.Errorf("redaction rule %s: valuePattern: %w", rr.Name, err)
+			}
+		}
+		r.rules = append(r.rules, c)
+	}
+	return r, nil
+}
+
+// match returns the first rule for the target that matches the key and value. Only string values
+// can match a valuePattern.
+func (r *Redactor) match(target searchv1alpha1.RedactionTarget, key string, value interface{}) *rule {
+	for i := range r.rules {
+		c := &r.rules[i]
+		if c.target != target {
+			continue
+		}
+		if len(c.keys) > 0 && !slices.Contains(c.keys, key) {
+			continue
+		}
+		if c.keyPattern != nil && !c.keyPattern.MatchString(key) {
+			continue
+		}
+		if c.valuePattern != nil {
+			s, ok := value.(string)
+			if !ok || !c.valuePattern.MatchString(s) {
+				continue
+			}
+		}
+		return c
+	}
+	return nil
+}
+
+// Annotations returns the annotations with the redaction rules applied, and the names of the
+// rules that redacted a value. The input map is not modified.
+func (r *Redactor) Annotations(annotations map[string]string) (map[string]string, []string) {
+	return r.strings(searchv1alpha1.RedactionTargetAnnotations, annotations)
+}
+
+// Labels returns the labels with the redaction rules applied, and the names of the rules that
+// redacted a value. The input map is not modified.
+func (r *Redactor) Labels(labels map[string]string) (map[string]string, []string) {
+	return r.strings(searchv1alpha1.RedactionTargetLabels, labels)
+}
+
+func (r *Redactor) strings(target searchv1alpha1.RedactionTarget, values map[string]string) (
+	map[string]string, []string) {
+	if values == nil {
+		return nil, nil
+	}
+	result := make(map[string]string, len(values))
+	var applied []string
+	for k, v := range values {
+		c := r.match(target, k, v)
+		switch {
+		case c == nil:
+			result[k] = v
+		case c.drop:
+			applied = appendUnique(applied, c.name)
+		default:
+			result[k] = searchv1alpha1.RedactedValue
+			applied = appendUnique(applied, c.name)
+		}
+	}
+	slices.Sort(applied)
+	return result, applied
+}
+
+// Field applies the redaction rules to a custom field value. name is the field name without the
+// fieldSuffix. It returns the value to index, whether the field is kept, and the name of the rule
+// that redacted it, or "" when no rule matched. Masked values that are not strings are dropped,
+// because the mask would not fit the field's data type.
+func (r *Redactor) Field(name string, value interface{}) (result interface{}, keep bool, ruleName string) {
+	c := r.match(searchv1alpha1.RedactionTargetFields, name, value)
+	if c == nil {
+		return value, true, ""
+	}
+	if _, isString := value.(string); c.drop || !isString {
+		return nil, false, c.name
+	}
+	return searchv1alpha1.RedactedValue, true, c.name
+}
+
+func appendUnique(names []string, name string) []string {
+	if slices.Contains(names, name) {
+		return names
+	}
+	return append(names, name)
+}
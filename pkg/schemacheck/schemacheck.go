@@ -0,0 +1,363 @@
+// Copyright Contributors to the Open Cluster Management project
+
+// Package schemacheck checks collection rules against the discovery data and CRD schemas of a
+// cluster: that their apiGroups and kinds are served, and that the jsonPaths of custom fields
+// resolve in the structural schema of the kind's CRD with a type that fits the field's data type.
+// Like pkg/impact, it does not depend on the API types so that the CollectorConfig webhook can use
+// it.
+package schemacheck
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+var crdResource = apiextensionsv1.SchemeGroupVersion.WithResource("customresourcedefinitions")
+
+// Rule is the part of a collection rule that is checked.
+type Rule struct {
+	APIGroups []string
+	Kinds     []string
+	// Versions are the pinned versions. When empty, fields are checked against the preferred
+	// version, which the collector watches.
+	Versions []string
+	Fields   []Field
+}
+
+// Field is a custom field of a rule.
+type Field struct {
+	JSONPath string
+	// Type is the field's data type: string (or empty), integer, float, boolean or bytes.
+	Type string
+	// Transformed is set when transforms change the value, so its type is not checked.
+	Transformed bool
+}
+
+// Problem is a part of a rule that does not match the cluster.
+type Problem struct {
+	// Field is the rule field the problem is reported on, such as resourceSelector.kinds or
+	// fields[0].jsonPath.
+	Field   string
+	Message string
+}
+
+// Checker reads discovery data and CRDs.
+type Checker struct {
+	Discovery discovery.DiscoveryInterface
+	Dynamic   dynamic.Interface
+}
+
+// NewForConfig returns a checker for the cluster of cfg.
+func NewForConfig(cfg *rest.Config) (*Checker, error) {
+	dc, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	dyn, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Checker{Discovery: dc, Dynamic: dyn}, nil
+}
+
+// kindInfo is a kind found by discovery.
+type kindInfo struct {
+	resource  string
+	versions  sets.Set[string]
+	preferred string
+}
+
+// Snapshot is the discovery data of a cluster at one point in time. CRDs are read when a rule
+// needs them and kept for the lifetime of the snapshot. Check is safe for concurrent use.
+type Snapshot struct {
+	dynamic dynamic.Interface
+	groups  sets.Set[string]
+	kinds   map[schema.GroupKind]*kindInfo
+
+	mu   sync.Mutex
+	crds map[schema.GroupKind]*apiextensionsv1.CustomResourceDefinition
+}
+
+// Snapshot reads the served groups and kinds. Discovery returns the groups it could read along
+// with an error for the others; those are used when there are any.
+func (c *Checker) Snapshot() (*Snapshot, error) {
+	groups, lists, err := c.Discovery.ServerGroupsAndResources()
+	if err != nil && len(lists) == 0 {
+		return nil, err
+	}
+	s := &Snapshot{
+		dynamic: c.Dynamic,
+		groups:  sets.New[string](),
+		kinds:   map[schema.GroupKind]*kindInfo{},
+		crds:    map[schema.GroupKind]*apiextensionsv1.CustomResourceDefinition{},
+	}
+	preferred := map[string]string{}
+	for _, g := range groups {
+		s.groups.Insert(g.Name)
+		preferred[g.Name] = g.PreferredVersion.Version
+	}
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		s.groups.Insert(gv.Group)
+		for _, r := range list.APIResources {
+			if strings.Contains(r.Name, "/") {
+				continue
+			}
+			gk := schema.GroupKind{Group: gv.Group, Kind: r.Kind}
+			info := s.kinds[gk]
+			if info == nil {
+				info = &kindInfo{resource: r.Name, versions: sets.New[string]()}
+				s.kinds[gk] = info
+			}
+			info.versions.Insert(gv.Version)
+			if gv.Version == preferred[gv.Group] || info.preferred == "" {
+				info.preferred = gv.Version
+			}
+		}
+	}
+	return s, nil
+}
+
+// ServedVersions returns the sorted versions served for a kind, and false when the kind is not served.
+func (s *Snapshot) ServedVersions(group, kind string) ([]string, bool) {
+	info, ok := s.kinds[schema.GroupKind{Group: group, Kind: kind}]
+	if !ok {
+		return nil, false
+	}
+	return sets.List(info.versions), true
+}
+
+// Check returns the problems of a rule: apiGroups and kinds that are not served, and fields whose
+// jsonPath does not resolve in the CRD schema or whose type does not fit the schema type. Wildcard
+// groups and kinds are not checked. Fields are only checked for a single apiGroup and kind with a
+// CRD; a CRD that can't be read is reported as a problem on the fields.
+func (s *Snapshot) Check(ctx context.Context, rule Rule) []Problem {
+	var problems []Problem
+	for _, group := range rule.APIGroups {
+		if group != "*" && !s.groups.Has(group) {
+			problems = append(problems, Problem{Field: "resourceSelector.apiGroups",
+				Message: fmt.Sprintf("apiGroup %q is not served", group)})
+		}
+	}
+	// A kind is only reported when every apiGroup is served, so a mistyped group is reported once.
+	for _, kind := range rule.Kinds {
+		if kind == "*" || slices.Contains(rule.APIGroups, "*") {
+			continue
+		}
+		served, unknownGroup := false, false
+		for _, group := range rule.APIGroups {
+			unknownGroup = unknownGroup || !s.groups.Has(group)
+			_, ok := s.kinds[schema.GroupKind{Group: group, Kind: kind}]
+			served = served || ok
+		}
+		if !served && !unknownGroup {
+			problems = append(problems, Problem{Field: "resourceSelector.kinds",
+				Message: fmt.Sprintf("kind %q is not served in apiGroups %q", kind, rule.APIGroups)})
+		}
+	}
+	if len(rule.Fields) == 0 || len(rule.APIGroups) != 1 || len(rule.Kinds) != 1 {
+		return problems
+	}
+
+	gk := schema.GroupKind{Group: rule.APIGroups[0], Kind: rule.Kinds[0]}
+	info, ok := s.kinds[gk]
+	if !ok {
+		return problems
+	}
+	crd, err := s.crd(ctx, gk, info)
+	if err != nil {
+		return append(problems, Problem{Field: "fields",
+			Message: fmt.Sprintf("could not read the CRD of %s: %v", gk.String(), err)})
+	}
+	if crd == nil {
+		return problems
+	}
+	versions := rule.Versions
+	if len(versions) == 0 {
+		versions = []string{info.preferred}
+	}
+	for _, version := range versions {
+		root := versionSchema(crd, version)
+		if root == nil {
+			continue
+		}
+		for i, f := range rule.Fields {
+			problems = append(problems, checkField(root, f, fmt.Sprintf("fields[%d]", i), gk, version)...)
+		}
+	}
+	return problems
+}
+
+// crd returns the CRD that defines a kind, or nil for built-in and aggregated kinds.
+func (s *Snapshot) crd(ctx context.Context, gk schema.GroupKind,
+	info *kindInfo) (*apiextensionsv1.CustomResourceDefinition, error) {
+	s.mu.Lock()
+	crd, ok := s.crds[gk]
+	s.mu.Unlock()
+	if ok {
+		return crd, nil
+	}
+	if gk.Group == "" || !strings.Contains(gk.Group, ".") {
+		// Core and other built-in groups without a dot are never CRDs.
+		return s.keepCRD(gk, nil), nil
+	}
+	u, err := s.dynamic.Resource(crdResource).Get(ctx, info.resource+"."+gk.Group, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return s.keepCRD(gk, nil), nil
+	} else if err != nil {
+		return nil, err
+	}
+	crd = &apiextensionsv1.CustomResourceDefinition{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, crd); err != nil {
+		return nil, err
+	}
+	return s.keepCRD(gk, crd), nil
+}
+
+// keepCRD stores the CRD of a kind for the lifetime of the snapshot and returns it.
+func (s *Snapshot) keepCRD(gk schema.GroupKind,
+	crd *apiextensionsv1.CustomResourceDefinition) *apiextensionsv1.CustomResourceDefinition {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.crds[gk] = crd
+	return crd
+}
+
+func versionSchema(crd *apiextensionsv1.CustomResourceDefinition, version string) *apiextensionsv1.JSONSchemaProps {
+	for _, v := range crd.Spec.Versions {
+		if v.Name == version && v.Schema != nil {
+			return v.Schema.OpenAPIV3Schema
+		}
+	}
+	return nil
+}
+
+// checkField resolves a field's jsonPath in the schema and compares the type.
+func checkField(root *apiextensionsv1.JSONSchemaProps, f Field, path string, gk schema.GroupKind,
+	version string) []Problem {
+	normalized := "{" + strings.TrimSuffix(strings.TrimPrefix(f.JSONPath, "{"), "}") + "}"
+	parser, err := jsonpath.Parse("schemacheck", normalized)
+	if err != nil || len(parser.Root.Nodes) != 1 {
+		// The webhook rejects invalid jsonPaths; nothing to check.
+		return nil
+	}
+	list, ok := parser.Root.Nodes[0].(*jsonpath.ListNode)
+	if !ok {
+		return nil
+	}
+	resolved, missing := resolve(root, list.Nodes)
+	if missing != "" {
+		return []Problem{{Field: path + ".jsonPath", Message: fmt.Sprintf(
+			"%s does not resolve in the %s schema of %s: %s", f.JSONPath, version, gk.String(), missing)}}
+	}
+	if resolved == nil || f.Transformed || typeFits(f.Type, resolved) {
+		return nil
+	}
+	fieldType := f.Type
+	if fieldType == "" {
+		fieldType = "string"
+	}
+	return []Problem{{Field: path + ".type", Message: fmt.Sprintf(
+		"%s is %s in the %s schema of %s, which does not fit type %s",
+		f.JSONPath, schemaType(resolved), version, gk.String(), fieldType)}}
+}
+
+// resolve follows the nodes of a jsonPath through a structural schema. It returns the schema at
+// the path, or nil when the path leaves what the schema describes: preserved unknown fields,
+// objects without properties such as metadata, maps, and recursive descent. When a field is not
+// in the schema, it returns why.
+func resolve(s *apiextensionsv1.JSONSchemaProps, nodes []jsonpath.Node) (*apiextensionsv1.JSONSchemaProps, string) {
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *jsonpath.FieldNode:
+			if n.Value == "" {
+				continue
+			}
+			if p, ok := s.Properties[n.Value]; ok {
+				s = &p
+				continue
+			}
+			if opaque(s) {
+				return nil, ""
+			}
+			if s.Type != "object" {
+				return nil, fmt.Sprintf("field %q is read from %s, not an object", n.Value, schemaType(s))
+			}
+			return nil, fmt.Sprintf("field %q is not defined", n.Value)
+		case *jsonpath.ArrayNode, *jsonpath.FilterNode:
+			if s.Type != "array" {
+				if opaque(s) {
+					return nil, ""
+				}
+				return nil, fmt.Sprintf("an index or filter is used on %s, not an array", schemaType(s))
+			}
+			if s.Items == nil || s.Items.Schema == nil {
+				return nil, ""
+			}
+			s = s.Items.Schema
+		default:
+			return nil, ""
+		}
+	}
+	return s, ""
+}
+
+// opaque reports whether the schema does not list the fields below it, so a path can't be checked.
+func opaque(s *apiextensionsv1.JSONSchemaProps) bool {
+	return (s.XPreserveUnknownFields != nil && *s.XPreserveUnknownFields) || s.XEmbeddedResource ||
+		s.AdditionalProperties != nil || (s.Type == "object" && len(s.Properties) == 0) ||
+		(s.Type == "" && !s.XIntOrString)
+}
+
+// typeFits reports whether a value of the schema type can be converted to the field data type.
+// Fields are indexed as strings by default, so any scalar fits string.
+func typeFits(fieldType string, s *apiextensionsv1.JSONSchemaProps) bool {
+	if s.XIntOrString {
+		return fieldType != "boolean"
+	}
+	if s.Type == "" {
+		return true
+	}
+	switch fieldType {
+	case "", "string":
+		return s.Type != "object" && s.Type != "array"
+	case "integer":
+		return s.Type == "integer"
+	case "float":
+		return s.Type == "integer" || s.Type == "number"
+	case "boolean":
+		return s.Type == "boolean"
+	case "bytes":
+		return s.Type == "string" || s.Type == "integer"
+	}
+	return true
+}
+
+func schemaType(s *apiextensionsv1.JSONSchemaProps) string {
+	switch {
+	case s.XIntOrString:
+		return "an int-or-string"
+	case s.Type == "":
+		return "untyped"
+	case s.Type == "array" || s.Type == "object" || s.Type == "integer":
+		return "an " + s.Type
+	}
+	return "a " + s.Type
+}
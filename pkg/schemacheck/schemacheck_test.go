@@ -0,0 +1,166 @@
+// Copyright Contributors to the Open Cluster Management project
+package schemacheck
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func props(p map[string]apiextensionsv1.JSONSchemaProps) apiextensionsv1.JSONSchemaProps {
+	return apiextensionsv1.JSONSchemaProps{Type: "object", Properties: p}
+}
+
+// myAppCRD defines MyApp.example.com: v1beta1 has spec.replicas, spec.size (int-or-string),
+// spec.containers[].image, spec.labels (a map) and an opaque spec.config; v1alpha1 has spec.count.
+func myAppCRD(t *testing.T) *unstructured.Unstructured {
+	t.Helper()
+	preserve := true
+	v1beta1 := props(map[string]apiextensionsv1.JSONSchemaProps{
+		"metadata": {Type: "object"},
+		"spec": props(map[string]apiextensionsv1.JSONSchemaProps{
+			"replicas": {Type: "integer"},
+			"paused":   {Type: "boolean"},
+			"size":     {XIntOrString: true},
+			"containers": {Type: "array", Items: &apiextensionsv1.JSONSchemaPropsOrArray{
+				Schema: &apiextensionsv1.JSONSchemaProps{Type: "object", Properties: map[string]apiextensionsv1.JSONSchemaProps{
+					"image": {Type: "string"},
+				}},
+			}},
+			"labels": {Type: "object", AdditionalProperties: &apiextensionsv1.JSONSchemaPropsOrBool{
+				Allows: true, Schema: &apiextensionsv1.JSONSchemaProps{Type: "string"}}},
+			"config": {Type: "object", XPreserveUnknownFields: &preserve},
+		}),
+	})
+	v1alpha1 := props(map[string]apiextensionsv1.JSONSchemaProps{
+		"spec": props(map[string]apiextensionsv1.JSONSchemaProps{"count": {Type: "integer"}}),
+	})
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apiextensions.k8s.io/v1", Kind: "CustomResourceDefinition"},
+		ObjectMeta: metav1.ObjectMeta{Name: "myapps.example.com"},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: "example.com",
+			Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "myapps", Kind: "MyApp"},
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{Name: "v1alpha1", Served: true, Schema: &apiextensionsv1.CustomResourceValidation{OpenAPIV3Schema: &v1alpha1}},
+				{Name: "v1beta1", Served: true, Storage: true, Schema: &apiextensionsv1.CustomResourceValidation{OpenAPIV3Schema: &v1beta1}},
+			},
+		},
+	}
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(crd)
+	require.NoError(t, err)
+	return &unstructured.Unstructured{Object: obj}
+}
+
+func newTestSnapshot(t *testing.T) *Snapshot {
+	t.Helper()
+	dyn := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{crdResource: "CustomResourceDefinitionList"}, myAppCRD(t))
+	disc := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{{Name: "configmaps", Kind: "ConfigMap"}}},
+		{GroupVersion: "apps/v1", APIResources: []metav1.APIResource{{Name: "deployments", Kind: "Deployment"}}},
+		{GroupVersion: "example.com/v1beta1", APIResources: []metav1.APIResource{
+			{Name: "myapps", Kind: "MyApp"}, {Name: "myapps/status", Kind: "MyApp"}}},
+		{GroupVersion: "example.com/v1alpha1", APIResources: []metav1.APIResource{{Name: "myapps", Kind: "MyApp"}}},
+	}}}
+	s, err := (&Checker{Discovery: disc, Dynamic: dyn}).Snapshot()
+	require.NoError(t, err)
+	return s
+}
+
+func TestCheck_GroupsAndKinds(t *testing.T) {
+	s := newTestSnapshot(t)
+
+	tests := []struct {
+		name string
+		rule Rule
+		want []Problem
+	}{
+		{"served", Rule{APIGroups: []string{"", "apps"}, Kinds: []string{"ConfigMap", "Deployment"}}, nil},
+		{"wildcards", Rule{APIGroups: []string{"*"}, Kinds: []string{"Anything"}}, nil},
+		{"unknown group", Rule{APIGroups: []string{"argoproj.i0"}, Kinds: []string{"Application"}}, []Problem{
+			{Field: "resourceSelector.apiGroups", Message: `apiGroup "argoproj.i0" is not served`}}},
+		{"unknown kind", Rule{APIGroups: []string{"apps"}, Kinds: []string{"Deploymnet"}}, []Problem{
+			{Field: "resourceSelector.kinds", Message: `kind "Deploymnet" is not served in apiGroups ["apps"]`}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, s.Check(context.TODO(), tt.rule))
+		})
+	}
+}
+
+func TestCheck_Fields(t *testing.T) {
+	s := newTestSnapshot(t)
+
+	tests := []struct {
+		name     string
+		field    Field
+		versions []string
+		want     string
+	}{
+		{"resolves", Field{JSONPath: "{.spec.replicas}", Type: "integer"}, nil, ""},
+		{"array items", Field{JSONPath: ".spec.containers[*].image"}, nil, ""},
+		{"int-or-string as bytes", Field{JSONPath: ".spec.size", Type: "bytes"}, nil, ""},
+		{"map", Field{JSONPath: ".spec.labels['app.kubernetes.io/name']"}, nil, ""},
+		{"preserved unknown fields", Field{JSONPath: ".spec.config.anything.at.all"}, nil, ""},
+		{"metadata", Field{JSONPath: ".metadata.labels.app"}, nil, ""},
+		{"transformed", Field{JSONPath: ".spec.containers[0].image", Type: "integer", Transformed: true}, nil, ""},
+		{"missing field", Field{JSONPath: ".spec.replica"}, nil,
+			`fields[0].jsonPath: .spec.replica does not resolve in the v1beta1 schema of MyApp.example.com: field "replica" is not defined`},
+		{"index on an object", Field{JSONPath: ".spec[0]"}, nil,
+			"fields[0].jsonPath: .spec[0] does not resolve in the v1beta1 schema of MyApp.example.com: an index or filter is used on an object, not an array"},
+		{"field of a scalar", Field{JSONPath: ".spec.replicas.value"}, nil,
+			`fields[0].jsonPath: .spec.replicas.value does not resolve in the v1beta1 schema of MyApp.example.com: field "value" is read from an integer, not an object`},
+		{"type mismatch", Field{JSONPath: ".spec.containers[0].image", Type: "integer"}, nil,
+			"fields[0].type: .spec.containers[0].image is a string in the v1beta1 schema of MyApp.example.com, which does not fit type integer"},
+		{"object as string", Field{JSONPath: ".spec.containers"}, nil,
+			"fields[0].type: .spec.containers is an array in the v1beta1 schema of MyApp.example.com, which does not fit type string"},
+		{"pinned version", Field{JSONPath: ".spec.count", Type: "integer"}, []string{"v1alpha1"}, ""},
+		{"not in the preferred version", Field{JSONPath: ".spec.count", Type: "integer"}, nil,
+			`fields[0].jsonPath: .spec.count does not resolve in the v1beta1 schema of MyApp.example.com: field "count" is not defined`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := s.Check(context.TODO(), Rule{
+				APIGroups: []string{"example.com"}, Kinds: []string{"MyApp"}, Versions: tt.versions,
+				Fields: []Field{tt.field},
+			})
+			if tt.want == "" {
+				assert.Empty(t, problems)
+				return
+			}
+			require.Len(t, problems, 1)
+			assert.Equal(t, tt.want, problems[0].Field+": "+problems[0].Message)
+		})
+	}
+}
+
+// Fields of built-in kinds have no CRD schema to check.
+func TestCheck_BuiltInKindFieldsNotChecked(t *testing.T) {
+	s := newTestSnapshot(t)
+	problems := s.Check(context.TODO(), Rule{
+		APIGroups: []string{"apps"}, Kinds: []string{"Deployment"},
+		Fields: []Field{{JSONPath: ".spec.anything", Type: "boolean"}},
+	})
+	assert.Empty(t, problems)
+}
+
+func TestServedVersions(t *testing.T) {
+	s := newTestSnapshot(t)
+	versions, ok := s.ServedVersions("example.com", "MyApp")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"v1alpha1", "v1beta1"}, versions)
+	_, ok = s.ServedVersions("example.com", "Other")
+	assert.False(t, ok)
+}